@@ -17,6 +17,8 @@ import (
 	"github.com/nginxinc/kubernetes-ingress/internal/k8s"
 	"github.com/nginxinc/kubernetes-ingress/internal/metrics"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	"github.com/nginxinc/kubernetes-ingress/internal/validation"
+	conf_client "github.com/nginxinc/kubernetes-ingress/pkg/client/configuration/v1alpha1"
 	api_v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -103,6 +105,45 @@ The external address of the service is used when reporting the status of Ingress
 
 	prometheusMetricsListenPort = flag.Int("prometheus-metrics-listen-port", 9113,
 		"Set the port where the Prometheus metrics are exposed. [1023 - 65535]")
+
+	certificateExpiryWarningDays = flag.Int("certificate-expiry-warning-days", 30,
+		`Emit Warning events for the Ingress resources with TLS certificates that expire in less than the number of days.
+	The certificates are checked when an Ingress resource is updated and daily. Set to 0 to disable the events`)
+
+	enableCustomResources = flag.Bool("enable-custom-resources", false,
+		"Enable custom resources. Requires the VirtualServer and TransportServer CustomResourceDefinitions to be created in the cluster")
+
+	defaultBackendService = flag.String("default-backend-service", "",
+		`A Service that serves the requests for unknown hosts and the requests for the services of Ingress resources without endpoints.
+	The first port of the Service is used. Format: <namespace>/<name>. If not set, NGINX responds to such requests with 404 and 502 respectively`)
+
+	enableTLSPassthrough = flag.Bool("enable-tls-passthrough", false,
+		`Enable TLS passthrough for the Ingress resources with the nginx.org/ssl-passthrough annotation. Port 443 is served by a stream server,
+	which routes TLS connections by the server name and forwards the connections for the other hosts to the HTTPS servers`)
+
+	reloadBatchInterval = flag.Duration("reload-batch-interval", 0,
+		`Apply the changes of the resources that arrive within the interval after the previous change with a single reload of NGINX.
+	The Events of the resources are emitted before the reload, and the resources get Warning Events if the reload fails. Set to 0 to reload NGINX for every change`)
+
+	reloadBatchMaxLatency = flag.Duration("reload-batch-max-latency", 5*time.Second,
+		`The maximum time between the first change of a batch and the reload of NGINX. Requires -reload-batch-interval`)
+
+	configHistorySize = flag.Int("config-history-size", 0,
+		`Keep the NGINX configuration files of the last N reloads in /etc/nginx/history and expose the history on localhost
+	to list the versions, diff them and roll back. Set to 0 to disable the history`)
+
+	configHistoryListenPort = flag.Int("config-history-listen-port", 8082,
+		"Set the port on localhost where the configuration history is exposed. Requires -config-history-size")
+
+	enableConfigRollback = flag.Bool("enable-config-rollback", false,
+		`Enable the rollback to a version of the configuration history via POST /configs/rollback on the history port.
+	The endpoint has no authentication: anyone who can reach the port (for example, via kubectl port-forward or from
+	the pod) can change the configuration of NGINX. Requires -config-history-size`)
+
+	configRollbackPinTimeout = flag.Duration("config-rollback-pin-timeout", nginx.DefaultConfigPinTimeout,
+		`Keep the configuration restored by a rollback for at most the timeout when only the endpoints change. The changes
+	of the endpoints are applied after the timeout. Any other change of the resources ends the pinning immediately.
+	Requires -enable-config-rollback`)
 )
 
 func main() {
@@ -124,6 +165,41 @@ func main() {
 		glog.Fatalf("Invalid value for prometheus-metrics-listen-port: %v", metricsPortValidationError)
 	}
 
+	if *certificateExpiryWarningDays < 0 {
+		glog.Fatalf("Invalid value for certificate-expiry-warning-days: %v: must not be negative", *certificateExpiryWarningDays)
+	}
+
+	if *reloadBatchInterval < 0 {
+		glog.Fatalf("Invalid value for reload-batch-interval: %v: must not be negative", *reloadBatchInterval)
+	}
+
+	if *reloadBatchMaxLatency <= 0 {
+		glog.Fatalf("Invalid value for reload-batch-max-latency: %v: must be positive", *reloadBatchMaxLatency)
+	}
+
+	if *configHistorySize < 0 {
+		glog.Fatalf("Invalid value for config-history-size: %v: must not be negative", *configHistorySize)
+	}
+
+	if *enableConfigRollback && *configHistorySize == 0 {
+		glog.Fatal("enable-config-rollback flag requires -config-history-size")
+	}
+
+	if *configRollbackPinTimeout < 0 {
+		glog.Fatalf("Invalid value for config-rollback-pin-timeout: %v: must not be negative", *configRollbackPinTimeout)
+	}
+
+	configHistoryPortValidationError := validatePort(*configHistoryListenPort)
+	if configHistoryPortValidationError != nil {
+		glog.Fatalf("Invalid value for config-history-listen-port: %v", configHistoryPortValidationError)
+	}
+
+	if *defaultBackendService != "" {
+		if _, _, err := k8s.ParseNamespaceName(*defaultBackendService); err != nil {
+			glog.Fatalf("Invalid value for default-backend-service: %v", err)
+		}
+	}
+
 	var err error
 	allowedCIDRs, err := parseNginxStatusAllowCIDRs(*nginxStatusAllowCIDRs)
 	if err != nil {
@@ -155,6 +231,14 @@ func main() {
 		glog.Fatalf("Failed to create client: %v.", err)
 	}
 
+	var confClient *conf_client.ConfigurationV1alpha1Client
+	if *enableCustomResources {
+		confClient, err = conf_client.NewForConfig(config)
+		if err != nil {
+			glog.Fatalf("Failed to create a conf client: %v", err)
+		}
+	}
+
 	local := *proxyURL != ""
 
 	nginxConfTemplatePath := "nginx.tmpl"
@@ -180,13 +264,37 @@ func main() {
 	if err != nil {
 		glog.Fatalf("Error creating TemplateExecutor: %v", err)
 	}
-	ngxc := nginx.NewNginxController("/etc/nginx/", nginxBinaryPath, local)
+	var certificateCollector *metrics.CertificateCollector
+	var reloadCollector *metrics.ReloadCollector
+	if *enablePrometheusMetrics {
+		certificateCollector = metrics.NewCertificateCollector()
+		reloadCollector = metrics.NewReloadCollector()
+	}
+
+	ngxc := nginx.NewNginxController("/etc/nginx/", nginxBinaryPath, local, reloadCollector)
+
+	if *configHistorySize > 0 {
+		err = ngxc.EnableConfigHistory(*configHistorySize)
+		if err != nil {
+			glog.Fatalf("Error enabling the configuration history: %v", err)
+		}
+		ngxc.SetConfigPinTimeout(*configRollbackPinTimeout)
+	}
+
+	// the default server secret file can be added to the image
+	err = ngxc.RemoveStaleFiles(configs.DefaultServerSecretName)
+	if err != nil {
+		glog.Fatalf("Error removing stale NGINX files: %v", err)
+	}
+
+	var specialSecrets []*api_v1.Secret
 
 	if *defaultServerSecret != "" {
 		secret, err := getAndValidateSecret(kubeClient, *defaultServerSecret)
 		if err != nil {
 			glog.Fatalf("Error trying to get the default server TLS secret %v: %v", *defaultServerSecret, err)
 		}
+		specialSecrets = append(specialSecrets, secret)
 
 		bytes := configs.GenerateCertAndKeyFileContent(secret)
 		ngxc.AddOrUpdateSecretFile(configs.DefaultServerSecretName, bytes, nginx.TLSSecretFileMode)
@@ -202,6 +310,7 @@ func main() {
 		if err != nil {
 			glog.Fatalf("Error trying to get the wildcard TLS secret %v: %v", *wildcardTLSSecret, err)
 		}
+		specialSecrets = append(specialSecrets, secret)
 
 		bytes := configs.GenerateCertAndKeyFileContent(secret)
 		ngxc.AddOrUpdateSecretFile(configs.WildcardSecretName, bytes, nginx.TLSSecretFileMode)
@@ -241,12 +350,17 @@ func main() {
 	}
 
 	ngxConfig := configs.GenerateNginxMainConfig(cfg)
+	ngxConfig.TLSPassthrough = *enableTLSPassthrough
 	content, err := templateExecutor.ExecuteMainConfigTemplate(ngxConfig)
 	if err != nil {
 		glog.Fatalf("Error generating NGINX main config: %v", err)
 	}
-	ngxc.UpdateMainConfigFile(content)
+	// the main config file includes the config version file, so the version file must exist for the test of the main config file
 	ngxc.UpdateConfigVersionFile()
+	err = ngxc.UpdateMainConfigFile(content)
+	if err != nil {
+		glog.Fatalf("Error updating NGINX main config: %v", err)
+	}
 
 	nginxDone := make(chan error, 1)
 	ngxc.Start(nginxDone)
@@ -260,11 +374,15 @@ func main() {
 		}
 	}
 	isWildcardEnabled := *wildcardTLSSecret != ""
-	cnf := configs.NewConfigurator(ngxc, cfg, nginxAPI, templateExecutor, isWildcardEnabled)
+	cnf := configs.NewConfigurator(ngxc, cfg, nginxAPI, templateExecutor, isWildcardEnabled, *enableTLSPassthrough, certificateCollector)
+	for _, secret := range specialSecrets {
+		cnf.UpdateCertificateMetrics(secret)
+	}
 	controllerNamespace := os.Getenv("POD_NAMESPACE")
 
 	lbcInput := k8s.NewLoadBalancerControllerInput{
 		KubeClient:              kubeClient,
+		ConfClient:              confClient,
 		ResyncPeriod:            30 * time.Second,
 		Namespace:               *watchNamespace,
 		NginxConfigurator:       cnf,
@@ -278,23 +396,34 @@ func main() {
 		IsLeaderElectionEnabled: *leaderElectionEnabled,
 		WildcardTLSSecret:       *wildcardTLSSecret,
 		ConfigMaps:              *nginxConfigMaps,
+		CustomResourcesEnabled:  *enableCustomResources,
+		DefaultBackendService:   *defaultBackendService,
+
+		CertificateExpiryWarningDays: *certificateExpiryWarningDays,
+		TransportServerReservedPorts: getTransportServerReservedPorts(),
+		ReloadBatchInterval:          *reloadBatchInterval,
+		ReloadBatchMaxLatency:        *reloadBatchMaxLatency,
 	}
 
 	lbc := k8s.NewLoadBalancerController(lbcInput)
 
 	if *enablePrometheusMetrics {
 		if *nginxPlus {
-			go metrics.RunPrometheusListenerForNginxPlus(*prometheusMetricsListenPort, nginxAPI.GetClientPlus())
+			go metrics.RunPrometheusListenerForNginxPlus(*prometheusMetricsListenPort, nginxAPI.GetClientPlus(), certificateCollector, reloadCollector)
 		} else {
 			httpClient := getSocketClient("/var/run/nginx-status.sock")
 			client, err := metrics.NewNginxMetricsClient(&httpClient)
 			if err != nil {
 				glog.Fatalf("Error creating the Nginx client for Prometheus metrics: %v", err)
 			}
-			go metrics.RunPrometheusListenerForNginx(*prometheusMetricsListenPort, client)
+			go metrics.RunPrometheusListenerForNginx(*prometheusMetricsListenPort, client, certificateCollector, reloadCollector)
 		}
 	}
 
+	if *configHistorySize > 0 {
+		go nginx.RunConfigHistoryListener(*configHistoryListenPort, ngxc, *enableConfigRollback)
+	}
+
 	go handleTermination(lbc, ngxc, nginxDone)
 	lbc.Run()
 
@@ -362,7 +491,7 @@ func parseNginxStatusAllowCIDRs(input string) (cidrs []string, err error) {
 	cidrsArray := strings.Split(input, ",")
 	for _, cidr := range cidrsArray {
 		trimmedCidr := strings.TrimSpace(cidr)
-		err := validateCIDRorIP(trimmedCidr)
+		err := validation.ValidateCIDRorIP(trimmedCidr)
 		if err != nil {
 			return cidrs, err
 		}
@@ -371,23 +500,6 @@ func parseNginxStatusAllowCIDRs(input string) (cidrs []string, err error) {
 	return cidrs, nil
 }
 
-// validateCIDRorIP makes sure a given string is either a valid CIDR block or IP address.
-// It an error if it is not valid.
-func validateCIDRorIP(cidr string) error {
-	if cidr == "" {
-		return fmt.Errorf("invalid CIDR address: an empty string is an invalid CIDR block or IP address")
-	}
-	_, _, err := net.ParseCIDR(cidr)
-	if err == nil {
-		return nil
-	}
-	ip := net.ParseIP(cidr)
-	if ip == nil {
-		return fmt.Errorf("invalid IP address: %v", cidr)
-	}
-	return nil
-}
-
 // getAndValidateSecret gets and validates a secret.
 func getAndValidateSecret(kubeClient *kubernetes.Clientset, secretNsName string) (secret *api_v1.Secret, err error) {
 	ns, name, err := k8s.ParseNamespaceName(secretNsName)
@@ -399,8 +511,30 @@ func getAndValidateSecret(kubeClient *kubernetes.Clientset, secretNsName string)
 		return nil, fmt.Errorf("could not get %v: %v", secretNsName, err)
 	}
 	err = configs.ValidateTLSSecret(secret)
-	if err != nil {
+	if configs.IsCertificateValidityError(err) {
+		// NGINX can use a certificate outside of its validity period, so the Ingress controller can still start
+		glog.Warningf("%v has a certificate that is not valid now: %v", secretNsName, err)
+	} else if err != nil {
 		return nil, fmt.Errorf("%v is invalid: %v", secretNsName, err)
 	}
 	return secret, nil
 }
+
+// getTransportServerReservedPorts returns the TCP ports that the Ingress Controller uses besides the ports of the http
+// context: the port of the default server of the upstreams without endpoints, the status port (which also serves
+// the NGINX Plus API) and the ports of the listeners of the Ingress Controller
+func getTransportServerReservedPorts() []int {
+	ports := []int{8181}
+
+	if *nginxStatus {
+		ports = append(ports, *nginxStatusPort)
+	}
+	if *enablePrometheusMetrics {
+		ports = append(ports, *prometheusMetricsListenPort)
+	}
+	if *configHistorySize > 0 {
+		ports = append(ports, *configHistoryListenPort)
+	}
+
+	return ports
+}
@@ -81,24 +81,6 @@ func TestParseNginxStatusAllowCIDRs(t *testing.T) {
 	}
 }
 
-func TestValidateCIDRorIP(t *testing.T) {
-	badCIDRs := []string{"localhost", "thing", "~", "!!!", "", " ", "-1"}
-	for _, badCIDR := range badCIDRs {
-		err := validateCIDRorIP(badCIDR)
-		if err == nil {
-			t.Errorf(`Expected error for invalid CIDR "%v"\n`, badCIDR)
-		}
-	}
-
-	goodCIDRs := []string{"0.0.0.0/32", "0.0.0.0/0", "127.0.0.1/32", "127.0.0.0/24", "23.232.65.42"}
-	for _, goodCIDR := range goodCIDRs {
-		err := validateCIDRorIP(goodCIDR)
-		if err != nil {
-			t.Errorf("Error for valid CIDR: %v err: %v\n", goodCIDR, err)
-		}
-	}
-}
-
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
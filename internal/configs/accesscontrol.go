@@ -0,0 +1,73 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/validation"
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+// AllowListAnnotation is the annotation where the client IP addresses and CIDR blocks that are allowed access are specified.
+const AllowListAnnotation = "nginx.org/allow-list"
+
+// DenyListAnnotation is the annotation where the client IP addresses and CIDR blocks that are denied access are specified.
+const DenyListAnnotation = "nginx.org/deny-list"
+
+// ParseAccessControl parses the access control annotations of the Ingress. It returns nil if the
+// access control is not configured.
+func ParseAccessControl(ing *extensions.Ingress) (*AccessControl, error) {
+	allowList, allowExists := ing.Annotations[AllowListAnnotation]
+	denyList, denyExists := ing.Annotations[DenyListAnnotation]
+
+	if !allowExists && !denyExists {
+		return nil, nil
+	}
+
+	accessControl := &AccessControl{}
+
+	if allowExists {
+		allow, err := parseCIDRList(allowList)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", AllowListAnnotation, err)
+		}
+		accessControl.Allow = allow
+	}
+
+	if denyExists {
+		deny, err := parseCIDRList(denyList)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", DenyListAnnotation, err)
+		}
+		accessControl.Deny = deny
+	}
+
+	return accessControl, nil
+}
+
+// parseCIDRList parses a comma-separated list of IP addresses and CIDR blocks.
+func parseCIDRList(list string) ([]string, error) {
+	var cidrs []string
+	for _, cidr := range strings.Split(list, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if err := validation.ValidateCIDRorIP(cidr); err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+func getAccessControl(ingEx *IngressEx, cfg *Config) *AccessControl {
+	accessControl, err := ParseAccessControl(ingEx.Ingress)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		return nil
+	}
+	if accessControl != nil && cfg.RealIPHeader != "" && len(cfg.SetRealIPFrom) == 0 {
+		glog.Warningf("Ingress %s/%s: real-ip-header is set without set-real-ip-from, access control uses the address of the connection",
+			ingEx.Ingress.Namespace, ingEx.Ingress.Name)
+	}
+	return accessControl
+}
@@ -0,0 +1,125 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAccessControl(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    *AccessControl
+		msg         string
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    nil,
+			msg:         "no access control",
+		},
+		{
+			annotations: map[string]string{
+				AllowListAnnotation: "10.0.0.0/8, 192.168.1.1",
+			},
+			expected: &AccessControl{
+				Allow: []string{"10.0.0.0/8", "192.168.1.1"},
+			},
+			msg: "allow list",
+		},
+		{
+			annotations: map[string]string{
+				AllowListAnnotation: "10.0.0.0/8",
+				DenyListAnnotation:  "10.0.0.1,2001:db8::/32",
+			},
+			expected: &AccessControl{
+				Allow: []string{"10.0.0.0/8"},
+				Deny:  []string{"10.0.0.1", "2001:db8::/32"},
+			},
+			msg: "allow and deny lists",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := ParseAccessControl(createIngressWithAnnotations(test.annotations))
+		if err != nil {
+			t.Errorf("ParseAccessControl() returned an error for the case of %s: %v", test.msg, err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("ParseAccessControl() returned %+v, but expected %+v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
+func TestParseAccessControlFails(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		msg         string
+	}{
+		{
+			annotations: map[string]string{
+				AllowListAnnotation: "",
+			},
+			msg: "empty allow list",
+		},
+		{
+			annotations: map[string]string{
+				AllowListAnnotation: "10.0.0.0/8,",
+			},
+			msg: "allow list with an empty entry",
+		},
+		{
+			annotations: map[string]string{
+				DenyListAnnotation: "10.0.0.0/33",
+			},
+			msg: "deny list with an invalid CIDR",
+		},
+		{
+			annotations: map[string]string{
+				AllowListAnnotation: "localhost",
+			},
+			msg: "allow list with a host name",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseAccessControl(createIngressWithAnnotations(test.annotations))
+		if err == nil {
+			t.Errorf("ParseAccessControl() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestGenerateNginxCfgWithAccessControl(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[AllowListAnnotation] = "10.0.0.0/8"
+	cafeIngressEx.Ingress.Annotations[DenyListAnnotation] = "10.0.0.1"
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	expected := &AccessControl{
+		Allow: []string{"10.0.0.0/8"},
+		Deny:  []string{"10.0.0.1"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	if !reflect.DeepEqual(result.Servers[0].AccessControl, expected) {
+		t.Errorf("generateNginxCfg returned AccessControl %+v for a server, but expected %+v", result.Servers[0].AccessControl, expected)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if loc.AccessControl != nil {
+			t.Errorf("generateNginxCfg returned AccessControl %+v for location %v, but expected none", loc.AccessControl, loc.Path)
+		}
+	}
+
+	isMinion := true
+	result = cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, isMinion)
+	if result.Servers[0].AccessControl != nil {
+		t.Errorf("generateNginxCfg returned AccessControl %+v for the server of a minion, but expected none", result.Servers[0].AccessControl)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.AccessControl, expected) {
+			t.Errorf("generateNginxCfg returned AccessControl %+v for location %v of a minion, but expected %+v", loc.AccessControl, loc.Path, expected)
+		}
+	}
+}
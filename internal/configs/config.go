@@ -34,6 +34,8 @@ type Config struct {
 	ProxyProtocol                 bool
 	ProxyHideHeaders              []string
 	ProxyPassHeaders              []string
+	ProxySetHeaders               []HeaderRule
+	AddHeaders                    []HeaderRule
 	HSTS                          bool
 	HSTSBehindProxy               bool
 	HSTSMaxAge                    int64
@@ -78,6 +80,25 @@ type Config struct {
 	JWTToken    string
 	JWTLoginURL string
 
+	BasicAuthSecret string
+	BasicAuthRealm  string
+
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_verify_client
+	SSLVerifyClient string
+	SSLVerifyDepth  int
+
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_stapling
+	SSLStapling       bool
+	SSLStaplingVerify bool
+
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html
+	LimitReqRate       string
+	LimitReqKey        string
+	LimitReqZoneSize   string
+	LimitReqBurst      int
+	LimitReqNoDelay    bool
+	LimitReqRejectCode int
+
 	Ports    []int
 	SSLPorts []int
 }
@@ -104,6 +125,9 @@ func NewDefaultConfig() *Config {
 		ResolverIPV6:               true,
 		MainKeepaliveTimeout:       "65s",
 		MainKeepaliveRequests:      100,
+		LimitReqKey:                "$binary_remote_addr",
+		LimitReqZoneSize:           "10m",
+		LimitReqRejectCode:         503,
 	}
 }
 
@@ -161,6 +185,20 @@ func ParseConfigMap(cfgm *api_v1.ConfigMap, nginxPlus bool) *Config {
 			cfg.ProxyPassHeaders = proxyPassHeaders
 		}
 	}
+	if proxySetHeaders, exists := cfgm.Data["proxy-set-headers"]; exists {
+		if rules, err := ParseProxySetHeaders(proxySetHeaders); err != nil {
+			glog.Errorf("Configmap %s/%s: Invalid value for the proxy-set-headers key: %v", cfgm.GetNamespace(), cfgm.GetName(), err)
+		} else {
+			cfg.ProxySetHeaders = rules
+		}
+	}
+	if addHeaders, exists := cfgm.Data["add-headers"]; exists {
+		if rules, err := ParseAddHeaders(addHeaders); err != nil {
+			glog.Errorf("Configmap %s/%s: Invalid value for the add-headers key: %v", cfgm.GetNamespace(), cfgm.GetName(), err)
+		} else {
+			cfg.AddHeaders = rules
+		}
+	}
 	if clientMaxBodySize, exists := cfgm.Data["client-max-body-size"]; exists {
 		cfg.ClientMaxBodySize = clientMaxBodySize
 	}
@@ -273,6 +311,20 @@ func ParseConfigMap(cfgm *api_v1.ConfigMap, nginxPlus bool) *Config {
 	if sslCiphers, exists := cfgm.Data["ssl-ciphers"]; exists {
 		cfg.MainServerSSLCiphers = strings.Trim(sslCiphers, "\n")
 	}
+	if sslStapling, exists, err := GetMapKeyAsBool(cfgm.Data, "ssl-stapling", cfgm); exists {
+		if err != nil {
+			glog.Error(err)
+		} else {
+			cfg.SSLStapling = sslStapling
+		}
+	}
+	if sslStaplingVerify, exists, err := GetMapKeyAsBool(cfgm.Data, "ssl-stapling-verify", cfgm); exists {
+		if err != nil {
+			glog.Error(err)
+		} else {
+			cfg.SSLStaplingVerify = sslStaplingVerify
+		}
+	}
 	if sslDHParamFile, exists := cfgm.Data["ssl-dhparam-file"]; exists {
 		sslDHParamFile = strings.Trim(sslDHParamFile, "\n")
 		cfg.MainServerSSLDHParamFileContent = &sslDHParamFile
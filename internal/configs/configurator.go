@@ -2,21 +2,29 @@ package configs
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/metrics"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
 	api_v1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const emptyHost = ""
 
+const defaultBasicAuthRealm = "Restricted"
+
 const pemFileNameForMissingTLSSecret = "/etc/nginx/secrets/default"
 const pemFileNameForWildcardTLSSecret = "/etc/nginx/secrets/wildcard"
 
@@ -32,6 +40,21 @@ const JWTKeyKey = "jwk"
 // JWTKeyAnnotation is the annotation where the Secret with a JWK is specified.
 const JWTKeyAnnotation = "nginx.com/jwt-key"
 
+// BasicAuthSecretAnnotation is the annotation where the Secret with an htpasswd file is specified.
+const BasicAuthSecretAnnotation = "nginx.org/basic-auth-secret"
+
+// ClientCASecretAnnotation is the annotation where the Secret with the CA for the verification of client certificates is specified.
+const ClientCASecretAnnotation = "nginx.org/ssl-client-ca-secret"
+
+// TrustedCertSecretAnnotation is the annotation where the Secret with the chain of certificates for the verification of OCSP responses is specified.
+const TrustedCertSecretAnnotation = "nginx.org/ssl-trusted-certificate-secret"
+
+// SplitsAnnotation is the annotation where the traffic splits for the services of an Ingress are specified.
+const SplitsAnnotation = "nginx.org/splits"
+
+// MatchesAnnotation is the annotation where the conditional routing rules for the services of an Ingress are specified.
+const MatchesAnnotation = "nginx.org/matches"
+
 // Configurator transforms an Ingress resource into NGINX Configuration
 type Configurator struct {
 	nginx             *nginx.Controller
@@ -40,11 +63,25 @@ type Configurator struct {
 	templateExecutor  *TemplateExecutor
 	ingresses         map[string]*IngressEx
 	minions           map[string]map[string]bool
+	virtualServers    map[string]*VirtualServerEx
+	transportServers  map[string]*TransportServerEx
+	limitReqZones     map[string][]LimitReqZone
 	isWildcardEnabled bool
+	// isTLSPassthroughEnabled is true if the stream server on port 443 routes the TLS connections by the server name
+	isTLSPassthroughEnabled bool
+	// tlsPassthroughExcludedHosts are the hosts of the stream server of the current main NGINX configuration file,
+	// which are passed to the internal HTTPS listener despite matching a wildcard host with TLS passthrough
+	tlsPassthroughExcludedHosts []string
+	// defaultBackendEndpoints are the endpoints of the default backend Service, which replace the default server
+	// in the upstreams of the services without endpoints
+	defaultBackendEndpoints []string
+	// certificateCollector is nil if the Prometheus metrics are not enabled
+	certificateCollector *metrics.CertificateCollector
 }
 
 // NewConfigurator creates a new Configurator
-func NewConfigurator(nginx *nginx.Controller, config *Config, nginxAPI *nginx.NginxAPIController, templateExecutor *TemplateExecutor, isWildcardEnabled bool) *Configurator {
+func NewConfigurator(nginx *nginx.Controller, config *Config, nginxAPI *nginx.NginxAPIController, templateExecutor *TemplateExecutor, isWildcardEnabled bool, isTLSPassthroughEnabled bool,
+	certificateCollector *metrics.CertificateCollector) *Configurator {
 	cnf := Configurator{
 		nginx:             nginx,
 		config:            config,
@@ -52,11 +89,28 @@ func NewConfigurator(nginx *nginx.Controller, config *Config, nginxAPI *nginx.Ng
 		ingresses:         make(map[string]*IngressEx),
 		templateExecutor:  templateExecutor,
 		minions:           make(map[string]map[string]bool),
+		virtualServers:    make(map[string]*VirtualServerEx),
+		transportServers:  make(map[string]*TransportServerEx),
+		limitReqZones:     make(map[string][]LimitReqZone),
 		isWildcardEnabled: isWildcardEnabled,
+
+		isTLSPassthroughEnabled: isTLSPassthroughEnabled,
+		certificateCollector:    certificateCollector,
 	}
 	return &cnf
 }
 
+// StartReloadBatch starts a batch of changes of the resources. The reloads of NGINX are postponed until EndReloadBatch is called,
+// so the methods of the Configurator don't return the errors of the reloads during the batch.
+func (cnf *Configurator) StartReloadBatch() {
+	cnf.nginx.StartReloadBatch()
+}
+
+// EndReloadBatch ends a batch of changes of the resources and reloads NGINX once if any of the changes required a reload.
+func (cnf *Configurator) EndReloadBatch() error {
+	return cnf.nginx.EndReloadBatch()
+}
+
 // AddOrUpdateDHParam creates a dhparam file with the content of the string.
 func (cnf *Configurator) AddOrUpdateDHParam(content string) (string, error) {
 	return cnf.nginx.AddOrUpdateDHParam(content)
@@ -74,8 +128,16 @@ func (cnf *Configurator) AddOrUpdateIngress(ingEx *IngressEx) error {
 }
 
 func (cnf *Configurator) addOrUpdateIngress(ingEx *IngressEx) error {
+	if isTLSPassthroughIngress(ingEx) {
+		return cnf.addOrUpdateTLSPassthroughIngress(ingEx)
+	}
+
 	pems := cnf.updateTLSSecrets(ingEx)
 	cnf.updateJWTSecret(ingEx.JWTKey)
+	cnf.updateBasicAuthSecret(ingEx.BasicAuthSecret)
+	cnf.updateClientCASecret(ingEx.ClientCASecret)
+	cnf.updateTrustedCertSecret(ingEx.TrustedCertSecret)
+	cnf.updateUpstreamTLSSecrets(ingEx)
 	isMinion := false
 	nginxCfg := cnf.generateNginxCfg(ingEx, pems, isMinion)
 	name := objectMetaToFileName(&ingEx.Ingress.ObjectMeta)
@@ -83,8 +145,21 @@ func (cnf *Configurator) addOrUpdateIngress(ingEx *IngressEx) error {
 	if err != nil {
 		return fmt.Errorf("Error generating Ingress Config %v: %v", name, err)
 	}
-	cnf.nginx.UpdateIngressConfigFile(name, content)
+	if err := cnf.addLimitReqZones(name, nginxCfg.LimitReqZones); err != nil {
+		return err
+	}
+	if err := cnf.nginx.UpdateIngressConfigFile(name, content); err != nil {
+		return err
+	}
+	if err := cnf.updateLimitReqZones(name, nginxCfg.LimitReqZones); err != nil {
+		return err
+	}
+
+	prev, exists := cnf.ingresses[name]
 	cnf.ingresses[name] = ingEx
+	if (exists && isTLSPassthroughIngress(prev)) || cnf.isTLSPassthroughExcludedHostsChanged() {
+		return cnf.updateMainConfig()
+	}
 	return nil
 }
 
@@ -106,20 +181,53 @@ func (cnf *Configurator) addOrUpdateMergeableIngress(mergeableIngs *MergeableIng
 	if err != nil {
 		return fmt.Errorf("Error generating Ingress Config %v: %v", name, err)
 	}
-	cnf.nginx.UpdateIngressConfigFile(name, content)
+	if err := cnf.addLimitReqZones(name, nginxCfg.LimitReqZones); err != nil {
+		return err
+	}
+	if err := cnf.nginx.UpdateIngressConfigFile(name, content); err != nil {
+		return err
+	}
+	if err := cnf.updateLimitReqZones(name, nginxCfg.LimitReqZones); err != nil {
+		return err
+	}
 	cnf.ingresses[name] = mergeableIngs.Master
 	cnf.minions[name] = make(map[string]bool)
 	for _, minion := range mergeableIngs.Minions {
 		minionName := objectMetaToFileName(&minion.Ingress.ObjectMeta)
 		cnf.minions[name][minionName] = true
 	}
+	if cnf.isTLSPassthroughExcludedHostsChanged() {
+		return cnf.updateMainConfig()
+	}
 	return nil
 }
 
+// addOrUpdateIngressWithErrors adds or updates NGINX configuration for the Ingress resource during an update of many
+// resources. The error is recorded in resErrs, so that the update of the other resources continues.
+func (cnf *Configurator) addOrUpdateIngressWithErrors(ingEx *IngressEx, resErrs *ResourceErrors) bool {
+	if err := cnf.addOrUpdateIngress(ingEx); err != nil {
+		resErrs.addIngressError(ingEx, fmt.Errorf("Error adding or updating ingress %v/%v: %v", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err))
+		return false
+	}
+	return true
+}
+
+// addOrUpdateMergeableIngressWithErrors is the same as addOrUpdateIngressWithErrors for the Ingress resources with Mergeable Types
+func (cnf *Configurator) addOrUpdateMergeableIngressWithErrors(mergeableIngs *MergeableIngresses, resErrs *ResourceErrors) bool {
+	if err := cnf.addOrUpdateMergeableIngress(mergeableIngs); err != nil {
+		resErrs.addMergeableIngressError(mergeableIngs, fmt.Errorf("Error adding or updating mergeableIngress %v/%v: %v", mergeableIngs.Master.Ingress.Namespace, mergeableIngs.Master.Ingress.Name, err))
+		return false
+	}
+	return true
+}
+
 func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *MergeableIngresses) IngressNginxConfig {
 	var masterServer Server
 	var locations []Location
 	var upstreams []Upstream
+	var splitClients []SplitClient
+	var maps []Map
+	var limitReqZones []LimitReqZone
 	healthChecks := make(map[string]HealthCheck)
 	var keepalive string
 	var removedAnnotations []string
@@ -132,12 +240,19 @@ func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *Me
 
 	pems := cnf.updateTLSSecrets(mergeableIngs.Master)
 	cnf.updateJWTSecret(mergeableIngs.Master.JWTKey)
+	cnf.updateBasicAuthSecret(mergeableIngs.Master.BasicAuthSecret)
+	cnf.updateClientCASecret(mergeableIngs.Master.ClientCASecret)
+	cnf.updateTrustedCertSecret(mergeableIngs.Master.TrustedCertSecret)
+	cnf.updateUpstreamTLSSecrets(mergeableIngs.Master)
 
 	isMinion := false
 	masterNginxCfg := cnf.generateNginxCfg(mergeableIngs.Master, pems, isMinion)
 
 	masterServer = masterNginxCfg.Servers[0]
 	masterServer.Locations = []Location{}
+	// the minions inherit the external authentication and the error pages of the master and use their own locations
+	masterServer.AuthRequestLocations = nil
+	masterServer.ErrorPageLocations = nil
 
 	for _, val := range masterNginxCfg.Upstreams {
 		upstreams = append(upstreams, val)
@@ -146,7 +261,12 @@ func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *Me
 		keepalive = masterNginxCfg.Keepalive
 	}
 
-	minions := mergeableIngs.Minions
+	// the order of the minions determines the order of their regex locations, which must not change between the updates
+	minions := make([]*IngressEx, len(mergeableIngs.Minions))
+	copy(minions, mergeableIngs.Minions)
+	sort.SliceStable(minions, func(i, j int) bool {
+		return minions[i].String() < minions[j].String()
+	})
 	for _, minion := range minions {
 		// Remove the default backend so that "/" will not be generated
 		minion.Ingress.Spec.Backend = nil
@@ -154,6 +274,15 @@ func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *Me
 		// Add acceptable master annotations to minion
 		mergeMasterAnnotationsIntoMinion(minion.Ingress.Annotations, mergeableIngs.Master.Ingress.Annotations)
 
+		if externalAuth := getExternalAuth(minion); externalAuth != nil && externalAuth.Service != nil {
+			addMasterBackendToMinion(mergeableIngs.Master, minion, externalAuth.Service)
+		}
+		for _, rule := range getErrorPages(minion) {
+			if rule.Service != nil {
+				addMasterBackendToMinion(mergeableIngs.Master, minion, rule.Service)
+			}
+		}
+
 		removedAnnotations = filterMinionAnnotations(minion.Ingress.Annotations)
 		if len(removedAnnotations) != 0 {
 			glog.Errorf("Ingress Resource %v/%v with the annotation 'nginx.org/mergeable-ingress-type' set to 'minion' cannot contain the %v annotation(s). They will be ignored",
@@ -162,6 +291,8 @@ func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *Me
 
 		pems := cnf.updateTLSSecrets(minion)
 		cnf.updateJWTSecret(minion.JWTKey)
+		cnf.updateBasicAuthSecret(minion.BasicAuthSecret)
+		cnf.updateUpstreamTLSSecrets(minion)
 		isMinion := true
 		nginxCfg := cnf.generateNginxCfg(minion, pems, isMinion)
 
@@ -174,55 +305,133 @@ func (cnf *Configurator) generateNginxCfgForMergeableIngresses(mergeableIngs *Me
 				healthChecks[hcName] = healthCheck
 			}
 			masterServer.JWTRedirectLocations = append(masterServer.JWTRedirectLocations, server.JWTRedirectLocations...)
+			masterServer.AuthRequestLocations = append(masterServer.AuthRequestLocations, server.AuthRequestLocations...)
+			masterServer.ErrorPageLocations = append(masterServer.ErrorPageLocations, server.ErrorPageLocations...)
 		}
 
 		for _, val := range nginxCfg.Upstreams {
 			upstreams = append(upstreams, val)
 		}
+		splitClients = append(splitClients, nginxCfg.SplitClients...)
+		maps = append(maps, nginxCfg.Maps...)
+		limitReqZones = append(limitReqZones, nginxCfg.LimitReqZones...)
 	}
 
+	sortLocations(locations)
 	masterServer.HealthChecks = healthChecks
 	masterServer.Locations = locations
 
 	return IngressNginxConfig{
-		Servers:   []Server{masterServer},
-		Upstreams: upstreams,
-		Keepalive: keepalive,
-		Ingress:   masterNginxCfg.Ingress,
+		Servers:       []Server{masterServer},
+		Upstreams:     upstreams,
+		SplitClients:  splitClients,
+		Maps:          maps,
+		LimitReqZones: limitReqZones,
+		Keepalive:     keepalive,
+		Ingress:       masterNginxCfg.Ingress,
 	}
 }
 
-func (cnf *Configurator) updateTLSSecrets(ingEx *IngressEx) map[string]string {
-	pems := make(map[string]string)
+// updateTLSSecrets writes the TLS Secrets of the Ingress to disk and returns the names of the pem files for every host.
+// A host can have several Secrets, for example, with an RSA and an ECDSA certificate, if it is listed in several TLS sections.
+func (cnf *Configurator) updateTLSSecrets(ingEx *IngressEx) map[string][]string {
+	pems := make(map[string][]string)
 
 	for _, tls := range ingEx.Ingress.Spec.TLS {
 		secretName := tls.SecretName
 
 		pemFileName := pemFileNameForMissingTLSSecret
+		secret, secretExists := ingEx.TLSSecrets[secretName]
 		if secretName == "" && cnf.isWildcardEnabled {
 			pemFileName = pemFileNameForWildcardTLSSecret
-		} else if secret, exists := ingEx.TLSSecrets[secretName]; exists {
+		} else if secretExists {
 			pemFileName = cnf.addOrUpdateSecret(secret)
 		}
 
-		for _, host := range tls.Hosts {
-			pems[host] = pemFileName
+		hosts := tls.Hosts
+		if len(hosts) == 0 {
+			hosts = []string{emptyHost}
 		}
-		if len(tls.Hosts) == 0 {
-			pems[emptyHost] = pemFileName
+		for _, host := range hosts {
+			hostPemFileName := pemFileName
+			// a certificate that doesn't cover the host is handled as a missing Secret, so that clients don't get a wrong certificate
+			if secretExists && host != emptyHost {
+				if err := ValidateTLSSecretHost(secret, host); err != nil {
+					glog.Warningf("Ingress %s/%s: the certificate of the Secret %v is not used for host %v: %v",
+						ingEx.Ingress.Namespace, ingEx.Ingress.Name, secretName, host, err)
+					hostPemFileName = pemFileNameForMissingTLSSecret
+				}
+			}
+			pems[host] = append(pems[host], hostPemFileName)
 		}
 	}
 
 	return pems
 }
 
+// createSSLCertificates creates the certificates of the server from the pem files of the host. The missing Secrets are skipped
+// as long as the host has a valid certificate, otherwise the only certificate is the one of a missing Secret.
+func createSSLCertificates(ingEx *IngressEx, host string, pemFiles []string) []SSLCertificate {
+	var certs []SSLCertificate
+	added := make(map[string]bool)
+	missing := false
+
+	for _, pemFile := range pemFiles {
+		if pemFile == pemFileNameForMissingTLSSecret {
+			missing = true
+			continue
+		}
+		if added[pemFile] {
+			continue
+		}
+		certs = append(certs, SSLCertificate{
+			Certificate: pemFile,
+			Key:         pemFile,
+		})
+		added[pemFile] = true
+	}
+
+	if len(certs) == 0 {
+		return []SSLCertificate{
+			{
+				Certificate: pemFileNameForMissingTLSSecret,
+				Key:         pemFileNameForMissingTLSSecret,
+			},
+		}
+	}
+	if missing {
+		glog.Warningf("Ingress %s/%s: some TLS Secrets of host %v are missing or invalid, using the other certificates",
+			ingEx.Ingress.Namespace, ingEx.Ingress.Name, host)
+	}
+
+	return certs
+}
+
 func (cnf *Configurator) updateJWTSecret(jwtKey JWTKey) {
 	if cnf.isPlus() && jwtKey.Secret != nil {
 		cnf.addOrUpdateSecret(jwtKey.Secret)
 	}
 }
 
-func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]string, isMinion bool) IngressNginxConfig {
+func (cnf *Configurator) updateBasicAuthSecret(basicAuthSecret BasicAuthSecret) {
+	if basicAuthSecret.Secret != nil {
+		cnf.addOrUpdateSecret(basicAuthSecret.Secret)
+	}
+}
+
+func (cnf *Configurator) updateClientCASecret(clientCASecret ClientCASecret) {
+	if clientCASecret.Secret != nil {
+		cnf.addOrUpdateSecret(clientCASecret.Secret)
+	}
+}
+
+func (cnf *Configurator) updateTrustedCertSecret(trustedCertSecret TrustedCertSecret) {
+	if trustedCertSecret.Secret != nil {
+		cnf.addOrUpdateSecret(trustedCertSecret.Secret)
+	}
+}
+
+func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string][]string, isMinion bool) IngressNginxConfig {
 	ingCfg := cnf.createConfig(ingEx)
 
 	upstreams := make(map[string]Upstream)
@@ -233,6 +442,19 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 	rewrites := getRewrites(ingEx)
 	sslServices := getSSLServices(ingEx)
 	grpcServices := getGrpcServices(ingEx)
+	proxySSLs := make(map[string]*ProxySSL)
+	for serviceName, upstreamTLS := range getUpstreamTLS(ingEx) {
+		proxySSLs[serviceName] = cnf.createProxySSL(ingEx, upstreamTLS)
+	}
+	splits := getSplits(ingEx)
+	splitClients := make(map[string]SplitClient)
+	matches := getMatches(ingEx)
+	pathTypes := getPathTypes(ingEx)
+	regexRewrites := getRegexRewrites(ingEx)
+	appRoots := getAppRoots(ingEx)
+	pathProxySetHeaders := getPathProxySetHeaders(ingEx)
+	pathAddHeaders := getPathAddHeaders(ingEx)
+	var maps []Map
 
 	// HTTP2 is required for gRPC to function
 	if len(grpcServices) > 0 && !ingCfg.HTTP2 {
@@ -252,6 +474,37 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 		}
 	}
 
+	var limitReqZones []LimitReqZone
+	var limitReq *LimitReq
+	if ingCfg.LimitReqRate != "" {
+		zone := LimitReqZone{
+			Key:  ingCfg.LimitReqKey,
+			Size: ingCfg.LimitReqZoneSize,
+			Rate: ingCfg.LimitReqRate,
+		}
+		zone.Name = getNameForLimitReqZone(ingEx.Ingress, zone)
+		limitReqZones = append(limitReqZones, zone)
+		limitReq = &LimitReq{
+			Zone:       zone.Name,
+			Burst:      ingCfg.LimitReqBurst,
+			NoDelay:    ingCfg.LimitReqNoDelay,
+			RejectCode: ingCfg.LimitReqRejectCode,
+		}
+	}
+
+	externalAuth := getExternalAuth(ingEx)
+	errorPageRules := getErrorPages(ingEx)
+	accessControl := getAccessControl(ingEx, &ingCfg)
+
+	var cors *CORS
+	if corsPolicy := getCORSPolicy(ingEx); corsPolicy != nil {
+		var originMap *Map
+		cors, originMap = createCORS(ingEx.Ingress, corsPolicy)
+		if originMap != nil {
+			maps = append(maps, *originMap)
+		}
+	}
+
 	var servers []Server
 
 	for _, rule := range ingEx.Ingress.Spec.Rules {
@@ -259,9 +512,9 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 			continue
 		}
 
-		serverName := rule.Host
+		serverName := getServerNameForHost(rule.Host)
 
-		statusZone := rule.Host
+		statusZone := getNameForHost(rule.Host)
 
 		server := Server{
 			Name:                  serverName,
@@ -285,11 +538,43 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 			SSLPorts:              ingCfg.SSLPorts,
 		}
 
-		if pemFile, ok := pems[serverName]; ok {
+		if pemFiles, ok := getPemFilesForHost(pems, rule.Host); ok {
 			server.SSL = true
-			server.SSLCertificate = pemFile
-			server.SSLCertificateKey = pemFile
-			if pemFile == pemFileNameForMissingTLSSecret {
+			server.SSLCertificates = createSSLCertificates(ingEx, rule.Host, pemFiles)
+			if server.SSLCertificates[0].Certificate == pemFileNameForMissingTLSSecret {
+				server.SSLCiphers = "NULL"
+			}
+			if cnf.isTLSPassthroughEnabled {
+				setTLSPassthroughListener(&server)
+			}
+		}
+
+		if !isMinion && server.SSL && ingCfg.SSLStapling {
+			server.SSLStapling = &SSLStapling{
+				Verify: ingCfg.SSLStaplingVerify,
+			}
+			if ingEx.TrustedCertSecret.Name != "" {
+				if ingEx.TrustedCertSecret.Secret != nil {
+					server.SSLStapling.TrustedCertificate = cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.TrustedCertSecret.Name)
+				} else {
+					// without the chain, the OCSP responses fail the verification and are not stapled, but the connections are still accepted
+					glog.Warningf("Ingress %s/%s: the Secret %v of %v is missing or invalid", ingEx.Ingress.Namespace, ingEx.Ingress.Name,
+						ingEx.TrustedCertSecret.Name, TrustedCertSecretAnnotation)
+				}
+			}
+		}
+
+		if !isMinion && server.SSL && ingEx.ClientCASecret.Name != "" {
+			if ingEx.ClientCASecret.Secret != nil {
+				server.ClientCertVerification = &ClientCertVerification{
+					CA:     cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.ClientCASecret.Name),
+					Verify: ingCfg.SSLVerifyClient,
+					Depth:  ingCfg.SSLVerifyDepth,
+				}
+			} else {
+				// without the CA, clients can't be verified, so TLS connections to the server are rejected
+				glog.Warningf("Ingress %s/%s: the CA Secret %v is missing or invalid, rejecting TLS connections for host %v",
+					ingEx.Ingress.Namespace, ingEx.Ingress.Name, ingEx.ClientCASecret.Name, rule.Host)
 				server.SSLCiphers = "NULL"
 			}
 		}
@@ -312,6 +597,29 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 			}
 		}
 
+		var authRequest *AuthRequest
+		if externalAuth != nil {
+			var authRequestLocation AuthRequestLocation
+			authRequestLocation, authRequest = cnf.createAuthRequest(ingEx, externalAuth, upstreams, &ingCfg)
+			server.AuthRequestLocations = append(server.AuthRequestLocations, authRequestLocation)
+		}
+
+		var errorPages []ErrorPage
+		if len(errorPageRules) > 0 {
+			server.ErrorPageLocations, errorPages = cnf.createErrorPages(ingEx, errorPageRules, upstreams, &ingCfg)
+		}
+
+		if !isMinion {
+			server.AccessControl = accessControl
+		}
+
+		if !isMinion && ingEx.BasicAuthSecret.Name != "" {
+			server.BasicAuth = &BasicAuth{
+				Secret: cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.BasicAuthSecret.Name),
+				Realm:  ingCfg.BasicAuthRealm,
+			}
+		}
+
 		var locations []Location
 		healthChecks := make(map[string]HealthCheck)
 
@@ -330,41 +638,162 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 		}
 
 		for _, path := range rule.HTTP.Paths {
+			pathType := pathTypes.get(pathOrDefault(path.Path))
+			if pathType != "" {
+				if err := validatePath(pathOrDefault(path.Path), pathType); err != nil {
+					glog.Errorf("Ingress %s/%s: %v, ignoring the path", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+					continue
+				}
+			}
+			locPath := getLocationPath(pathOrDefault(path.Path), pathType)
+
 			upsName := getNameForUpstream(ingEx.Ingress, rule.Host, &path.Backend)
 
+			backends := []extensions.IngressBackend{path.Backend}
+			serviceSplits, isSplit := splits[path.Backend.ServiceName]
+			if isSplit {
+				backends = GetSplitBackends(&path.Backend, serviceSplits)
+			}
+
 			if ingCfg.HealthCheckEnabled {
-				if hc, exists := ingEx.HealthChecks[path.Backend.ServiceName+path.Backend.ServicePort.String()]; exists {
-					healthChecks[upsName] = cnf.createHealthCheck(hc, upsName, &ingCfg)
+				for i := range backends {
+					name := getNameForUpstream(ingEx.Ingress, rule.Host, &backends[i])
+					if hc, exists := ingEx.HealthChecks[backends[i].ServiceName+backends[i].ServicePort.String()]; exists {
+						healthChecks[name] = cnf.createHealthCheck(hc, name, &ingCfg)
+					}
 				}
 			}
 
-			if _, exists := upstreams[upsName]; !exists {
-				upstream := cnf.createUpstream(ingEx, upsName, &path.Backend, ingEx.Ingress.Namespace, spServices[path.Backend.ServiceName], &ingCfg)
-				upstreams[upsName] = upstream
+			var locUpstream Upstream
+			rewrite := rewrites[path.Backend.ServiceName]
+			// NGINX doesn't support a URI in proxy_pass in a regex location
+			if rewrite != "" && isRegexPathType(pathType) {
+				glog.Errorf("Ingress %s/%s: service %v has a rewrite and the regex path %v, ignoring the rewrite", ingEx.Ingress.Namespace, ingEx.Ingress.Name,
+					path.Backend.ServiceName, path.Path)
+				rewrite = ""
+			}
+			pathRegexRewrites := createRegexRewrites(ingEx, pathOrDefault(path.Path), pathType, regexRewrites[pathOrDefault(path.Path)], appRoots[pathOrDefault(path.Path)])
+			// the URI rewritten with the break flag replaces the URI of proxy_pass
+			if rewrite != "" && len(pathRegexRewrites) > 0 {
+				glog.Errorf("Ingress %s/%s: service %v has a rewrite and the path %v has regex rewrites, ignoring the rewrite of the service",
+					ingEx.Ingress.Namespace, ingEx.Ingress.Name, path.Backend.ServiceName, path.Path)
+				rewrite = ""
+			}
+			if isSplit {
+				splitClient := cnf.createSplitClient(ingEx, rule.Host, &path.Backend, serviceSplits, upstreams, spServices, &ingCfg)
+				splitClients[splitClient.Variable] = splitClient
+				// proxy_pass with a variable selects the upstream the variable evaluates to
+				locUpstream = Upstream{Name: "$" + splitClient.Variable}
+
+				// with a variable in proxy_pass, a URI is passed to the upstream as is, which breaks the rewrite
+				if rewrite != "" {
+					glog.Errorf("Ingress %s/%s: service %v has both a rewrite and splits, ignoring the rewrite", ingEx.Ingress.Namespace, ingEx.Ingress.Name, path.Backend.ServiceName)
+					rewrite = ""
+				}
+			} else {
+				if _, exists := upstreams[upsName]; !exists {
+					upstream := cnf.createUpstream(ingEx, upsName, &path.Backend, ingEx.Ingress.Namespace, spServices[path.Backend.ServiceName], &ingCfg)
+					upstreams[upsName] = upstream
+				}
+				locUpstream = upstreams[upsName]
 			}
 
-			loc := createLocation(pathOrDefault(path.Path), upstreams[upsName], &ingCfg, wsServices[path.Backend.ServiceName], rewrites[path.Backend.ServiceName],
-				sslServices[path.Backend.ServiceName], grpcServices[path.Backend.ServiceName])
-			if isMinion && ingEx.JWTKey.Name != "" {
-				jwtKeyFileName := cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.JWTKey.Name)
+			serviceMatches, hasMatches := matches[path.Backend.ServiceName]
+			if hasMatches && grpcServices[path.Backend.ServiceName] {
+				glog.Errorf("Ingress %s/%s: gRPC service %v does not support matches, ignoring the matches", ingEx.Ingress.Namespace, ingEx.Ingress.Name, path.Backend.ServiceName)
+				hasMatches = false
+			}
 
-				loc.JWTAuth = &JWTAuth{
-					Key:   jwtKeyFileName,
-					Realm: ingCfg.JWTRealm,
-					Token: ingCfg.JWTToken,
+			var pathLocations []Location
+			if hasMatches {
+				// the internal locations receive the rewritten URI, so the original one is passed to the upstreams
+				if rewrite != "" {
+					glog.Errorf("Ingress %s/%s: service %v has both a rewrite and matches, ignoring the rewrite", ingEx.Ingress.Namespace, ingEx.Ingress.Name, path.Backend.ServiceName)
+				}
+				if len(pathRegexRewrites) > 0 {
+					glog.Errorf("Ingress %s/%s: the path %v has both regex rewrites and the matches of service %v, ignoring the regex rewrites",
+						ingEx.Ingress.Namespace, ingEx.Ingress.Name, path.Path, path.Backend.ServiceName)
 				}
 
-				if ingCfg.JWTLoginURL != "" {
-					loc.JWTAuth.RedirectLocationName = getNameForRedirectLocation(ingEx.Ingress)
-					server.JWTRedirectLocations = append(server.JWTRedirectLocations, JWTRedirectLocation{
-						Name:     loc.JWTAuth.RedirectLocationName,
-						LoginURL: ingCfg.JWTLoginURL,
-					})
+				variable := getNameForMatchVariable(upsName)
+				maps = append(maps, createMatchMaps(variable, serviceMatches)...)
+
+				matchBackends := GetMatchBackends(&path.Backend, serviceMatches)
+				for i := range matchBackends {
+					name := getNameForUpstream(ingEx.Ingress, rule.Host, &matchBackends[i])
+					if _, exists := upstreams[name]; !exists {
+						upstreams[name] = cnf.createUpstream(ingEx, name, &matchBackends[i], ingEx.Ingress.Namespace, spServices[matchBackends[i].ServiceName], &ingCfg)
+					}
+					if ingCfg.HealthCheckEnabled {
+						if hc, exists := ingEx.HealthChecks[matchBackends[i].ServiceName+matchBackends[i].ServicePort.String()]; exists {
+							healthChecks[name] = cnf.createHealthCheck(hc, name, &ingCfg)
+						}
+					}
+
+					matchLoc := createLocation(getLocationPath(getPathForMatchLocation(variable, strconv.Itoa(i)), pathTypeExact), upstreams[name], &ingCfg, wsServices[matchBackends[i].ServiceName],
+						"$request_uri", sslServices[matchBackends[i].ServiceName], false)
+					matchLoc.ProxySSL = proxySSLs[matchBackends[i].ServiceName]
+					matchLoc.Internal = true
+					pathLocations = append(pathLocations, matchLoc)
 				}
+
+				defaultLoc := createLocation(getLocationPath(getPathForMatchLocation(variable, "default"), pathTypeExact), locUpstream, &ingCfg, wsServices[path.Backend.ServiceName],
+					"$request_uri", sslServices[path.Backend.ServiceName], false)
+				defaultLoc.ProxySSL = proxySSLs[path.Backend.ServiceName]
+				defaultLoc.Internal = true
+				pathLocations = append(pathLocations, defaultLoc)
+
+				pathLocations = append([]Location{{Path: locPath, MatchVariable: variable}}, pathLocations...)
+			} else {
+				loc := createLocation(locPath, locUpstream, &ingCfg, wsServices[path.Backend.ServiceName], rewrite,
+					sslServices[path.Backend.ServiceName], grpcServices[path.Backend.ServiceName])
+				loc.ProxySSL = proxySSLs[path.Backend.ServiceName]
+				loc.RegexRewrites = pathRegexRewrites
+				pathLocations = append(pathLocations, loc)
+			}
+
+			for _, loc := range pathLocations {
+				// a location with a match variable only routes the requests to the internal locations
+				if loc.MatchVariable == "" {
+					loc.LimitReq = limitReq
+					loc.AuthRequest = authRequest
+					loc.CORS = cors
+					loc.ErrorPages = errorPages
+					loc.ProxySetHeaders = createProxySetHeaders(ingEx, mergeHeaderRules(ingCfg.ProxySetHeaders, pathProxySetHeaders[pathOrDefault(path.Path)]), authRequest)
+					loc.AddHeaders = createAddHeaders(ingEx, mergeHeaderRules(ingCfg.AddHeaders, pathAddHeaders[pathOrDefault(path.Path)]), cors)
+				}
+				if isMinion && loc.MatchVariable == "" {
+					loc.AccessControl = accessControl
+				}
+				if isMinion && ingEx.BasicAuthSecret.Name != "" && loc.MatchVariable == "" {
+					loc.BasicAuth = &BasicAuth{
+						Secret: cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.BasicAuthSecret.Name),
+						Realm:  ingCfg.BasicAuthRealm,
+					}
+				}
+				if isMinion && ingEx.JWTKey.Name != "" && loc.MatchVariable == "" {
+					jwtKeyFileName := cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + ingEx.JWTKey.Name)
+
+					loc.JWTAuth = &JWTAuth{
+						Key:   jwtKeyFileName,
+						Realm: ingCfg.JWTRealm,
+						Token: ingCfg.JWTToken,
+					}
+
+					if ingCfg.JWTLoginURL != "" {
+						loc.JWTAuth.RedirectLocationName = getNameForRedirectLocation(ingEx.Ingress)
+						server.JWTRedirectLocations = append(server.JWTRedirectLocations, JWTRedirectLocation{
+							Name:     loc.JWTAuth.RedirectLocationName,
+							LoginURL: ingCfg.JWTLoginURL,
+						})
+					}
+				}
+				locations = append(locations, loc)
+
 			}
-			locations = append(locations, loc)
 
-			if loc.Path == "/" {
+			// an exact path or a regex doesn't match all requests
+			if pathOrDefault(path.Path) == "/" && (pathType == "" || pathType == pathTypePrefix) {
 				rootLocation = true
 			}
 		}
@@ -374,6 +803,13 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 
 			loc := createLocation(pathOrDefault("/"), upstreams[upsName], &ingCfg, wsServices[ingEx.Ingress.Spec.Backend.ServiceName], rewrites[ingEx.Ingress.Spec.Backend.ServiceName],
 				sslServices[ingEx.Ingress.Spec.Backend.ServiceName], grpcServices[ingEx.Ingress.Spec.Backend.ServiceName])
+			loc.ProxySSL = proxySSLs[ingEx.Ingress.Spec.Backend.ServiceName]
+			loc.LimitReq = limitReq
+			loc.AuthRequest = authRequest
+			loc.CORS = cors
+			loc.ErrorPages = errorPages
+			loc.ProxySetHeaders = createProxySetHeaders(ingEx, ingCfg.ProxySetHeaders, authRequest)
+			loc.AddHeaders = createAddHeaders(ingEx, ingCfg.AddHeaders, cors)
 			locations = append(locations, loc)
 
 			if ingCfg.HealthCheckEnabled {
@@ -387,6 +823,7 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 			}
 		}
 
+		sortLocations(locations)
 		server.Locations = locations
 		server.HealthChecks = healthChecks
 		server.GRPCOnly = grpcOnly
@@ -400,9 +837,12 @@ func (cnf *Configurator) generateNginxCfg(ingEx *IngressEx, pems map[string]stri
 	}
 
 	return IngressNginxConfig{
-		Upstreams: upstreamMapToSlice(upstreams),
-		Servers:   servers,
-		Keepalive: keepalive,
+		Upstreams:     upstreamMapToSlice(upstreams),
+		SplitClients:  splitClientMapToSlice(splitClients),
+		Maps:          maps,
+		LimitReqZones: limitReqZones,
+		Servers:       servers,
+		Keepalive:     keepalive,
 		Ingress: Ingress{
 			Name:        ingEx.Ingress.Name,
 			Namespace:   ingEx.Ingress.Namespace,
@@ -520,6 +960,21 @@ func (cnf *Configurator) createConfig(ingEx *IngressEx) Config {
 			ingCfg.ProxyPassHeaders = proxyPassHeaders
 		}
 	}
+	// the headers of the Ingress are merged with the headers of the ConfigMap
+	if proxySetHeaders, exists := ingEx.Ingress.Annotations[ProxySetHeadersAnnotation]; exists {
+		if rules, err := ParseProxySetHeaders(proxySetHeaders); err != nil {
+			glog.Errorf("Ingress %s/%s: Invalid value for the %v annotation: %v", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), ProxySetHeadersAnnotation, err)
+		} else {
+			ingCfg.ProxySetHeaders = mergeHeaderRules(ingCfg.ProxySetHeaders, rules)
+		}
+	}
+	if addHeaders, exists := ingEx.Ingress.Annotations[AddHeadersAnnotation]; exists {
+		if rules, err := ParseAddHeaders(addHeaders); err != nil {
+			glog.Errorf("Ingress %s/%s: Invalid value for the %v annotation: %v", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), AddHeadersAnnotation, err)
+		} else {
+			ingCfg.AddHeaders = mergeHeaderRules(ingCfg.AddHeaders, rules)
+		}
+	}
 	if clientMaxBodySize, exists := ingEx.Ingress.Annotations["nginx.org/client-max-body-size"]; exists {
 		ingCfg.ClientMaxBodySize = clientMaxBodySize
 	}
@@ -609,6 +1064,48 @@ func (cnf *Configurator) createConfig(ingEx *IngressEx) Config {
 		}
 	}
 
+	if basicAuthSecret, exists := ingEx.Ingress.Annotations[BasicAuthSecretAnnotation]; exists {
+		ingCfg.BasicAuthSecret = fmt.Sprintf("%v/%v", ingEx.Ingress.Namespace, basicAuthSecret)
+		ingCfg.BasicAuthRealm = defaultBasicAuthRealm
+	}
+	if basicAuthRealm, exists := ingEx.Ingress.Annotations["nginx.org/basic-auth-realm"]; exists {
+		ingCfg.BasicAuthRealm = basicAuthRealm
+	}
+
+	if _, exists := ingEx.Ingress.Annotations[ClientCASecretAnnotation]; exists {
+		ingCfg.SSLVerifyClient = "on"
+	}
+	if sslVerifyClient, exists := ingEx.Ingress.Annotations["nginx.org/ssl-verify-client"]; exists {
+		if sslVerifyClient == "on" || sslVerifyClient == "optional" {
+			ingCfg.SSLVerifyClient = sslVerifyClient
+		} else {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/ssl-verify-client: got %q: must be on or optional", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), sslVerifyClient)
+		}
+	}
+	if sslVerifyDepth, exists, err := GetMapKeyAsInt(ingEx.Ingress.Annotations, "nginx.org/ssl-verify-depth", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else if sslVerifyDepth < 1 {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/ssl-verify-depth: got %v: must be positive", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), sslVerifyDepth)
+		} else {
+			ingCfg.SSLVerifyDepth = sslVerifyDepth
+		}
+	}
+	if sslStapling, exists, err := GetMapKeyAsBool(ingEx.Ingress.Annotations, "nginx.org/ssl-stapling", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else {
+			ingCfg.SSLStapling = sslStapling
+		}
+	}
+	if sslStaplingVerify, exists, err := GetMapKeyAsBool(ingEx.Ingress.Annotations, "nginx.org/ssl-stapling-verify", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else {
+			ingCfg.SSLStaplingVerify = sslStaplingVerify
+		}
+	}
+
 	ports, sslPorts := getServicesPorts(ingEx)
 	if len(ports) > 0 {
 		ingCfg.Ports = ports
@@ -637,6 +1134,53 @@ func (cnf *Configurator) createConfig(ingEx *IngressEx) Config {
 		ingCfg.FailTimeout = failTimeout
 	}
 
+	if limitReqRate, exists := ingEx.Ingress.Annotations["nginx.org/limit-req-rate"]; exists {
+		if parsedRate, err := ParseRate(limitReqRate); err != nil {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/limit-req-rate: got %q: %v", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), limitReqRate, err)
+		} else {
+			ingCfg.LimitReqRate = parsedRate
+		}
+	}
+	if limitReqKey, exists := ingEx.Ingress.Annotations["nginx.org/limit-req-key"]; exists {
+		if parsedKey, err := ParseVariables(limitReqKey); err != nil {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/limit-req-key: got %q: %v", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), limitReqKey, err)
+		} else {
+			ingCfg.LimitReqKey = parsedKey
+		}
+	}
+	if limitReqZoneSize, exists := ingEx.Ingress.Annotations["nginx.org/limit-req-zone-size"]; exists {
+		if parsedSize, err := ParseSize(limitReqZoneSize); err != nil {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/limit-req-zone-size: got %q: %v", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), limitReqZoneSize, err)
+		} else {
+			ingCfg.LimitReqZoneSize = parsedSize
+		}
+	}
+	if limitReqBurst, exists, err := GetMapKeyAsInt(ingEx.Ingress.Annotations, "nginx.org/limit-req-burst", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else if limitReqBurst < 0 {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/limit-req-burst: got %d: must not be negative", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), limitReqBurst)
+		} else {
+			ingCfg.LimitReqBurst = limitReqBurst
+		}
+	}
+	if limitReqNoDelay, exists, err := GetMapKeyAsBool(ingEx.Ingress.Annotations, "nginx.org/limit-req-nodelay", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else {
+			ingCfg.LimitReqNoDelay = limitReqNoDelay
+		}
+	}
+	if limitReqRejectCode, exists, err := GetMapKeyAsInt(ingEx.Ingress.Annotations, "nginx.org/limit-req-reject-code", ingEx.Ingress); exists {
+		if err != nil {
+			glog.Error(err)
+		} else if limitReqRejectCode < 400 || limitReqRejectCode > 599 {
+			glog.Errorf("Ingress %s/%s: Invalid value for the nginx.org/limit-req-reject-code: got %d: must be between 400 and 599", ingEx.Ingress.GetNamespace(), ingEx.Ingress.GetName(), limitReqRejectCode)
+		} else {
+			ingCfg.LimitReqRejectCode = limitReqRejectCode
+		}
+	}
+
 	return ingCfg
 }
 
@@ -688,6 +1232,402 @@ func parseRewrites(service string) (serviceName string, rewrite string, err erro
 	return svcNameParts[1], rwPathParts[1], nil
 }
 
+// Split is a target service of a traffic split along with the percentage of requests it receives.
+type Split struct {
+	ServiceName string
+	Weight      int
+}
+
+func getSplits(ingEx *IngressEx) map[string][]Split {
+	splits := make(map[string][]Split)
+
+	if annotation, exists := ingEx.Ingress.Annotations[SplitsAnnotation]; exists {
+		parsedSplits, err := ParseSplits(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, SplitsAnnotation, err)
+		} else {
+			splits = parsedSplits
+		}
+	}
+
+	return splits
+}
+
+// ParseSplits parses the value of the nginx.org/splits annotation. The annotation has the format
+// "serviceName=<service> weights=<service>:<weight>,<service>:<weight>[;...]". For every declaration,
+// the requests of the paths with the backend service <service> are split among the listed services, which
+// use the same service port as the backend. The weights are percentages and must sum to 100.
+func ParseSplits(annotation string) (map[string][]Split, error) {
+	result := make(map[string][]Split)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		if strings.TrimSpace(declaration) == "" {
+			continue
+		}
+
+		serviceName, splits, err := parseSplit(declaration)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := result[serviceName]; exists {
+			return nil, fmt.Errorf("Duplicate splits for service %s", serviceName)
+		}
+
+		result[serviceName] = splits
+	}
+
+	return result, nil
+}
+
+func parseSplit(declaration string) (serviceName string, splits []Split, err error) {
+	parts := strings.Fields(declaration)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("Invalid split format: %s", declaration)
+	}
+
+	svcNameParts := strings.SplitN(parts[0], "=", 2)
+	if len(svcNameParts) != 2 || svcNameParts[0] != "serviceName" {
+		return "", nil, fmt.Errorf("Invalid split format: %s", parts[0])
+	}
+	serviceName = svcNameParts[1]
+	if errs := validation.IsDNS1035Label(serviceName); len(errs) > 0 {
+		return "", nil, fmt.Errorf("Invalid service name %q: %s", serviceName, strings.Join(errs, ", "))
+	}
+
+	weightsParts := strings.SplitN(parts[1], "=", 2)
+	if len(weightsParts) != 2 || weightsParts[0] != "weights" {
+		return "", nil, fmt.Errorf("Invalid split format: %s", parts[1])
+	}
+
+	targets := make(map[string]bool)
+	sum := 0
+
+	for _, target := range strings.Split(weightsParts[1], ",") {
+		targetParts := strings.Split(target, ":")
+		if len(targetParts) != 2 {
+			return "", nil, fmt.Errorf("Invalid split format: %s", target)
+		}
+
+		targetName := targetParts[0]
+		if errs := validation.IsDNS1035Label(targetName); len(errs) > 0 {
+			return "", nil, fmt.Errorf("Invalid service name %q: %s", targetName, strings.Join(errs, ", "))
+		}
+		if targets[targetName] {
+			return "", nil, fmt.Errorf("Duplicate service %s in the splits for service %s", targetName, serviceName)
+		}
+		targets[targetName] = true
+
+		weight, err := strconv.Atoi(targetParts[1])
+		if err != nil || weight < 0 || weight > 100 {
+			return "", nil, fmt.Errorf("Invalid weight %q of service %s: must be an integer between 0 and 100", targetParts[1], targetName)
+		}
+		sum += weight
+
+		splits = append(splits, Split{ServiceName: targetName, Weight: weight})
+	}
+
+	if sum != 100 {
+		return "", nil, fmt.Errorf("The weights of the splits for service %s sum to %d, must be 100", serviceName, sum)
+	}
+
+	return serviceName, splits, nil
+}
+
+// GetSplitBackends returns the backends the requests for the given backend are split among.
+// The backends use the service port of the given backend.
+func GetSplitBackends(backend *extensions.IngressBackend, splits []Split) []extensions.IngressBackend {
+	var backends []extensions.IngressBackend
+	for _, split := range splits {
+		backends = append(backends, extensions.IngressBackend{
+			ServiceName: split.ServiceName,
+			ServicePort: backend.ServicePort,
+		})
+	}
+	return backends
+}
+
+// createSplitClient creates a SplitClient for the backend of a path and adds the upstreams of the split to the upstreams map.
+func (cnf *Configurator) createSplitClient(ingEx *IngressEx, host string, backend *extensions.IngressBackend, splits []Split,
+	upstreams map[string]Upstream, spServices map[string]string, cfg *Config) SplitClient {
+	splitClient := SplitClient{
+		Source:   "$request_id",
+		Variable: getNameForSplitClientVariable(getNameForUpstream(ingEx.Ingress, host, backend)),
+	}
+
+	splitBackends := GetSplitBackends(backend, splits)
+	for i := range splitBackends {
+		upsName := getNameForUpstream(ingEx.Ingress, host, &splitBackends[i])
+		if _, exists := upstreams[upsName]; !exists {
+			upstreams[upsName] = cnf.createUpstream(ingEx, upsName, &splitBackends[i], ingEx.Ingress.Namespace, spServices[splitBackends[i].ServiceName], cfg)
+		}
+
+		if splits[i].Weight == 0 {
+			continue
+		}
+		splitClient.Distributions = append(splitClient.Distributions, Distribution{
+			Weight: fmt.Sprintf("%d%%", splits[i].Weight),
+			Value:  upsName,
+		})
+	}
+
+	return splitClient
+}
+
+func getNameForSplitClientVariable(upstreamName string) string {
+	return "split_" + encodeVariableName(upstreamName)
+}
+
+// encodeVariableName converts a name into the characters allowed in the name of an NGINX variable. The conversion is
+// injective, so different names never get the same variable: the letters and digits are kept, '_' becomes "__",
+// '-' becomes "_h", '.' becomes "_d" and any other byte becomes "_x" followed by its hex code.
+func encodeVariableName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'):
+			b.WriteByte(c)
+		case c == '_':
+			b.WriteString("__")
+		case c == '-':
+			b.WriteString("_h")
+		case c == '.':
+			b.WriteString("_d")
+		default:
+			fmt.Fprintf(&b, "_x%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func splitClientMapToSlice(splitClients map[string]SplitClient) []SplitClient {
+	keys := make([]string, 0, len(splitClients))
+	for k := range splitClients {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var result []SplitClient
+	for _, k := range keys {
+		result = append(result, splitClients[k])
+	}
+
+	return result
+}
+
+// The types of the conditions of a Match.
+const (
+	MatchTypeHeader   = "header"
+	MatchTypeCookie   = "cookie"
+	MatchTypeArgument = "argument"
+	MatchTypeMethod   = "method"
+)
+
+// Match is a condition on the requests along with the target service the matching requests are routed to.
+type Match struct {
+	Type        string
+	Name        string
+	Value       string
+	ServiceName string
+}
+
+var headerNameRegexp = regexp.MustCompile("^[A-Za-z0-9-]+$")
+
+// cookie and argument names become parts of the names of the NGINX variables $cookie_<name> and $arg_<name>
+var variableNameRegexp = regexp.MustCompile("^[A-Za-z0-9_]+$")
+
+var validMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"DELETE":  true,
+	"CONNECT": true,
+	"OPTIONS": true,
+	"TRACE":   true,
+	"PATCH":   true,
+}
+
+func getMatches(ingEx *IngressEx) map[string][]Match {
+	matches := make(map[string][]Match)
+
+	if annotation, exists := ingEx.Ingress.Annotations[MatchesAnnotation]; exists {
+		parsedMatches, err := ParseMatches(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, MatchesAnnotation, err)
+		} else {
+			matches = parsedMatches
+		}
+	}
+
+	return matches
+}
+
+// ParseMatches parses the value of the nginx.org/matches annotation. The annotation has the format
+// "serviceName=<service> <type>=<condition> target=<service>[;...]", where <type> is header, cookie or argument
+// with the condition <name>:<value>, or method with the condition <method>. For every declaration, the requests
+// of the paths with the backend service <service> that satisfy the condition are routed to the target service,
+// which uses the same service port as the backend. The declarations for the same service are evaluated in order.
+func ParseMatches(annotation string) (map[string][]Match, error) {
+	result := make(map[string][]Match)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		if strings.TrimSpace(declaration) == "" {
+			continue
+		}
+
+		serviceName, match, err := parseMatch(declaration)
+		if err != nil {
+			return nil, err
+		}
+
+		result[serviceName] = append(result[serviceName], match)
+	}
+
+	return result, nil
+}
+
+func parseMatch(declaration string) (serviceName string, match Match, err error) {
+	parts := strings.Fields(declaration)
+	if len(parts) != 3 {
+		return "", Match{}, fmt.Errorf("Invalid match format: %s", declaration)
+	}
+
+	svcNameParts := strings.SplitN(parts[0], "=", 2)
+	if len(svcNameParts) != 2 || svcNameParts[0] != "serviceName" {
+		return "", Match{}, fmt.Errorf("Invalid match format: %s", parts[0])
+	}
+	serviceName = svcNameParts[1]
+	if errs := validation.IsDNS1035Label(serviceName); len(errs) > 0 {
+		return "", Match{}, fmt.Errorf("Invalid service name %q: %s", serviceName, strings.Join(errs, ", "))
+	}
+
+	conditionParts := strings.SplitN(parts[1], "=", 2)
+	if len(conditionParts) != 2 {
+		return "", Match{}, fmt.Errorf("Invalid match format: %s", parts[1])
+	}
+	match, err = parseMatchCondition(conditionParts[0], conditionParts[1])
+	if err != nil {
+		return "", Match{}, err
+	}
+
+	targetParts := strings.SplitN(parts[2], "=", 2)
+	if len(targetParts) != 2 || targetParts[0] != "target" {
+		return "", Match{}, fmt.Errorf("Invalid match format: %s", parts[2])
+	}
+	match.ServiceName = targetParts[1]
+	if errs := validation.IsDNS1035Label(match.ServiceName); len(errs) > 0 {
+		return "", Match{}, fmt.Errorf("Invalid service name %q: %s", match.ServiceName, strings.Join(errs, ", "))
+	}
+
+	return serviceName, match, nil
+}
+
+func parseMatchCondition(matchType string, condition string) (Match, error) {
+	if matchType == MatchTypeMethod {
+		if !validMethods[condition] {
+			return Match{}, fmt.Errorf("Invalid method %q: must be an uppercase HTTP method", condition)
+		}
+		return Match{Type: matchType, Value: condition}, nil
+	}
+
+	if matchType != MatchTypeHeader && matchType != MatchTypeCookie && matchType != MatchTypeArgument {
+		return Match{}, fmt.Errorf("Invalid match type %q: must be one of %s, %s, %s or %s", matchType, MatchTypeHeader, MatchTypeCookie, MatchTypeArgument, MatchTypeMethod)
+	}
+
+	conditionParts := strings.SplitN(condition, ":", 2)
+	if len(conditionParts) != 2 {
+		return Match{}, fmt.Errorf("Invalid %s condition %q: must be <name>:<value>", matchType, condition)
+	}
+	name, value := conditionParts[0], conditionParts[1]
+
+	if matchType == MatchTypeHeader {
+		if !headerNameRegexp.MatchString(name) {
+			return Match{}, fmt.Errorf("Invalid header name %q: must contain only letters, digits and '-'", name)
+		}
+	} else if !variableNameRegexp.MatchString(name) {
+		return Match{}, fmt.Errorf("Invalid %s name %q: must contain only letters, digits and '_'", matchType, name)
+	}
+
+	if value == "" || strings.HasPrefix(value, "~") || strings.ContainsAny(value, `"\$`) {
+		return Match{}, fmt.Errorf("Invalid %s value %q: must be non-empty, must not start with '~' and must not contain '\"', '\\' or '$'", matchType, value)
+	}
+
+	return Match{Type: matchType, Name: name, Value: value}, nil
+}
+
+// GetMatchBackends returns the backends the requests for the given backend are routed to if they satisfy the matches.
+// The backends use the service port of the given backend.
+func GetMatchBackends(backend *extensions.IngressBackend, matches []Match) []extensions.IngressBackend {
+	var backends []extensions.IngressBackend
+	for _, match := range matches {
+		backends = append(backends, extensions.IngressBackend{
+			ServiceName: match.ServiceName,
+			ServicePort: backend.ServicePort,
+		})
+	}
+	return backends
+}
+
+// createMatchMaps creates a map for every match, which evaluates to 1 if the request satisfies the match and to 0 otherwise,
+// and a map that evaluates to the path of the internal location of the first satisfied match or of the default location.
+func createMatchMaps(variable string, matches []Match) []Map {
+	var maps []Map
+	var conditionVariables string
+
+	resultMap := Map{
+		Variable: variable,
+	}
+
+	for i, match := range matches {
+		conditionVariable := fmt.Sprintf("%s_cond_%d", variable, i)
+		maps = append(maps, Map{
+			Source:   getSourceForMatch(match),
+			Variable: conditionVariable,
+			Parameters: []Parameter{
+				{Value: `"` + match.Value + `"`, Result: "1"},
+				{Value: "default", Result: "0"},
+			},
+		})
+		conditionVariables += "$" + conditionVariable
+
+		// the preceding matches are not satisfied and this one is
+		resultMap.Parameters = append(resultMap.Parameters, Parameter{
+			Value:  "~^" + strings.Repeat("0", i) + "1",
+			Result: getPathForMatchLocation(variable, strconv.Itoa(i)),
+		})
+	}
+
+	resultMap.Source = conditionVariables
+	resultMap.Parameters = append(resultMap.Parameters, Parameter{
+		Value:  "default",
+		Result: getPathForMatchLocation(variable, "default"),
+	})
+
+	return append(maps, resultMap)
+}
+
+func getSourceForMatch(match Match) string {
+	switch match.Type {
+	case MatchTypeHeader:
+		return "$http_" + strings.ToLower(strings.Replace(match.Name, "-", "_", -1))
+	case MatchTypeCookie:
+		return "$cookie_" + match.Name
+	case MatchTypeArgument:
+		return "$arg_" + match.Name
+	default:
+		return "$request_method"
+	}
+}
+
+func getNameForMatchVariable(upstreamName string) string {
+	return "match_" + encodeVariableName(upstreamName)
+}
+
+func getPathForMatchLocation(variable string, suffix string) string {
+	return fmt.Sprintf("/internal_%s_%s", variable, suffix)
+}
+
 func getSSLServices(ingEx *IngressEx) map[string]bool {
 	sslServices := make(map[string]bool)
 
@@ -783,7 +1723,7 @@ func parsePort(value string) (int, error) {
 	if port <= 0 {
 		return 0, fmt.Errorf(
 			"Port number should be greater than zero: %q",
-			port,
+			value,
 		)
 	}
 
@@ -859,10 +1799,37 @@ func (cnf *Configurator) createUpstream(ingEx *IngressEx, name string, backend *
 			ups.UpstreamServers = upsServers
 		}
 	}
+	if len(endps) == 0 && len(cnf.defaultBackendEndpoints) > 0 {
+		ups.UpstreamServers = cnf.createDefaultBackendServers(cfg)
+	}
 	ups.LBMethod = cfg.LBMethod
 	return ups
 }
 
+// createDefaultBackendServers creates the upstream servers for the endpoints of the default backend Service.
+func (cnf *Configurator) createDefaultBackendServers(cfg *Config) []UpstreamServer {
+	var upsServers []UpstreamServer
+	for _, endp := range cnf.defaultBackendEndpoints {
+		addressport := strings.Split(endp, ":")
+		upsServers = append(upsServers, UpstreamServer{
+			Address:     addressport[0],
+			Port:        addressport[1],
+			MaxFails:    cfg.MaxFails,
+			FailTimeout: cfg.FailTimeout,
+			SlowStart:   cfg.SlowStart,
+		})
+	}
+	return upsServers
+}
+
+// endpointsOrDefaultBackend returns the endpoints of the default backend Service if there are no endpoints.
+func (cnf *Configurator) endpointsOrDefaultBackend(endps []string) []string {
+	if len(endps) == 0 && len(cnf.defaultBackendEndpoints) > 0 {
+		return cnf.defaultBackendEndpoints
+	}
+	return endps
+}
+
 func (cnf *Configurator) createHealthCheck(hc *api_v1.Probe, upstreamName string, cfg *Config) HealthCheck {
 	return HealthCheck{
 		UpstreamName:   upstreamName,
@@ -893,7 +1860,66 @@ func pathOrDefault(path string) string {
 }
 
 func getNameForUpstream(ing *extensions.Ingress, host string, backend *extensions.IngressBackend) string {
-	return fmt.Sprintf("%v-%v-%v-%v-%v", ing.Namespace, ing.Name, host, backend.ServiceName, backend.ServicePort.String())
+	return fmt.Sprintf("%v-%v-%v-%v-%v", ing.Namespace, ing.Name, getNameForHost(host), backend.ServiceName, backend.ServicePort.String())
+}
+
+// getNameForHost returns the name of the host for the names of the upstreams and the status zones. The '*' of a wildcard host
+// is replaced with "_wildcard", which doesn't clash with the names of other hosts, because a host can't contain '_'
+func getNameForHost(host string) string {
+	if isWildcardHost(host) {
+		return "_wildcard" + strings.TrimPrefix(host, "*")
+	}
+	return host
+}
+
+// isWildcardHost checks if the host is a wildcard host, such as *.example.com
+func isWildcardHost(host string) bool {
+	return strings.HasPrefix(host, "*.")
+}
+
+// getServerNameForHost returns the server name of the host for the server_name directive and the maps of the hostnames.
+// A wildcard host is converted to a regex, because the wildcard names of NGINX match any number of labels,
+// for example, *.example.com matches tea.cafe.example.com, while the wildcard host of an Ingress matches a single label.
+func getServerNameForHost(host string) string {
+	if !isWildcardHost(host) {
+		return host
+	}
+	return "~^[^.]+" + strings.Replace(strings.TrimPrefix(host, "*"), ".", `\.`, -1) + "$"
+}
+
+// getWildcardHostForHost returns the wildcard host that matches the host, for example, *.example.com for cafe.example.com.
+// A wildcard host only matches a single label, so *.example.com doesn't match tea.cafe.example.com.
+// The function returns an empty string for the empty host and wildcard hosts
+func getWildcardHostForHost(host string) string {
+	dot := strings.Index(host, ".")
+	if dot <= 0 || isWildcardHost(host) {
+		return ""
+	}
+	return "*" + host[dot:]
+}
+
+// getPemFilesForHost returns the pem files of the host. If the host is not listed in the TLS sections, the pem files
+// of the wildcard host that matches it are returned, so that an exact host takes precedence over a wildcard host, like in NGINX
+func getPemFilesForHost(pems map[string][]string, host string) ([]string, bool) {
+	if pemFiles, exists := pems[host]; exists {
+		return pemFiles, true
+	}
+
+	wildcardHost := getWildcardHostForHost(host)
+	if wildcardHost == "" {
+		return nil, false
+	}
+
+	pemFiles, exists := pems[wildcardHost]
+	return pemFiles, exists
+}
+
+// getNameForLimitReqZone returns a name of the limit_req zone of the Ingress, which is unique,
+// because the names of Kubernetes resources and namespaces can't contain '_'. The name ends with a hash of the key,
+// the size and the rate of the zone, because NGINX fails to reload if an existing zone changes its parameters.
+func getNameForLimitReqZone(ing *extensions.Ingress, zone LimitReqZone) string {
+	hash := sha1.Sum([]byte(zone.Key + "/" + zone.Size + "/" + zone.Rate))
+	return fmt.Sprintf("ing_%s_%s_%x", ing.Namespace, ing.Name, hash[:4])
 }
 
 func getNameForRedirectLocation(ing *extensions.Ingress) string {
@@ -924,29 +1950,24 @@ func upstreamMapToSlice(upstreams map[string]Upstream) []Upstream {
 func (cnf *Configurator) AddOrUpdateSecret(secret *api_v1.Secret, ingExes []IngressEx, mergeableIngresses []MergeableIngresses) error {
 	cnf.addOrUpdateSecret(secret)
 
+	// NGINX reads the files of JWK and htpasswd Secrets for every request, so a reload is not necessary
 	kind, _ := GetSecretKind(secret)
-	if cnf.isPlus() && kind == JWK {
+	if (cnf.isPlus() && kind == JWK) || kind == Htpasswd {
 		return nil
 	}
 
+	resErrs := newResourceErrors()
 	for i := range ingExes {
-		err := cnf.addOrUpdateIngress(&ingExes[i])
-		if err != nil {
-			return fmt.Errorf("Error adding or updating ingress %v/%v: %v", ingExes[i].Ingress.Namespace, ingExes[i].Ingress.Name, err)
-		}
+		cnf.addOrUpdateIngressWithErrors(&ingExes[i], resErrs)
 	}
-
 	for i := range mergeableIngresses {
-		err := cnf.addOrUpdateMergeableIngress(&mergeableIngresses[i])
-		if err != nil {
-			return fmt.Errorf("Error adding or updating mergeableIngress %v/%v: %v", mergeableIngresses[i].Master.Ingress.Namespace, mergeableIngresses[i].Master.Ingress.Name, err)
-		}
+		cnf.addOrUpdateMergeableIngressWithErrors(&mergeableIngresses[i], resErrs)
 	}
 
 	if err := cnf.nginx.Reload(); err != nil {
 		return fmt.Errorf("Error when reloading NGINX when updating Secret: %v", err)
 	}
-	return nil
+	return resErrs.getError()
 }
 
 func (cnf *Configurator) addOrUpdateSecret(secret *api_v1.Secret) string {
@@ -959,16 +1980,40 @@ func (cnf *Configurator) addOrUpdateSecret(secret *api_v1.Secret) string {
 	if cnf.isPlus() && kind == JWK {
 		mode = nginx.JWKSecretFileMode
 		data = []byte(secret.Data[JWTKeyKey])
+	} else if kind == Htpasswd {
+		mode = nginx.HtpasswdSecretFileMode
+		data = []byte(secret.Data[HtpasswdSecretKey])
+	} else if kind == CA {
+		mode = nginx.CASecretFileMode
+		data = []byte(secret.Data[CASecretKey])
 	} else {
 		mode = nginx.TLSSecretFileMode
 		data = GenerateCertAndKeyFileContent(secret)
+		cnf.UpdateCertificateMetrics(secret)
 	}
 	return cnf.nginx.AddOrUpdateSecretFile(name, data, mode)
 }
 
+// UpdateCertificateMetrics updates the metrics of the expiration of the certificate of the TLS Secret.
+func (cnf *Configurator) UpdateCertificateMetrics(secret *api_v1.Secret) {
+	if cnf.certificateCollector == nil {
+		return
+	}
+
+	cert, err := ParseTLSSecretCertificate(secret)
+	if err != nil {
+		glog.Warningf("Error parsing the certificate of the Secret %v/%v: %v", secret.Namespace, secret.Name, err)
+		cnf.certificateCollector.DeleteCertificate(secret.Namespace, secret.Name)
+		return
+	}
+
+	cnf.certificateCollector.UpdateCertificate(secret.Namespace, secret.Name, cert.NotAfter)
+}
+
 // AddOrUpdateSpecialSecrets creates or updates a file with a TLS cert and a key from a Special Secret (eg. DefaultServerSecret, WildcardTLSSecret)
 func (cnf *Configurator) AddOrUpdateSpecialSecrets(secret *api_v1.Secret, secretNames []string) error {
 	data := GenerateCertAndKeyFileContent(secret)
+	cnf.UpdateCertificateMetrics(secret)
 	for _, secretName := range secretNames {
 		cnf.nginx.AddOrUpdateSecretFile(secretName, data, nginx.TLSSecretFileMode)
 	}
@@ -992,19 +2037,17 @@ func GenerateCertAndKeyFileContent(secret *api_v1.Secret) []byte {
 // DeleteSecret deletes the file associated with the secret and the configuration files for the Ingress resources. NGINX is reloaded only when len(ings) > 0
 func (cnf *Configurator) DeleteSecret(key string, ingExes []IngressEx, mergeableIngresses []MergeableIngresses) error {
 	cnf.nginx.DeleteSecretFile(keyToFileName(key))
+	if cnf.certificateCollector != nil {
+		namespace, name := keyToNamespaceAndName(key)
+		cnf.certificateCollector.DeleteCertificate(namespace, name)
+	}
 
+	resErrs := newResourceErrors()
 	for i := range ingExes {
-		err := cnf.addOrUpdateIngress(&ingExes[i])
-		if err != nil {
-			return fmt.Errorf("Error adding or updating ingress %v/%v: %v", ingExes[i].Ingress.Namespace, ingExes[i].Ingress.Name, err)
-		}
+		cnf.addOrUpdateIngressWithErrors(&ingExes[i], resErrs)
 	}
-
 	for i := range mergeableIngresses {
-		err := cnf.addOrUpdateMergeableIngress(&mergeableIngresses[i])
-		if err != nil {
-			return fmt.Errorf("Error adding or updating mergeableIngress %v/%v: %v", mergeableIngresses[i].Master.Ingress.Namespace, mergeableIngresses[i].Master.Ingress.Name, err)
-		}
+		cnf.addOrUpdateMergeableIngressWithErrors(&mergeableIngresses[i], resErrs)
 	}
 
 	if len(ingExes)+len(mergeableIngresses) > 0 {
@@ -1013,16 +2056,33 @@ func (cnf *Configurator) DeleteSecret(key string, ingExes []IngressEx, mergeable
 		}
 	}
 
-	return nil
+	return resErrs.getError()
 }
 
 // DeleteIngress deletes NGINX configuration for the Ingress resource
 func (cnf *Configurator) DeleteIngress(key string) error {
 	name := keyToFileName(key)
-	cnf.nginx.DeleteIngress(name)
+	prev, exists := cnf.ingresses[name]
 	delete(cnf.ingresses, name)
 	delete(cnf.minions, name)
 
+	if exists && isTLSPassthroughIngress(prev) {
+		if err := cnf.updateMainConfig(); err != nil {
+			return fmt.Errorf("Error when removing ingress %v: %v", key, err)
+		}
+	} else {
+		cnf.nginx.DeleteIngress(name)
+		if cnf.isTLSPassthroughExcludedHostsChanged() {
+			if err := cnf.updateMainConfig(); err != nil {
+				return fmt.Errorf("Error when removing ingress %v: %v", key, err)
+			}
+		}
+	}
+
+	if err := cnf.updateLimitReqZones(name, nil); err != nil {
+		return fmt.Errorf("Error when removing ingress %v: %v", key, err)
+	}
+
 	if err := cnf.nginx.Reload(); err != nil {
 		return fmt.Errorf("Error when removing ingress %v: %v", key, err)
 	}
@@ -1032,14 +2092,19 @@ func (cnf *Configurator) DeleteIngress(key string) error {
 // UpdateEndpoints updates endpoints in NGINX configuration for the Ingress resources
 func (cnf *Configurator) UpdateEndpoints(ingExes []*IngressEx) error {
 	reloadPlus := false
+	resErrs := newResourceErrors()
 
 	for _, ingEx := range ingExes {
-		err := cnf.addOrUpdateIngress(ingEx)
-		if err != nil {
-			return fmt.Errorf("Error adding or updating ingress %v/%v: %v", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		if !cnf.addOrUpdateIngressWithErrors(ingEx, resErrs) {
+			// the main config file could have been updated before the failure
+			reloadPlus = true
+			continue
 		}
 
-		if cnf.isPlus() {
+		// the upstreams of TLS passthrough are in the main NGINX configuration file, which requires a reload
+		if cnf.isPlus() && isTLSPassthroughIngress(ingEx) {
+			reloadPlus = true
+		} else if cnf.isPlus() {
 			err := cnf.updatePlusEndpoints(ingEx)
 			if err != nil {
 				glog.Warningf("Couldn't update the endpoints via the API: %v; reloading configuration instead", err)
@@ -1057,21 +2122,23 @@ func (cnf *Configurator) UpdateEndpoints(ingExes []*IngressEx) error {
 		return fmt.Errorf("Error reloading NGINX when updating endpoints: %v", err)
 	}
 
-	return nil
+	return resErrs.getError()
 }
 
 // UpdateEndpointsMergeableIngress updates endpoints in NGINX configuration for a mergeable Ingress resource
 func (cnf *Configurator) UpdateEndpointsMergeableIngress(mergableIngressesSlice []*MergeableIngresses) error {
 	reloadPlus := false
+	resErrs := newResourceErrors()
 	for i := range mergableIngressesSlice {
-		err := cnf.addOrUpdateMergeableIngress(mergableIngressesSlice[i])
-		if err != nil {
-			return fmt.Errorf("Error adding or updating mergeableIngress %v/%v: %v", mergableIngressesSlice[i].Master.Ingress.Namespace, mergableIngressesSlice[i].Master.Ingress.Name, err)
+		if !cnf.addOrUpdateMergeableIngressWithErrors(mergableIngressesSlice[i], resErrs) {
+			// the main config file could have been updated before the failure
+			reloadPlus = true
+			continue
 		}
 
 		if cnf.isPlus() {
 			for _, ing := range mergableIngressesSlice[i].Minions {
-				err = cnf.updatePlusEndpoints(ing)
+				err := cnf.updatePlusEndpoints(ing)
 				if err != nil {
 					glog.Warningf("Couldn't update the endpoints via the API: %v; reloading configuration instead", err)
 					reloadPlus = true
@@ -1087,7 +2154,7 @@ func (cnf *Configurator) UpdateEndpointsMergeableIngress(mergableIngressesSlice
 	if err := cnf.nginx.Reload(); err != nil {
 		return fmt.Errorf("Error reloading NGINX when updating endpoints for %v: %v", mergableIngressesSlice, err)
 	}
-	return nil
+	return resErrs.getError()
 }
 
 func (cnf *Configurator) updatePlusEndpoints(ingEx *IngressEx) error {
@@ -1106,33 +2173,78 @@ func (cnf *Configurator) updatePlusEndpoints(ingEx *IngressEx) error {
 			if _, isExternalName := ingEx.ExternalNameSvcs[ingEx.Ingress.Spec.Backend.ServiceName]; isExternalName {
 				glog.V(3).Infof("Service %s is Type ExternalName, skipping NGINX Plus endpoints update via API", ingEx.Ingress.Spec.Backend.ServiceName)
 			} else {
-				err := cnf.nginxAPI.UpdateServers(name, endps, cfg, cnf.nginx.ConfigVersion)
+				err := cnf.nginxAPI.UpdateServers(name, cnf.endpointsOrDefaultBackend(endps), cfg, cnf.nginx.GetConfigVersion())
+				if err != nil {
+					return fmt.Errorf("Couldn't update the endpoints for %v: %v", name, err)
+				}
+			}
+		}
+	}
+	if externalAuth := getExternalAuth(ingEx); externalAuth != nil && externalAuth.Service != nil {
+		name := getNameForUpstream(ingEx.Ingress, emptyHost, externalAuth.Service)
+		endps, exists := ingEx.Endpoints[externalAuth.Service.ServiceName+externalAuth.Service.ServicePort.String()]
+		if exists {
+			if _, isExternalName := ingEx.ExternalNameSvcs[externalAuth.Service.ServiceName]; isExternalName {
+				glog.V(3).Infof("Service %s is Type ExternalName, skipping NGINX Plus endpoints update via API", externalAuth.Service.ServiceName)
+			} else {
+				err := cnf.nginxAPI.UpdateServers(name, endps, cfg, cnf.nginx.GetConfigVersion())
 				if err != nil {
 					return fmt.Errorf("Couldn't update the endpoints for %v: %v", name, err)
 				}
 			}
 		}
 	}
+
+	splits := getSplits(ingEx)
+	matches := getMatches(ingEx)
 	for _, rule := range ingEx.Ingress.Spec.Rules {
 		if rule.IngressRuleValue.HTTP == nil {
 			continue
 		}
 		for _, path := range rule.HTTP.Paths {
-			name := getNameForUpstream(ingEx.Ingress, rule.Host, &path.Backend)
-			endps, exists := ingEx.Endpoints[path.Backend.ServiceName+path.Backend.ServicePort.String()]
-			if exists {
-				if _, isExternalName := ingEx.ExternalNameSvcs[path.Backend.ServiceName]; isExternalName {
-					glog.V(3).Infof("Service %s is Type ExternalName, skipping NGINX Plus endpoints update via API", path.Backend.ServiceName)
-					continue
-				}
-				err := cnf.nginxAPI.UpdateServers(name, endps, cfg, cnf.nginx.ConfigVersion)
-				if err != nil {
-					return fmt.Errorf("Couldn't update the endpoints for %v: %v", name, err)
+			backends := []extensions.IngressBackend{path.Backend}
+			if serviceSplits, isSplit := splits[path.Backend.ServiceName]; isSplit {
+				backends = GetSplitBackends(&path.Backend, serviceSplits)
+			}
+			if serviceMatches, hasMatches := matches[path.Backend.ServiceName]; hasMatches {
+				backends = append(backends, GetMatchBackends(&path.Backend, serviceMatches)...)
+			}
+
+			for _, backend := range backends {
+				name := getNameForUpstream(ingEx.Ingress, rule.Host, &backend)
+				endps, exists := ingEx.Endpoints[backend.ServiceName+backend.ServicePort.String()]
+				if exists {
+					if _, isExternalName := ingEx.ExternalNameSvcs[backend.ServiceName]; isExternalName {
+						glog.V(3).Infof("Service %s is Type ExternalName, skipping NGINX Plus endpoints update via API", backend.ServiceName)
+						continue
+					}
+					err := cnf.nginxAPI.UpdateServers(name, cnf.endpointsOrDefaultBackend(endps), cfg, cnf.nginx.GetConfigVersion())
+					if err != nil {
+						return fmt.Errorf("Couldn't update the endpoints for %v: %v", name, err)
+					}
 				}
 			}
 		}
 	}
 
+	for _, rule := range getErrorPages(ingEx) {
+		if rule.Service == nil {
+			continue
+		}
+		name := getNameForUpstream(ingEx.Ingress, emptyHost, rule.Service)
+		endps, exists := ingEx.Endpoints[rule.Service.ServiceName+rule.Service.ServicePort.String()]
+		if exists {
+			if _, isExternalName := ingEx.ExternalNameSvcs[rule.Service.ServiceName]; isExternalName {
+				glog.V(3).Infof("Service %s is Type ExternalName, skipping NGINX Plus endpoints update via API", rule.Service.ServiceName)
+				continue
+			}
+			err := cnf.nginxAPI.UpdateServers(name, cnf.endpointsOrDefaultBackend(endps), cfg, cnf.nginx.GetConfigVersion())
+			if err != nil {
+				return fmt.Errorf("Couldn't update the endpoints for %v: %v", name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1172,6 +2284,37 @@ func mergeMasterAnnotationsIntoMinion(minionAnnotations map[string]string, maste
 	}
 }
 
+// addMasterBackendToMinion adds the endpoints of the backend of the master to the minion, if the minion doesn't have them.
+// This is the case for the backends of the annotations that the minion inherits from the master, like the auth Service.
+func addMasterBackendToMinion(master *IngressEx, minion *IngressEx, backend *extensions.IngressBackend) {
+	key := backend.ServiceName + backend.ServicePort.String()
+	if _, exists := minion.Endpoints[key]; exists {
+		return
+	}
+	endps, exists := master.Endpoints[key]
+	if !exists {
+		return
+	}
+
+	if minion.Endpoints == nil {
+		minion.Endpoints = make(map[string][]string)
+	}
+	minion.Endpoints[key] = endps
+
+	if master.ExternalNameSvcs[backend.ServiceName] {
+		if minion.ExternalNameSvcs == nil {
+			minion.ExternalNameSvcs = make(map[string]bool)
+		}
+		minion.ExternalNameSvcs[backend.ServiceName] = true
+	}
+	if healthCheck, exists := master.HealthChecks[key]; exists {
+		if minion.HealthChecks == nil {
+			minion.HealthChecks = make(map[string]*api_v1.Probe)
+		}
+		minion.HealthChecks[key] = healthCheck
+	}
+}
+
 // GenerateNginxMainConfig generate NginxMainConfig from Config
 func GenerateNginxMainConfig(config *Config) *MainConfig {
 	nginxCfg := &MainConfig{
@@ -1202,12 +2345,13 @@ func GenerateNginxMainConfig(config *Config) *MainConfig {
 		ResolverTimeout:           config.ResolverTimeout,
 		KeepaliveTimeout:          config.MainKeepaliveTimeout,
 		KeepaliveRequests:         config.MainKeepaliveRequests,
+		SetRealIPFrom:             config.SetRealIPFrom,
 	}
 	return nginxCfg
 }
 
 // UpdateConfig updates NGINX Configuration parameters
-func (cnf *Configurator) UpdateConfig(config *Config, ingExes []*IngressEx, mergeableIngs map[string]*MergeableIngresses) error {
+func (cnf *Configurator) UpdateConfig(config *Config, ingExes []*IngressEx, mergeableIngs map[string]*MergeableIngresses, virtualServerExes []*VirtualServerEx, transportServerExes []*TransportServerEx) error {
 	cnf.config = config
 	if cnf.config.MainServerSSLDHParamFileContent != nil {
 		fileName, err := cnf.nginx.AddOrUpdateDHParam(*cnf.config.MainServerSSLDHParamFileContent)
@@ -1230,22 +2374,25 @@ func (cnf *Configurator) UpdateConfig(config *Config, ingExes []*IngressEx, merg
 		}
 	}
 
-	mainCfg := GenerateNginxMainConfig(config)
+	cnf.transportServers = make(map[string]*TransportServerEx)
+	for _, tsEx := range transportServerExes {
+		cnf.transportServers[getNameForTransportServer(tsEx.TransportServer)] = tsEx
+	}
 
-	mainCfgContent, err := cnf.templateExecutor.ExecuteMainConfigTemplate(mainCfg)
-	if err != nil {
-		return fmt.Errorf("Error when writing main Config")
+	if err := cnf.updateMainConfig(); err != nil {
+		return err
 	}
-	cnf.nginx.UpdateMainConfigFile(mainCfgContent)
 
+	resErrs := newResourceErrors()
 	for _, ingEx := range ingExes {
-		if err := cnf.addOrUpdateIngress(ingEx); err != nil {
-			return err
-		}
+		cnf.addOrUpdateIngressWithErrors(ingEx, resErrs)
 	}
 	for _, mergeableIng := range mergeableIngs {
-		if err := cnf.addOrUpdateMergeableIngress(mergeableIng); err != nil {
-			return err
+		cnf.addOrUpdateMergeableIngressWithErrors(mergeableIng, resErrs)
+	}
+	for _, vsEx := range virtualServerExes {
+		if err := cnf.addOrUpdateVirtualServer(vsEx); err != nil {
+			resErrs.addVirtualServerError(vsEx, fmt.Errorf("Error adding or updating VirtualServer %v/%v: %v", vsEx.VirtualServer.Namespace, vsEx.VirtualServer.Name, err))
 		}
 	}
 
@@ -1253,9 +2400,141 @@ func (cnf *Configurator) UpdateConfig(config *Config, ingExes []*IngressEx, merg
 		return fmt.Errorf("Error when updating config from ConfigMap: %v", err)
 	}
 
+	return resErrs.getError()
+}
+
+// IsConfigPinned checks if the configuration restored by a rollback is pinned
+func (cnf *Configurator) IsConfigPinned() bool {
+	return cnf.nginx.IsConfigPinned()
+}
+
+// GetConfigPinTimeLeft returns the time left until the pin of the configuration restored by a rollback expires
+func (cnf *Configurator) GetConfigPinTimeLeft() time.Duration {
+	return cnf.nginx.GetConfigPinTimeLeft()
+}
+
+// UnpinConfig ends the pinning of the configuration restored by a rollback and generates the configuration
+// of all the resources again
+func (cnf *Configurator) UnpinConfig(ingExes []*IngressEx, mergeableIngs map[string]*MergeableIngresses, virtualServerExes []*VirtualServerEx, transportServerExes []*TransportServerEx) error {
+	if err := cnf.nginx.UnpinConfig(); err != nil {
+		return fmt.Errorf("Error when unpinning the restored configuration: %v", err)
+	}
+	return cnf.UpdateConfig(cnf.config, ingExes, mergeableIngs, virtualServerExes, transportServerExes)
+}
+
+// updateMainConfig generates the main NGINX configuration file from the current config, the limit_req zones
+// of the Ingress resources, TransportServers and the default backend.
+func (cnf *Configurator) updateMainConfig() error {
+	mainCfg := GenerateNginxMainConfig(cnf.config)
+	mainCfg.LimitReqZones = cnf.generateLimitReqZones()
+	mainCfg.StreamUpstreams, mainCfg.StreamServers = cnf.generateStreamConfig()
+	mainCfg.DefaultBackendEndpoints = cnf.defaultBackendEndpoints
+
+	mainCfg.TLSPassthrough = cnf.isTLSPassthroughEnabled
+	passthroughUpstreams, passthroughHosts := cnf.generateTLSPassthroughConfig()
+	mainCfg.StreamUpstreams = append(mainCfg.StreamUpstreams, passthroughUpstreams...)
+	mainCfg.TLSPassthroughHosts = passthroughHosts
+	mainCfg.TLSPassthroughExcludedHosts = cnf.generateTLSPassthroughExcludedHosts(passthroughHosts)
+
+	mainCfgContent, err := cnf.templateExecutor.ExecuteMainConfigTemplate(mainCfg)
+	if err != nil {
+		return fmt.Errorf("Error when writing main Config: %v", err)
+	}
+	if err := cnf.nginx.UpdateMainConfigFile(mainCfgContent); err != nil {
+		return err
+	}
+	cnf.tlsPassthroughExcludedHosts = mainCfg.TLSPassthroughExcludedHosts
+	return nil
+}
+
+// UpdateDefaultBackend updates the endpoints of the default backend Service, which serves the requests for unknown hosts
+// and the requests for the services without endpoints. The NGINX configuration of the Ingress resources is regenerated.
+func (cnf *Configurator) UpdateDefaultBackend(endpoints []string, ingExes []*IngressEx, mergeableIngs map[string]*MergeableIngresses) error {
+	if reflect.DeepEqual(cnf.defaultBackendEndpoints, endpoints) {
+		return nil
+	}
+	prevEndpoints := cnf.defaultBackendEndpoints
+	cnf.defaultBackendEndpoints = endpoints
+
+	if err := cnf.updateMainConfig(); err != nil {
+		cnf.defaultBackendEndpoints = prevEndpoints
+		return fmt.Errorf("Error when updating the upstream of the default backend: %v", err)
+	}
+
+	resErrs := newResourceErrors()
+	for _, ingEx := range ingExes {
+		cnf.addOrUpdateIngressWithErrors(ingEx, resErrs)
+	}
+	for _, mergeableIng := range mergeableIngs {
+		cnf.addOrUpdateMergeableIngressWithErrors(mergeableIng, resErrs)
+	}
+
+	if err := cnf.nginx.Reload(); err != nil {
+		return fmt.Errorf("Error reloading NGINX when updating the default backend: %v", err)
+	}
+
+	return resErrs.getError()
+}
+
+// updateLimitReqZones stores the limit_req zones of the Ingress config with the given name and updates the main config
+// if the zones have changed. If the main config fails the test, the previous zones are kept.
+func (cnf *Configurator) updateLimitReqZones(name string, zones []LimitReqZone) error {
+	prevZones := cnf.limitReqZones[name]
+	if reflect.DeepEqual(prevZones, zones) {
+		return nil
+	}
+
+	setZones := func(zones []LimitReqZone) {
+		if len(zones) == 0 {
+			delete(cnf.limitReqZones, name)
+		} else {
+			cnf.limitReqZones[name] = zones
+		}
+	}
+
+	setZones(zones)
+	if err := cnf.updateMainConfig(); err != nil {
+		setZones(prevZones)
+		return fmt.Errorf("Error when updating the limit_req zones: %v", err)
+	}
 	return nil
 }
 
+// addLimitReqZones adds the limit_req zones of the new Ingress config to the main config before the config file is
+// written, keeping the zones of the previous config file, which passes the test of NGINX only with its zones.
+// The zones of the previous file are removed by updateLimitReqZones after the file is written.
+func (cnf *Configurator) addLimitReqZones(name string, zones []LimitReqZone) error {
+	allZones := append([]LimitReqZone{}, cnf.limitReqZones[name]...)
+	for _, zone := range zones {
+		exists := false
+		for _, z := range allZones {
+			if z.Name == zone.Name {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			allZones = append(allZones, zone)
+		}
+	}
+	return cnf.updateLimitReqZones(name, allZones)
+}
+
+func (cnf *Configurator) generateLimitReqZones() []LimitReqZone {
+	names := make([]string, 0, len(cnf.limitReqZones))
+	for name := range cnf.limitReqZones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var zones []LimitReqZone
+	for _, name := range names {
+		zones = append(zones, cnf.limitReqZones[name]...)
+	}
+
+	return zones
+}
+
 func (cnf *Configurator) isPlus() bool {
 	return cnf.nginxAPI != nil
 }
@@ -1264,10 +2543,48 @@ func keyToFileName(key string) string {
 	return strings.Replace(key, "/", "-", -1)
 }
 
+func keyToNamespaceAndName(key string) (string, string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return "", key
+	}
+	return parts[0], parts[1]
+}
+
 func objectMetaToFileName(meta *meta_v1.ObjectMeta) string {
 	return meta.Namespace + "-" + meta.Name
 }
 
+// GetHTTPListenPorts returns the sorted TCP ports that the http context of NGINX listens on: the ports of the default
+// server, the ports of the ConfigMap and the ports of the nginx.org/listen-ports and nginx.org/listen-ports-ssl
+// annotations of the Ingress resources. The stream server of TLS passthrough listens on port 443.
+func (cnf *Configurator) GetHTTPListenPorts() []int {
+	ports := map[int]bool{
+		80:  true,
+		443: true,
+	}
+	for _, port := range cnf.config.Ports {
+		ports[port] = true
+	}
+	for _, port := range cnf.config.SSLPorts {
+		ports[port] = true
+	}
+	for _, ingEx := range cnf.ingresses {
+		ingPorts, ingSSLPorts := getServicesPorts(ingEx)
+		for _, port := range append(ingPorts, ingSSLPorts...) {
+			ports[port] = true
+		}
+	}
+
+	var result []int
+	for port := range ports {
+		result = append(result, port)
+	}
+	sort.Ints(result)
+
+	return result
+}
+
 // HasIngress checks if the Ingress resource is present in NGINX configuration
 func (cnf *Configurator) HasIngress(ing *extensions.Ingress) bool {
 	name := objectMetaToFileName(&ing.ObjectMeta)
@@ -84,6 +84,132 @@ func TestParseStickyServiceInvalidFormat(t *testing.T) {
 	}
 }
 
+func TestParsePortFails(t *testing.T) {
+	_, err := parsePort("0")
+	if err == nil {
+		t.Fatalf("parsePort(0) should return error, got nil")
+	}
+
+	expected := `Port number should be greater than zero: "0"`
+	if err.Error() != expected {
+		t.Errorf("parsePort(0) returned the error %q, but expected %q", err, expected)
+	}
+}
+
+func TestGetHTTPListenPorts(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a configurator: %v", err)
+	}
+
+	expected := []int{80, 443}
+	if result := cnf.GetHTTPListenPorts(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("GetHTTPListenPorts() returned %v, but expected %v", result, expected)
+	}
+
+	cnf.config.Ports = []int{8080}
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations["nginx.org/listen-ports"] = "81"
+	cafeIngressEx.Ingress.Annotations["nginx.org/listen-ports-ssl"] = "8443,444"
+	cnf.ingresses["default-cafe-ingress"] = &cafeIngressEx
+
+	expected = []int{80, 81, 443, 444, 8080, 8443}
+	if result := cnf.GetHTTPListenPorts(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("GetHTTPListenPorts() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParseSplits(t *testing.T) {
+	annotation := "serviceName=api-v1 weights=api-v1:90,api-v2:10; serviceName=web weights=web-v2:100;"
+	expected := map[string][]Split{
+		"api-v1": {
+			{ServiceName: "api-v1", Weight: 90},
+			{ServiceName: "api-v2", Weight: 10},
+		},
+		"web": {
+			{ServiceName: "web-v2", Weight: 100},
+		},
+	}
+
+	result, err := ParseSplits(annotation)
+	if err != nil {
+		t.Errorf("ParseSplits(%q) returned an error: %v", annotation, err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseSplits(%q) returned %v, but expected %v", annotation, result, expected)
+	}
+}
+
+func TestParseSplitsInvalidFormat(t *testing.T) {
+	invalidAnnotations := []string{
+		"api-v1 weights=api-v1:90,api-v2:10",
+		"serviceName=api-v1",
+		"serviceName=api-v1 api-v1:90,api-v2:10",
+		"serviceName=api-v1 weights=api-v1:90,api-v2",
+		"serviceName=api-v1 weights=api-v1:90,api-v2:ten",
+		"serviceName=api-v1 weights=api-v1:110,api-v2:-10",
+		"serviceName=api-v1 weights=api-v1:90,api-v2:5",
+		"serviceName=api-v1 weights=api-v1:50,api-v1:50",
+		"serviceName=api-v1 weights=api-v1:90,api{v2:10",
+		"serviceName=api-v1 weights=api-v1:100;serviceName=api-v1 weights=api-v2:100",
+	}
+
+	for _, annotation := range invalidAnnotations {
+		_, err := ParseSplits(annotation)
+		if err == nil {
+			t.Errorf("ParseSplits(%q) should return an error", annotation)
+		}
+	}
+}
+
+func TestParseMatches(t *testing.T) {
+	annotation := "serviceName=tea header=X-Canary:always target=tea-canary; serviceName=tea cookie=beta:1 target=tea-beta;" +
+		"serviceName=coffee argument=version:v2 target=coffee-v2; serviceName=coffee method=POST target=coffee-post"
+	expected := map[string][]Match{
+		"tea": {
+			{Type: MatchTypeHeader, Name: "X-Canary", Value: "always", ServiceName: "tea-canary"},
+			{Type: MatchTypeCookie, Name: "beta", Value: "1", ServiceName: "tea-beta"},
+		},
+		"coffee": {
+			{Type: MatchTypeArgument, Name: "version", Value: "v2", ServiceName: "coffee-v2"},
+			{Type: MatchTypeMethod, Value: "POST", ServiceName: "coffee-post"},
+		},
+	}
+
+	result, err := ParseMatches(annotation)
+	if err != nil {
+		t.Errorf("ParseMatches(%q) returned an error: %v", annotation, err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseMatches(%q) returned %v, but expected %v", annotation, result, expected)
+	}
+}
+
+func TestParseMatchesInvalidFormat(t *testing.T) {
+	invalidAnnotations := []string{
+		"tea header=X-Canary:always target=tea-canary",
+		"serviceName=tea header=X-Canary:always",
+		"serviceName=tea header=X-Canary:always tea-canary",
+		"serviceName=tea query=version:v2 target=tea-canary",
+		"serviceName=tea header=X-Canary target=tea-canary",
+		"serviceName=tea header=X_Canary:always target=tea-canary",
+		"serviceName=tea cookie=beta-user:1 target=tea-canary",
+		"serviceName=tea argument=version: target=tea-canary",
+		"serviceName=tea header=X-Canary:~always target=tea-canary",
+		`serviceName=tea header=X-Canary:"always" target=tea-canary`,
+		"serviceName=tea header=X-Canary:$always target=tea-canary",
+		"serviceName=tea method=post target=tea-canary",
+		"serviceName=tea header=X-Canary:always target=tea_canary",
+	}
+
+	for _, annotation := range invalidAnnotations {
+		_, err := ParseMatches(annotation)
+		if err == nil {
+			t.Errorf("ParseMatches(%q) should return an error", annotation)
+		}
+	}
+}
+
 func TestFilterMasterAnnotations(t *testing.T) {
 	masterAnnotations := map[string]string{
 		"nginx.org/rewrites":                "serviceName=service1 rewrite=rewrite1",
@@ -283,15 +409,19 @@ func createExpectedConfigForCafeIngressEx() IngressNginxConfig {
 						ProxyBuffering:      true,
 					},
 				},
-				SSL:               true,
-				SSLCertificate:    "/etc/nginx/secrets/default-cafe-secret",
-				SSLCertificateKey: "/etc/nginx/secrets/default-cafe-secret",
-				StatusZone:        "cafe.example.com",
-				HSTSMaxAge:        2592000,
-				Ports:             []int{80},
-				SSLPorts:          []int{443},
-				SSLRedirect:       true,
-				HealthChecks:      make(map[string]HealthCheck),
+				SSL: true,
+				SSLCertificates: []SSLCertificate{
+					{
+						Certificate: "/etc/nginx/secrets/default-cafe-secret",
+						Key:         "/etc/nginx/secrets/default-cafe-secret",
+					},
+				},
+				StatusZone:   "cafe.example.com",
+				HSTSMaxAge:   2592000,
+				Ports:        []int{80},
+				SSLPorts:     []int{443},
+				SSLRedirect:  true,
+				HealthChecks: make(map[string]HealthCheck),
 			},
 		},
 		Ingress: Ingress{
@@ -406,6 +536,7 @@ func createMergeableCafeIngress() *MergeableIngresses {
 						Name:      "cafe-secret",
 						Namespace: "default",
 					},
+					Data: createTestTLSSecretData("cafe.example.com"),
 				},
 			},
 			Endpoints: map[string][]string{
@@ -499,15 +630,19 @@ func createExpectedConfigForMergeableCafeIngress() IngressNginxConfig {
 						},
 					},
 				},
-				SSL:               true,
-				SSLCertificate:    "/etc/nginx/secrets/default-cafe-secret",
-				SSLCertificateKey: "/etc/nginx/secrets/default-cafe-secret",
-				StatusZone:        "cafe.example.com",
-				HSTSMaxAge:        2592000,
-				Ports:             []int{80},
-				SSLPorts:          []int{443},
-				SSLRedirect:       true,
-				HealthChecks:      make(map[string]HealthCheck),
+				SSL: true,
+				SSLCertificates: []SSLCertificate{
+					{
+						Certificate: "/etc/nginx/secrets/default-cafe-secret",
+						Key:         "/etc/nginx/secrets/default-cafe-secret",
+					},
+				},
+				StatusZone:   "cafe.example.com",
+				HSTSMaxAge:   2592000,
+				Ports:        []int{80},
+				SSLPorts:     []int{443},
+				SSLRedirect:  true,
+				HealthChecks: make(map[string]HealthCheck),
 			},
 		},
 		Ingress: Ingress{
@@ -528,12 +663,12 @@ func createTestConfigurator() (*Configurator, error) {
 	if err != nil {
 		return nil, err
 	}
-	ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true)
+	ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true, nil)
 	apiCtrl, err := nginx.NewNginxAPIController(&http.Client{}, "", true)
 	if err != nil {
 		return nil, err
 	}
-	return NewConfigurator(ngxc, NewDefaultConfig(), apiCtrl, templateExecutor, false), nil
+	return NewConfigurator(ngxc, NewDefaultConfig(), apiCtrl, templateExecutor, false, false, nil), nil
 }
 
 func createTestConfiguratorInvalidIngressTemplate() (*Configurator, error) {
@@ -545,9 +680,9 @@ func createTestConfiguratorInvalidIngressTemplate() (*Configurator, error) {
 	if err := templateExecutor.UpdateIngressTemplate(&invalidIngressTemplate); err != nil {
 		return nil, err
 	}
-	ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true)
+	ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true, nil)
 	apiCtrl, _ := nginx.NewNginxAPIController(&http.Client{}, "", true)
-	return NewConfigurator(ngxc, NewDefaultConfig(), apiCtrl, templateExecutor, false), nil
+	return NewConfigurator(ngxc, NewDefaultConfig(), apiCtrl, templateExecutor, false, false, nil), nil
 }
 
 func TestGenerateNginxCfg(t *testing.T) {
@@ -558,8 +693,8 @@ func TestGenerateNginxCfg(t *testing.T) {
 	}
 	expected := createExpectedConfigForCafeIngressEx()
 
-	pems := map[string]string{
-		"cafe.example.com": "/etc/nginx/secrets/default-cafe-secret",
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
 	}
 
 	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
@@ -570,6 +705,167 @@ func TestGenerateNginxCfg(t *testing.T) {
 
 }
 
+func TestGenerateNginxCfgWithSplits(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[SplitsAnnotation] = "serviceName=coffee-svc weights=coffee-svc:90,coffee-v2-svc:10"
+	cafeIngressEx.Ingress.Annotations["nginx.org/rewrites"] = "serviceName=coffee-svc rewrite=/beans/"
+	cafeIngressEx.Endpoints["coffee-v2-svc80"] = []string{"10.0.0.3:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+
+	expectedSplitClients := []SplitClient{
+		{
+			Source:   "$request_id",
+			Variable: "split_default_hcafe_hingress_hcafe_dexample_dcom_hcoffee_hsvc_h80",
+			Distributions: []Distribution{
+				{
+					Weight: "90%",
+					Value:  "default-cafe-ingress-cafe.example.com-coffee-svc-80",
+				},
+				{
+					Weight: "10%",
+					Value:  "default-cafe-ingress-cafe.example.com-coffee-v2-svc-80",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(result.SplitClients, expectedSplitClients) {
+		t.Errorf("generateNginxCfg returned SplitClients \n%+v, but expected \n%+v", result.SplitClients, expectedSplitClients)
+	}
+
+	var upstreamNames []string
+	for _, ups := range result.Upstreams {
+		upstreamNames = append(upstreamNames, ups.Name)
+	}
+	expectedUpstreamNames := []string{
+		"default-cafe-ingress-cafe.example.com-coffee-svc-80",
+		"default-cafe-ingress-cafe.example.com-coffee-v2-svc-80",
+		"default-cafe-ingress-cafe.example.com-tea-svc-80",
+	}
+	if !reflect.DeepEqual(upstreamNames, expectedUpstreamNames) {
+		t.Errorf("generateNginxCfg returned upstreams %v, but expected %v", upstreamNames, expectedUpstreamNames)
+	}
+
+	coffeeLocation := result.Servers[0].Locations[0]
+	expectedUpstreamName := "$split_default_hcafe_hingress_hcafe_dexample_dcom_hcoffee_hsvc_h80"
+	if coffeeLocation.Upstream.Name != expectedUpstreamName {
+		t.Errorf("generateNginxCfg returned location upstream %v, but expected %v", coffeeLocation.Upstream.Name, expectedUpstreamName)
+	}
+	if coffeeLocation.Rewrite != "" {
+		t.Errorf("generateNginxCfg returned rewrite %q for a location with splits, but expected no rewrite", coffeeLocation.Rewrite)
+	}
+}
+
+func TestGenerateNginxCfgWithMatches(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[MatchesAnnotation] = "serviceName=tea-svc header=X-Canary:always target=tea-canary-svc;" +
+		"serviceName=tea-svc cookie=beta:1 target=tea-beta-svc"
+	cafeIngressEx.Endpoints["tea-canary-svc80"] = []string{"10.0.0.3:80"}
+	cafeIngressEx.Endpoints["tea-beta-svc80"] = []string{"10.0.0.4:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+
+	variable := "match_default_hcafe_hingress_hcafe_dexample_dcom_htea_hsvc_h80"
+	expectedMaps := []Map{
+		{
+			Source:   "$http_x_canary",
+			Variable: variable + "_cond_0",
+			Parameters: []Parameter{
+				{Value: `"always"`, Result: "1"},
+				{Value: "default", Result: "0"},
+			},
+		},
+		{
+			Source:   "$cookie_beta",
+			Variable: variable + "_cond_1",
+			Parameters: []Parameter{
+				{Value: `"1"`, Result: "1"},
+				{Value: "default", Result: "0"},
+			},
+		},
+		{
+			Source:   "$" + variable + "_cond_0$" + variable + "_cond_1",
+			Variable: variable,
+			Parameters: []Parameter{
+				{Value: "~^1", Result: "/internal_" + variable + "_0"},
+				{Value: "~^01", Result: "/internal_" + variable + "_1"},
+				{Value: "default", Result: "/internal_" + variable + "_default"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(result.Maps, expectedMaps) {
+		t.Errorf("generateNginxCfg returned Maps \n%+v, but expected \n%+v", result.Maps, expectedMaps)
+	}
+
+	type locationSummary struct {
+		Path          string
+		Upstream      string
+		Rewrite       string
+		Internal      bool
+		MatchVariable string
+	}
+	var locations []locationSummary
+	for _, loc := range result.Servers[0].Locations {
+		locations = append(locations, locationSummary{loc.Path, loc.Upstream.Name, loc.Rewrite, loc.Internal, loc.MatchVariable})
+	}
+	expectedLocations := []locationSummary{
+		{Path: "/coffee", Upstream: "default-cafe-ingress-cafe.example.com-coffee-svc-80"},
+		{Path: "/tea", MatchVariable: variable},
+		{Path: "= /internal_" + variable + "_0", Upstream: "default-cafe-ingress-cafe.example.com-tea-canary-svc-80", Rewrite: "$request_uri", Internal: true},
+		{Path: "= /internal_" + variable + "_1", Upstream: "default-cafe-ingress-cafe.example.com-tea-beta-svc-80", Rewrite: "$request_uri", Internal: true},
+		{Path: "= /internal_" + variable + "_default", Upstream: "default-cafe-ingress-cafe.example.com-tea-svc-80", Rewrite: "$request_uri", Internal: true},
+	}
+	if !reflect.DeepEqual(locations, expectedLocations) {
+		t.Errorf("generateNginxCfg returned locations \n%+v, but expected \n%+v", locations, expectedLocations)
+	}
+}
+
+func TestGenerateNginxCfgWithLimitReq(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations["nginx.org/limit-req-rate"] = "100r/m"
+	cafeIngressEx.Ingress.Annotations["nginx.org/limit-req-burst"] = "20"
+	cafeIngressEx.Ingress.Annotations["nginx.org/limit-req-nodelay"] = "true"
+	cafeIngressEx.Ingress.Annotations["nginx.org/limit-req-reject-code"] = "429"
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+
+	expectedZones := []LimitReqZone{
+		{
+			Name: "ing_default_cafe-ingress_54b56b55",
+			Key:  "$binary_remote_addr",
+			Size: "10m",
+			Rate: "100r/m",
+		},
+	}
+	if !reflect.DeepEqual(result.LimitReqZones, expectedZones) {
+		t.Errorf("generateNginxCfg returned LimitReqZones %+v, but expected %+v", result.LimitReqZones, expectedZones)
+	}
+
+	expectedLimitReq := &LimitReq{
+		Zone:       "ing_default_cafe-ingress_54b56b55",
+		Burst:      20,
+		NoDelay:    true,
+		RejectCode: 429,
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.LimitReq, expectedLimitReq) {
+			t.Errorf("generateNginxCfg returned LimitReq %+v for location %v, but expected %+v", loc.LimitReq, loc.Path, expectedLimitReq)
+		}
+	}
+}
+
 func TestGenerateNginxCfgForJWT(t *testing.T) {
 	cafeIngressEx := createCafeIngressEx()
 	cafeIngressEx.Ingress.Annotations["nginx.com/jwt-key"] = "cafe-jwk"
@@ -601,8 +897,8 @@ func TestGenerateNginxCfgForJWT(t *testing.T) {
 		},
 	}
 
-	pems := map[string]string{
-		"cafe.example.com": "/etc/nginx/secrets/default-cafe-secret",
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
 	}
 
 	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
@@ -615,6 +911,127 @@ func TestGenerateNginxCfgForJWT(t *testing.T) {
 	}
 }
 
+func TestGenerateNginxCfgForBasicAuth(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[BasicAuthSecretAnnotation] = "cafe-htpasswd"
+	cafeIngressEx.Ingress.Annotations["nginx.org/basic-auth-realm"] = "Cafe App"
+	cafeIngressEx.BasicAuthSecret = BasicAuthSecret{
+		Name: "cafe-htpasswd",
+		Secret: &api_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "cafe-htpasswd",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				HtpasswdSecretKey: []byte("user:$apr1$hash"),
+			},
+		},
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+	cnf.nginxAPI = nil
+
+	expected := &BasicAuth{
+		Secret: "/etc/nginx/secrets/default-cafe-htpasswd",
+		Realm:  "Cafe App",
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	if !reflect.DeepEqual(result.Servers[0].BasicAuth, expected) {
+		t.Errorf("generateNginxCfg returned BasicAuth %+v for a server, but expected %+v", result.Servers[0].BasicAuth, expected)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if loc.BasicAuth != nil {
+			t.Errorf("generateNginxCfg returned BasicAuth %+v for location %v, but expected none", loc.BasicAuth, loc.Path)
+		}
+	}
+
+	isMinion := true
+	result = cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, isMinion)
+	if result.Servers[0].BasicAuth != nil {
+		t.Errorf("generateNginxCfg returned BasicAuth %+v for a server of a minion, but expected none", result.Servers[0].BasicAuth)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.BasicAuth, expected) {
+			t.Errorf("generateNginxCfg returned BasicAuth %+v for location %v of a minion, but expected %+v", loc.BasicAuth, loc.Path, expected)
+		}
+	}
+}
+
+func TestGenerateNginxCfgForClientCertVerification(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[ClientCASecretAnnotation] = "cafe-ca"
+	cafeIngressEx.Ingress.Annotations["nginx.org/ssl-verify-client"] = "optional"
+	cafeIngressEx.Ingress.Annotations["nginx.org/ssl-verify-depth"] = "2"
+	cafeIngressEx.ClientCASecret = ClientCASecret{
+		Name: "cafe-ca",
+		Secret: &api_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "cafe-ca",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				CASecretKey: []byte("ca"),
+			},
+		},
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	expected := &ClientCertVerification{
+		CA:     "/etc/nginx/secrets/default-cafe-ca",
+		Verify: "optional",
+		Depth:  2,
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+	if !reflect.DeepEqual(result.Servers[0].ClientCertVerification, expected) {
+		t.Errorf("generateNginxCfg returned ClientCertVerification %+v, but expected %+v", result.Servers[0].ClientCertVerification, expected)
+	}
+
+	result = cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	if result.Servers[0].ClientCertVerification != nil {
+		t.Errorf("generateNginxCfg returned ClientCertVerification %+v for a server without TLS, but expected none", result.Servers[0].ClientCertVerification)
+	}
+}
+
+func TestGenerateNginxCfgWithMissingClientCASecret(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[ClientCASecretAnnotation] = "cafe-ca"
+	cafeIngressEx.ClientCASecret = ClientCASecret{
+		Name: "cafe-ca",
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	if result.Servers[0].ClientCertVerification != nil {
+		t.Errorf("generateNginxCfg returned ClientCertVerification %+v, but expected none", result.Servers[0].ClientCertVerification)
+	}
+	expectedCiphers := "NULL"
+	if result.Servers[0].SSLCiphers != expectedCiphers {
+		t.Errorf("generateNginxCfg returned SSLCiphers %v, but expected %v", result.Servers[0].SSLCiphers, expectedCiphers)
+	}
+}
+
 func TestGenerateNginxCfgForMergeableIngresses(t *testing.T) {
 	mergeableIngresses := createMergeableCafeIngress()
 	expected := createExpectedConfigForMergeableCafeIngress()
@@ -710,8 +1127,8 @@ func TestGenerateNginxCfgWithMissingTLSSecret(t *testing.T) {
 		t.Errorf("Failed to create a test configurator: %v", err)
 	}
 
-	pems := map[string]string{
-		"cafe.example.com": pemFileNameForMissingTLSSecret,
+	pems := map[string][]string{
+		"cafe.example.com": {pemFileNameForMissingTLSSecret},
 	}
 
 	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
@@ -730,18 +1147,215 @@ func TestGenerateNginxCfgWithWildcardTLSSecret(t *testing.T) {
 		t.Errorf("Failed to create a test configurator: %v", err)
 	}
 
-	pems := map[string]string{
-		"cafe.example.com": pemFileNameForWildcardTLSSecret,
+	pems := map[string][]string{
+		"cafe.example.com": {pemFileNameForWildcardTLSSecret},
 	}
 
 	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
 
+	expected := []SSLCertificate{
+		{
+			Certificate: pemFileNameForWildcardTLSSecret,
+			Key:         pemFileNameForWildcardTLSSecret,
+		},
+	}
+
 	resultServer := result.Servers[0]
-	if !reflect.DeepEqual(resultServer.SSLCertificate, pemFileNameForWildcardTLSSecret) {
-		t.Errorf("generateNginxCfg returned SSLCertificate %v,  but expected %v", resultServer.SSLCertificate, pemFileNameForWildcardTLSSecret)
+	if !reflect.DeepEqual(resultServer.SSLCertificates, expected) {
+		t.Errorf("generateNginxCfg returned SSLCertificates %v,  but expected %v", resultServer.SSLCertificates, expected)
 	}
-	if !reflect.DeepEqual(resultServer.SSLCertificateKey, pemFileNameForWildcardTLSSecret) {
-		t.Errorf("generateNginxCfg returned SSLCertificateKey %v,  but expected %v", resultServer.SSLCertificateKey, pemFileNameForWildcardTLSSecret)
+}
+
+func TestGetNameForHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{
+			host:     "cafe.example.com",
+			expected: "cafe.example.com",
+		},
+		{
+			host:     "*.example.com",
+			expected: "_wildcard.example.com",
+		},
+		{
+			host:     "",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		result := getNameForHost(test.host)
+		if result != test.expected {
+			t.Errorf("getNameForHost(%q) returned %q, but expected %q", test.host, result, test.expected)
+		}
+	}
+}
+
+func TestGetServerNameForHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{
+			host:     "cafe.example.com",
+			expected: "cafe.example.com",
+		},
+		{
+			host:     "*.example.com",
+			expected: `~^[^.]+\.example\.com$`,
+		},
+		{
+			host:     "*.cafe.example.com",
+			expected: `~^[^.]+\.cafe\.example\.com$`,
+		},
+	}
+
+	for _, test := range tests {
+		result := getServerNameForHost(test.host)
+		if result != test.expected {
+			t.Errorf("getServerNameForHost(%q) returned %q, but expected %q", test.host, result, test.expected)
+		}
+	}
+}
+
+func TestGetPemFilesForHost(t *testing.T) {
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+		"*.example.com":    {"/etc/nginx/secrets/default-wildcard-secret"},
+	}
+
+	tests := []struct {
+		host     string
+		expected []string
+		exists   bool
+	}{
+		{
+			host:     "cafe.example.com",
+			expected: []string{"/etc/nginx/secrets/default-cafe-secret"},
+			exists:   true,
+		},
+		{
+			host:     "tea.example.com",
+			expected: []string{"/etc/nginx/secrets/default-wildcard-secret"},
+			exists:   true,
+		},
+		{
+			host:     "*.example.com",
+			expected: []string{"/etc/nginx/secrets/default-wildcard-secret"},
+			exists:   true,
+		},
+		{
+			host:     "green.tea.example.com",
+			expected: nil,
+			exists:   false,
+		},
+		{
+			host:     "example.com",
+			expected: nil,
+			exists:   false,
+		},
+		{
+			host:     "",
+			expected: nil,
+			exists:   false,
+		},
+	}
+
+	for _, test := range tests {
+		result, exists := getPemFilesForHost(pems, test.host)
+		if exists != test.exists || !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("getPemFilesForHost(%q) returned %v, %v, but expected %v, %v", test.host, result, exists, test.expected, test.exists)
+		}
+	}
+}
+
+func TestGenerateNginxCfgForWildcardHost(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Spec.TLS[0].Hosts = []string{"*.example.com"}
+	cafeIngressEx.Ingress.Spec.Rules[0].Host = "*.example.com"
+	cafeIngressEx.Ingress.Spec.Rules = append(cafeIngressEx.Ingress.Spec.Rules, extensions.IngressRule{
+		Host: "tea.example.com",
+		IngressRuleValue: extensions.IngressRuleValue{
+			HTTP: &extensions.HTTPIngressRuleValue{
+				Paths: []extensions.HTTPIngressPath{
+					{
+						Path: "/",
+						Backend: extensions.IngressBackend{
+							ServiceName: "tea-svc",
+							ServicePort: intstr.FromString("80"),
+						},
+					},
+				},
+			},
+		},
+	})
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"*.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	expectedCerts := []SSLCertificate{
+		{
+			Certificate: "/etc/nginx/secrets/default-cafe-secret",
+			Key:         "/etc/nginx/secrets/default-cafe-secret",
+		},
+	}
+
+	wildcardServer := result.Servers[0]
+	if wildcardServer.Name != `~^[^.]+\.example\.com$` {
+		t.Errorf("generateNginxCfg returned server name %v, but expected ~^[^.]+\\.example\\.com$", wildcardServer.Name)
+	}
+	if wildcardServer.StatusZone != "_wildcard.example.com" {
+		t.Errorf("generateNginxCfg returned status zone %v, but expected _wildcard.example.com", wildcardServer.StatusZone)
+	}
+	if upsName := wildcardServer.Locations[0].Upstream.Name; upsName != "default-cafe-ingress-_wildcard.example.com-coffee-svc-80" {
+		t.Errorf("generateNginxCfg returned upstream %v, but expected default-cafe-ingress-_wildcard.example.com-coffee-svc-80", upsName)
+	}
+	if !reflect.DeepEqual(wildcardServer.SSLCertificates, expectedCerts) {
+		t.Errorf("generateNginxCfg returned SSLCertificates %v, but expected %v", wildcardServer.SSLCertificates, expectedCerts)
+	}
+
+	// the host matches the wildcard host of the TLS section
+	teaServer := result.Servers[1]
+	if !teaServer.SSL || !reflect.DeepEqual(teaServer.SSLCertificates, expectedCerts) {
+		t.Errorf("generateNginxCfg returned SSL %v and SSLCertificates %v for tea.example.com, but expected true and %v",
+			teaServer.SSL, teaServer.SSLCertificates, expectedCerts)
+	}
+}
+
+func TestUpdateTLSSecretsWithHostNotInCertificate(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Spec.TLS[0].Hosts = []string{"cafe.example.com", "tea.example.com"}
+	cafeIngressEx.TLSSecrets["cafe-secret"] = &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe-secret",
+			Namespace: "default",
+		},
+		Data: createTestTLSSecretData("cafe.example.com"),
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	expected := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+		"tea.example.com":  {pemFileNameForMissingTLSSecret},
+	}
+
+	result := cnf.updateTLSSecrets(&cafeIngressEx)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("updateTLSSecrets returned %v, but expected %v", result, expected)
 	}
 }
 
@@ -762,6 +1376,90 @@ func TestAddOrUpdateIngress(t *testing.T) {
 	}
 }
 
+func TestAddOrUpdateAndDeleteIngressWithLimitReq(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+	ingress := createCafeIngressEx()
+	ingress.Ingress.Annotations["nginx.org/limit-req-rate"] = "10r/s"
+	ingress.Ingress.Annotations["nginx.org/limit-req-key"] = "$http_x_tenant"
+
+	err = cnf.AddOrUpdateIngress(&ingress)
+	if err != nil {
+		t.Errorf("AddOrUpdateIngress returned:  \n%v, but expected: \n%v", err, nil)
+	}
+
+	expectedZones := []LimitReqZone{
+		{
+			Name: "ing_default_cafe-ingress_5ca1d9a4",
+			Key:  "$http_x_tenant",
+			Size: "10m",
+			Rate: "10r/s",
+		},
+	}
+	zones := cnf.generateLimitReqZones()
+	if !reflect.DeepEqual(zones, expectedZones) {
+		t.Errorf("generateLimitReqZones returned %+v after AddOrUpdateIngress, but expected %+v", zones, expectedZones)
+	}
+
+	err = cnf.DeleteIngress("default/cafe-ingress")
+	if err != nil {
+		t.Errorf("DeleteIngress returned:  \n%v, but expected: \n%v", err, nil)
+	}
+
+	zones = cnf.generateLimitReqZones()
+	if len(zones) != 0 {
+		t.Errorf("generateLimitReqZones returned %+v after DeleteIngress, but expected no zones", zones)
+	}
+}
+
+func TestGetNameForLimitReqZone(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	zone := LimitReqZone{Key: "$binary_remote_addr", Size: "10m", Rate: "100r/m"}
+
+	expected := "ing_default_cafe-ingress_54b56b55"
+	if result := getNameForLimitReqZone(cafeIngressEx.Ingress, zone); result != expected {
+		t.Errorf("getNameForLimitReqZone() returned %q, but expected %q", result, expected)
+	}
+
+	// a zone with changed parameters gets a new name
+	changedZones := []LimitReqZone{
+		{Key: "$http_x_tenant", Size: "10m", Rate: "100r/m"},
+		{Key: "$binary_remote_addr", Size: "20m", Rate: "100r/m"},
+		{Key: "$binary_remote_addr", Size: "10m", Rate: "10r/s"},
+	}
+	for _, changedZone := range changedZones {
+		if result := getNameForLimitReqZone(cafeIngressEx.Ingress, changedZone); result == expected {
+			t.Errorf("getNameForLimitReqZone() returned the same name %q for the zone %+v", result, changedZone)
+		}
+	}
+}
+
+func TestAddLimitReqZones(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	cafeZone := LimitReqZone{Name: "ing_default_cafe", Key: "$binary_remote_addr", Size: "10m", Rate: "10r/s"}
+	if err := cnf.updateLimitReqZones("default-cafe", []LimitReqZone{cafeZone}); err != nil {
+		t.Fatalf("updateLimitReqZones() returned an error: %v", err)
+	}
+
+	// the zones of the previous config file are kept until the new file is written
+	updatedCafeZone := LimitReqZone{Name: "ing_default_cafe", Key: "$binary_remote_addr", Size: "10m", Rate: "20r/s"}
+	teaZone := LimitReqZone{Name: "ing_default_tea", Key: "$binary_remote_addr", Size: "10m", Rate: "10r/s"}
+	if err := cnf.addLimitReqZones("default-cafe", []LimitReqZone{updatedCafeZone, teaZone}); err != nil {
+		t.Fatalf("addLimitReqZones() returned an error: %v", err)
+	}
+
+	expectedZones := []LimitReqZone{cafeZone, teaZone}
+	if zones := cnf.generateLimitReqZones(); !reflect.DeepEqual(zones, expectedZones) {
+		t.Errorf("generateLimitReqZones() returned %+v after addLimitReqZones(), but expected %+v", zones, expectedZones)
+	}
+}
+
 func TestAddOrUpdateMergeableIngress(t *testing.T) {
 	cnf, err := createTestConfigurator()
 	if err != nil {
@@ -859,6 +1557,10 @@ func TestUpdateEndpointsFailsWithInvalidTemplate(t *testing.T) {
 	if err == nil {
 		t.Errorf("UpdateEndpoints returned\n%v, but expected \n%v", nil, "template execution error")
 	}
+	resErrs, ok := err.(*ResourceErrors)
+	if !ok || resErrs.IngressError(ingress.Ingress) == nil {
+		t.Errorf("UpdateEndpoints returned %v, but expected the error of the Ingress %v", err, ingress.Ingress.Name)
+	}
 }
 
 func TestUpdateEndpointsMergeableIngressFailsWithInvalidTemplate(t *testing.T) {
@@ -873,4 +1575,145 @@ func TestUpdateEndpointsMergeableIngressFailsWithInvalidTemplate(t *testing.T) {
 	if err == nil {
 		t.Errorf("UpdateEndpointsMergeableIngress returned \n%v, but expected \n%v", nil, "template execution error")
 	}
+	resErrs, ok := err.(*ResourceErrors)
+	if !ok || resErrs.IngressError(mergeableIngress.Minions[0].Ingress) == nil {
+		t.Errorf("UpdateEndpointsMergeableIngress returned %v, but expected the error of the Minion %v", err, mergeableIngress.Minions[0].Ingress.Name)
+	}
+}
+
+func TestGenerateNginxCfgWithMultipleTLSSecrets(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {
+			"/etc/nginx/secrets/default-cafe-secret-rsa",
+			pemFileNameForMissingTLSSecret,
+			"/etc/nginx/secrets/default-cafe-secret-ecdsa",
+			"/etc/nginx/secrets/default-cafe-secret-rsa",
+		},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	expected := []SSLCertificate{
+		{
+			Certificate: "/etc/nginx/secrets/default-cafe-secret-rsa",
+			Key:         "/etc/nginx/secrets/default-cafe-secret-rsa",
+		},
+		{
+			Certificate: "/etc/nginx/secrets/default-cafe-secret-ecdsa",
+			Key:         "/etc/nginx/secrets/default-cafe-secret-ecdsa",
+		},
+	}
+
+	resultServer := result.Servers[0]
+	if !reflect.DeepEqual(resultServer.SSLCertificates, expected) {
+		t.Errorf("generateNginxCfg returned SSLCertificates %v,  but expected %v", resultServer.SSLCertificates, expected)
+	}
+	if resultServer.SSLCiphers != "" {
+		t.Errorf("generateNginxCfg returned SSLCiphers %v,  but expected none", resultServer.SSLCiphers)
+	}
+}
+
+func TestGenerateNginxCfgWithSSLStapling(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations["nginx.org/ssl-stapling"] = "true"
+	cafeIngressEx.Ingress.Annotations["nginx.org/ssl-stapling-verify"] = "true"
+	cafeIngressEx.Ingress.Annotations[TrustedCertSecretAnnotation] = "cafe-chain"
+	cafeIngressEx.TrustedCertSecret = TrustedCertSecret{
+		Name: "cafe-chain",
+		Secret: &api_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "cafe-chain",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				CASecretKey: []byte("chain"),
+			},
+		},
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	expected := &SSLStapling{
+		Verify:             true,
+		TrustedCertificate: "/etc/nginx/secrets/default-cafe-chain",
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+	if !reflect.DeepEqual(result.Servers[0].SSLStapling, expected) {
+		t.Errorf("generateNginxCfg returned SSLStapling %+v, but expected %+v", result.Servers[0].SSLStapling, expected)
+	}
+
+	// a missing chain Secret doesn't disable the stapling
+	cafeIngressEx.TrustedCertSecret.Secret = nil
+	expected.TrustedCertificate = ""
+
+	result = cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+	if !reflect.DeepEqual(result.Servers[0].SSLStapling, expected) {
+		t.Errorf("generateNginxCfg returned SSLStapling %+v for a missing Secret, but expected %+v", result.Servers[0].SSLStapling, expected)
+	}
+
+	isMinion := true
+	result = cnf.generateNginxCfg(&cafeIngressEx, pems, isMinion)
+	if result.Servers[0].SSLStapling != nil {
+		t.Errorf("generateNginxCfg returned SSLStapling %+v for a minion, but expected nil", result.Servers[0].SSLStapling)
+	}
+}
+
+func TestEncodeVariableName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{
+			name:     "default-cafe-ingress-cafe.example.com-coffee-svc-80",
+			expected: "default_hcafe_hingress_hcafe_dexample_dcom_hcoffee_hsvc_h80",
+		},
+		{
+			name:     "default-cafe-ingress-_wildcard.example.com-coffee-svc-80",
+			expected: "default_hcafe_hingress_h__wildcard_dexample_dcom_hcoffee_hsvc_h80",
+		},
+		{
+			name:     "default/cafe",
+			expected: "default_x2Fcafe",
+		},
+	}
+
+	for _, test := range tests {
+		result := encodeVariableName(test.name)
+		if result != test.expected {
+			t.Errorf("encodeVariableName(%q) returned %q, but expected %q", test.name, result, test.expected)
+		}
+	}
+
+	// the names that differ only in '-', '.' and '_' get different variables
+	names := []string{"cafe.example.com", "cafe-example.com", "cafe_example.com"}
+	variables := make(map[string]string)
+	for _, name := range names {
+		variable := getNameForSplitClientVariable(name)
+		if other, exists := variables[variable]; exists {
+			t.Errorf("getNameForSplitClientVariable() returned %q for both %q and %q", variable, other, name)
+		}
+		variables[variable] = name
+	}
+}
+
+func TestGetNameForMatchVariable(t *testing.T) {
+	first := getNameForMatchVariable("default-cafe-ingress-cafe.example.com-tea-svc-80")
+	second := getNameForMatchVariable("default-cafe-ingress-cafe-example.com-tea-svc-80")
+	if first == second {
+		t.Errorf("getNameForMatchVariable() returned %q for different upstreams", first)
+	}
 }
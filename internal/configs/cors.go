@@ -0,0 +1,189 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+// CORSAllowOriginAnnotation is the annotation where the origins allowed to make cross-origin requests are specified.
+// An origin that starts with '~' is a regular expression.
+const CORSAllowOriginAnnotation = "nginx.org/cors-allow-origin"
+
+// CORSAllowMethodsAnnotation is the annotation where the methods allowed in cross-origin requests are specified.
+const CORSAllowMethodsAnnotation = "nginx.org/cors-allow-methods"
+
+// CORSAllowHeadersAnnotation is the annotation where the headers allowed in cross-origin requests are specified.
+const CORSAllowHeadersAnnotation = "nginx.org/cors-allow-headers"
+
+// CORSAllowCredentialsAnnotation is the annotation that allows cross-origin requests with credentials.
+const CORSAllowCredentialsAnnotation = "nginx.org/cors-allow-credentials"
+
+// CORSMaxAgeAnnotation is the annotation where the time in seconds the results of a preflight request can be cached is specified.
+const CORSMaxAgeAnnotation = "nginx.org/cors-max-age"
+
+var defaultCORSAllowMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// CORSPolicy holds the CORS policy of an Ingress.
+type CORSPolicy struct {
+	AllowAnyOrigin   bool
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	AllowCredentials bool
+	MaxAge           *int
+}
+
+// ParseCORSPolicy parses the CORS annotations of the Ingress. It returns nil if CORS is not configured.
+func ParseCORSPolicy(ing *extensions.Ingress) (*CORSPolicy, error) {
+	allowOrigin, exists := ing.Annotations[CORSAllowOriginAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	policy := &CORSPolicy{
+		AllowMethods: defaultCORSAllowMethods,
+	}
+
+	for _, origin := range strings.Split(allowOrigin, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			policy.AllowAnyOrigin = true
+			continue
+		}
+		if err := validateCORSOrigin(origin); err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", CORSAllowOriginAnnotation, err)
+		}
+		policy.AllowOrigins = append(policy.AllowOrigins, origin)
+	}
+
+	if policy.AllowAnyOrigin && len(policy.AllowOrigins) > 0 {
+		return nil, fmt.Errorf("Invalid %v: '*' can't be combined with other origins", CORSAllowOriginAnnotation)
+	}
+
+	if methods, exists := ing.Annotations[CORSAllowMethodsAnnotation]; exists {
+		policy.AllowMethods = nil
+		for _, method := range strings.Split(methods, ",") {
+			method = strings.TrimSpace(method)
+			if !validMethods[method] {
+				return nil, fmt.Errorf("Invalid method %q in %v", method, CORSAllowMethodsAnnotation)
+			}
+			policy.AllowMethods = append(policy.AllowMethods, method)
+		}
+	}
+
+	if headers, exists := ing.Annotations[CORSAllowHeadersAnnotation]; exists {
+		for _, header := range strings.Split(headers, ",") {
+			header = strings.TrimSpace(header)
+			if !headerNameRegexp.MatchString(header) {
+				return nil, fmt.Errorf("Invalid header name %q in %v: must contain only letters, digits and '-'", header, CORSAllowHeadersAnnotation)
+			}
+			policy.AllowHeaders = append(policy.AllowHeaders, header)
+		}
+	}
+
+	if allowCredentials, exists, err := GetMapKeyAsBool(ing.Annotations, CORSAllowCredentialsAnnotation, ing); exists {
+		if err != nil {
+			return nil, err
+		}
+		policy.AllowCredentials = allowCredentials
+	}
+
+	// browsers don't send credentials to servers that allow any origin
+	if policy.AllowAnyOrigin && policy.AllowCredentials {
+		return nil, fmt.Errorf("%v can't be enabled if %v is '*'", CORSAllowCredentialsAnnotation, CORSAllowOriginAnnotation)
+	}
+
+	if maxAge, exists, err := GetMapKeyAsInt(ing.Annotations, CORSMaxAgeAnnotation, ing); exists {
+		if err != nil {
+			return nil, err
+		}
+		if maxAge < 0 {
+			return nil, fmt.Errorf("Invalid %v: must not be negative", CORSMaxAgeAnnotation)
+		}
+		policy.MaxAge = &maxAge
+	}
+
+	return policy, nil
+}
+
+// validateCORSOrigin validates an origin of the format <scheme>://<host>[:<port>] or a regular expression that starts with '~'.
+func validateCORSOrigin(origin string) error {
+	// the origin is quoted in the map block
+	if strings.ContainsAny(origin, " \t\"'") {
+		return fmt.Errorf("Origin %q must not contain whitespace or quotes", origin)
+	}
+
+	if strings.HasPrefix(origin, "~") {
+		if _, err := regexp.Compile(strings.TrimPrefix(origin, "~")); err != nil {
+			return fmt.Errorf("Invalid regular expression %q: %v", origin, err)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Origin %q must have the http or https scheme", origin)
+	}
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("Origin %q must be of the format <scheme>://<host>[:<port>]", origin)
+	}
+	return nil
+}
+
+func getCORSPolicy(ingEx *IngressEx) *CORSPolicy {
+	policy, err := ParseCORSPolicy(ingEx.Ingress)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		return nil
+	}
+	return policy
+}
+
+// createCORS creates the configuration of CORS of the locations of the Ingress. For a list of origins,
+// it also creates a map that evaluates to the origin of the request if the origin is allowed.
+func createCORS(ing *extensions.Ingress, policy *CORSPolicy) (*CORS, *Map) {
+	cors := &CORS{
+		AllowMethods:     strings.Join(policy.AllowMethods, ", "),
+		AllowHeaders:     strings.Join(policy.AllowHeaders, ", "),
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+	}
+
+	if policy.AllowAnyOrigin {
+		cors.AllowOrigin = `"*"`
+		return cors, nil
+	}
+
+	variable := getNameForCORSOriginVariable(ing)
+	originMap := &Map{
+		Source:   "$http_origin",
+		Variable: variable,
+		Parameters: []Parameter{
+			{Value: "default", Result: `""`},
+		},
+	}
+	for _, origin := range policy.AllowOrigins {
+		originMap.Parameters = append(originMap.Parameters, Parameter{
+			Value:  `"` + origin + `"`,
+			Result: "$http_origin",
+		})
+	}
+
+	cors.AllowOrigin = "$" + variable
+	// the response depends on the origin of the request
+	cors.Vary = true
+
+	return cors, originMap
+}
+
+func getNameForCORSOriginVariable(ing *extensions.Ingress) string {
+	return "cors_origin_" + encodeVariableName(ing.Namespace+"/"+ing.Name)
+}
@@ -0,0 +1,208 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCORSPolicy(t *testing.T) {
+	maxAge := 3600
+	tests := []struct {
+		annotations map[string]string
+		expected    *CORSPolicy
+		msg         string
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    nil,
+			msg:         "no CORS",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: "*",
+			},
+			expected: &CORSPolicy{
+				AllowAnyOrigin: true,
+				AllowMethods:   defaultCORSAllowMethods,
+			},
+			msg: "any origin",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation:      `https://app.example.com, ~^https://[a-z]+\.example\.org$`,
+				CORSAllowMethodsAnnotation:     "GET, POST",
+				CORSAllowHeadersAnnotation:     "Content-Type,Authorization",
+				CORSAllowCredentialsAnnotation: "true",
+				CORSMaxAgeAnnotation:           "3600",
+			},
+			expected: &CORSPolicy{
+				AllowOrigins:     []string{"https://app.example.com", `~^https://[a-z]+\.example\.org$`},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"Content-Type", "Authorization"},
+				AllowCredentials: true,
+				MaxAge:           &maxAge,
+			},
+			msg: "origins with a regular expression and all parameters",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := ParseCORSPolicy(createIngressWithAnnotations(test.annotations))
+		if err != nil {
+			t.Errorf("ParseCORSPolicy() returned an error for the case of %s: %v", test.msg, err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("ParseCORSPolicy() returned %+v, but expected %+v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
+func TestParseCORSPolicyFails(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		msg         string
+	}{
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: "*, https://app.example.com",
+			},
+			msg: "any origin combined with an origin",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: "https://app.example.com/path",
+			},
+			msg: "origin with a path",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: "app.example.com",
+			},
+			msg: "origin without a scheme",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: `~^https://(app\.example\.com$`,
+			},
+			msg: "invalid regular expression",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: `https://app.example.com"`,
+			},
+			msg: "origin with a quote",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation:  "https://app.example.com",
+				CORSAllowMethodsAnnotation: "GET, FETCH",
+			},
+			msg: "invalid method",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation:  "https://app.example.com",
+				CORSAllowHeadersAnnotation: "Content Type",
+			},
+			msg: "invalid header",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation:      "*",
+				CORSAllowCredentialsAnnotation: "true",
+			},
+			msg: "any origin with credentials",
+		},
+		{
+			annotations: map[string]string{
+				CORSAllowOriginAnnotation: "https://app.example.com",
+				CORSMaxAgeAnnotation:      "-1",
+			},
+			msg: "negative max age",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseCORSPolicy(createIngressWithAnnotations(test.annotations))
+		if err == nil {
+			t.Errorf("ParseCORSPolicy() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestGenerateNginxCfgWithCORS(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[CORSAllowOriginAnnotation] = "https://app.example.com"
+	cafeIngressEx.Ingress.Annotations[CORSAllowMethodsAnnotation] = "GET"
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	expectedMaps := []Map{
+		{
+			Source:   "$http_origin",
+			Variable: "cors_origin_default_x2Fcafe_hingress",
+			Parameters: []Parameter{
+				{Value: "default", Result: `""`},
+				{Value: `"https://app.example.com"`, Result: "$http_origin"},
+			},
+		},
+	}
+	expectedCORS := &CORS{
+		AllowOrigin:  "$cors_origin_default_x2Fcafe_hingress",
+		Vary:         true,
+		AllowMethods: "GET",
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	if !reflect.DeepEqual(result.Maps, expectedMaps) {
+		t.Errorf("generateNginxCfg returned Maps %+v, but expected %+v", result.Maps, expectedMaps)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.CORS, expectedCORS) {
+			t.Errorf("generateNginxCfg returned CORS %+v for location %v, but expected %+v", loc.CORS, loc.Path, expectedCORS)
+		}
+	}
+}
+
+func TestCreateCORSForAnyOrigin(t *testing.T) {
+	policy := &CORSPolicy{
+		AllowAnyOrigin: true,
+		AllowMethods:   []string{"GET", "POST"},
+	}
+	expected := &CORS{
+		AllowOrigin:  `"*"`,
+		AllowMethods: "GET, POST",
+	}
+
+	result, originMap := createCORS(createIngressWithAnnotations(nil), policy)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("createCORS() returned %+v, but expected %+v", result, expected)
+	}
+	if originMap != nil {
+		t.Errorf("createCORS() returned map %+v for any origin, but expected none", originMap)
+	}
+}
+
+func TestGetNameForCORSOriginVariable(t *testing.T) {
+	first := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "a-b",
+			Name:      "c",
+		},
+	}
+	second := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "a",
+			Name:      "b-c",
+		},
+	}
+
+	if getNameForCORSOriginVariable(first) == getNameForCORSOriginVariable(second) {
+		t.Errorf("getNameForCORSOriginVariable() returned %q for different Ingress resources", getNameForCORSOriginVariable(first))
+	}
+}
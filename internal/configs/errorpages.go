@@ -0,0 +1,150 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+// ErrorPagesAnnotation is the annotation where the error pages of an Ingress are specified.
+const ErrorPagesAnnotation = "nginx.org/error-pages"
+
+// ErrorPageRule describes an error page for the status codes Codes. The page is returned either by the Service
+// with the path Path or as the static Body.
+type ErrorPageRule struct {
+	Codes   []int
+	Service *extensions.IngressBackend
+	Path    string
+	Body    string
+}
+
+// ParseErrorPages parses the value of the nginx.org/error-pages annotation. The annotation has the format
+// "<code>[,<code>...] service=<service>:<port>[<path>]|body=<body>[;...]". For every declaration, the responses with
+// the listed status codes are replaced with the response of the Service or with the body. The status codes are preserved.
+func ParseErrorPages(annotation string) ([]ErrorPageRule, error) {
+	var rules []ErrorPageRule
+	seenCodes := make(map[int]bool)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		declaration = strings.TrimSpace(declaration)
+		if declaration == "" {
+			continue
+		}
+
+		rule, err := parseErrorPage(declaration)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range rule.Codes {
+			if seenCodes[code] {
+				return nil, fmt.Errorf("Duplicate error page for the status code %d", code)
+			}
+			seenCodes[code] = true
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("No error pages are specified")
+	}
+
+	return rules, nil
+}
+
+func parseErrorPage(declaration string) (ErrorPageRule, error) {
+	parts := strings.SplitN(declaration, " ", 2)
+	if len(parts) != 2 {
+		return ErrorPageRule{}, fmt.Errorf("Invalid error page format: %s", declaration)
+	}
+
+	var rule ErrorPageRule
+
+	for _, value := range strings.Split(parts[0], ",") {
+		code, err := strconv.Atoi(value)
+		if err != nil || code < 400 || code > 599 {
+			return ErrorPageRule{}, fmt.Errorf("Invalid status code %q in %s: must be between 400 and 599", value, declaration)
+		}
+		rule.Codes = append(rule.Codes, code)
+	}
+
+	page := strings.TrimSpace(parts[1])
+	switch {
+	case strings.HasPrefix(page, "service="):
+		backend, path, err := parseServiceReference(strings.TrimPrefix(page, "service="))
+		if err != nil {
+			return ErrorPageRule{}, fmt.Errorf("Invalid service in %s: %v", declaration, err)
+		}
+		rule.Service = backend
+		rule.Path = path
+	case strings.HasPrefix(page, "body="):
+		body := strings.TrimPrefix(page, "body=")
+		// the body is rendered as a quoted string, where NGINX interprets '$' as the start of a variable
+		if body == "" || strings.ContainsAny(body, "\"\\$") {
+			return ErrorPageRule{}, fmt.Errorf("Invalid body in %s: must be non-empty and must not contain '\"', '\\' or '$'", declaration)
+		}
+		rule.Body = body
+	default:
+		return ErrorPageRule{}, fmt.Errorf("Invalid error page format: %s: must have either service or body", declaration)
+	}
+
+	return rule, nil
+}
+
+func getErrorPages(ingEx *IngressEx) []ErrorPageRule {
+	annotation, exists := ingEx.Ingress.Annotations[ErrorPagesAnnotation]
+	if !exists {
+		return nil
+	}
+
+	rules, err := ParseErrorPages(annotation)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, ErrorPagesAnnotation, err)
+		return nil
+	}
+	return rules
+}
+
+// createErrorPages creates the internal locations that return the error pages and the error_page directives of the locations
+// that use them. For a Service, the upstream of the Service is added to the upstreams map.
+func (cnf *Configurator) createErrorPages(ingEx *IngressEx, rules []ErrorPageRule, upstreams map[string]Upstream,
+	cfg *Config) ([]ErrorPageLocation, []ErrorPage) {
+	var locations []ErrorPageLocation
+	var errorPages []ErrorPage
+
+	for i, rule := range rules {
+		location := ErrorPageLocation{
+			Path: getPathForErrorPageLocation(ingEx.Ingress, i),
+		}
+
+		if rule.Service != nil {
+			name := getNameForUpstream(ingEx.Ingress, emptyHost, rule.Service)
+			if _, exists := upstreams[name]; !exists {
+				upstreams[name] = cnf.createUpstream(ingEx, name, rule.Service, ingEx.Ingress.Namespace, "", cfg)
+			}
+			location.ProxyPass = "http://" + name + rule.Path
+		} else {
+			location.Body = rule.Body
+		}
+
+		var codes []string
+		for _, code := range rule.Codes {
+			codes = append(codes, strconv.Itoa(code))
+		}
+
+		locations = append(locations, location)
+		errorPages = append(errorPages, ErrorPage{
+			Codes:    strings.Join(codes, " "),
+			Location: location.Path,
+		})
+	}
+
+	return locations, errorPages
+}
+
+func getPathForErrorPageLocation(ing *extensions.Ingress, index int) string {
+	return fmt.Sprintf("/internal_error_page_%v_%v_%d", ing.Namespace, ing.Name, index)
+}
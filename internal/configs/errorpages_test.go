@@ -0,0 +1,182 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParseErrorPages(t *testing.T) {
+	annotation := "404 service=errors-svc:80/404.html; 500,502,503 body=<html><body>Try again later</body></html>"
+	expected := []ErrorPageRule{
+		{
+			Codes: []int{404},
+			Service: &extensions.IngressBackend{
+				ServiceName: "errors-svc",
+				ServicePort: intstr.FromInt(80),
+			},
+			Path: "/404.html",
+		},
+		{
+			Codes: []int{500, 502, 503},
+			Body:  "<html><body>Try again later</body></html>",
+		},
+	}
+
+	result, err := ParseErrorPages(annotation)
+	if err != nil {
+		t.Errorf("ParseErrorPages() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseErrorPages() returned %+v, but expected %+v", result, expected)
+	}
+}
+
+func TestParseErrorPagesFails(t *testing.T) {
+	annotations := []string{
+		"",
+		"404",
+		"404 errors-svc:80",
+		"399 body=Moved",
+		"600 body=Unknown",
+		"40x body=Not Found",
+		"404 service=errors-svc",
+		"404 body=",
+		"404 body=Not found: $uri",
+		`404 body=<a href="/">Home</a>`,
+		"404 body=Not Found; 404,500 body=Error",
+	}
+
+	for _, annotation := range annotations {
+		_, err := ParseErrorPages(annotation)
+		if err == nil {
+			t.Errorf("ParseErrorPages(%q) returned no error", annotation)
+		}
+	}
+}
+
+func TestGenerateNginxCfgWithErrorPages(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[ErrorPagesAnnotation] = "404 service=errors-svc:80; 502,503 body=Try again later"
+	cafeIngressEx.Endpoints["errors-svc80"] = []string{"10.0.0.10:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	expectedLocations := []ErrorPageLocation{
+		{
+			Path:      "/internal_error_page_default_cafe-ingress_0",
+			ProxyPass: "http://default-cafe-ingress--errors-svc-80/",
+		},
+		{
+			Path: "/internal_error_page_default_cafe-ingress_1",
+			Body: "Try again later",
+		},
+	}
+	expectedErrorPages := []ErrorPage{
+		{
+			Codes:    "404",
+			Location: "/internal_error_page_default_cafe-ingress_0",
+		},
+		{
+			Codes:    "502 503",
+			Location: "/internal_error_page_default_cafe-ingress_1",
+		},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	if !reflect.DeepEqual(result.Servers[0].ErrorPageLocations, expectedLocations) {
+		t.Errorf("generateNginxCfg returned ErrorPageLocations %+v, but expected %+v", result.Servers[0].ErrorPageLocations, expectedLocations)
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.ErrorPages, expectedErrorPages) {
+			t.Errorf("generateNginxCfg returned ErrorPages %+v for location %v, but expected %+v", loc.ErrorPages, loc.Path, expectedErrorPages)
+		}
+	}
+
+	found := false
+	for _, ups := range result.Upstreams {
+		if ups.Name == "default-cafe-ingress--errors-svc-80" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("generateNginxCfg didn't return the upstream of the error page service")
+	}
+}
+
+func TestCreateUpstreamWithDefaultBackend(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Endpoints["coffee-svc80"] = []string{}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+	cnf.nginxAPI = nil
+	cfg := NewDefaultConfig()
+	backend := &extensions.IngressBackend{
+		ServiceName: "coffee-svc",
+		ServicePort: intstr.FromInt(80),
+	}
+
+	ups := cnf.createUpstream(&cafeIngressEx, "coffee", backend, "default", "", cfg)
+	if !reflect.DeepEqual(ups.UpstreamServers, NewUpstreamWithDefaultServer("coffee").UpstreamServers) {
+		t.Errorf("createUpstream() returned servers %+v without the default backend, but expected the default server", ups.UpstreamServers)
+	}
+
+	cnf.defaultBackendEndpoints = []string{"10.0.0.30:8080"}
+	expected := []UpstreamServer{
+		{
+			Address:     "10.0.0.30",
+			Port:        "8080",
+			MaxFails:    cfg.MaxFails,
+			FailTimeout: cfg.FailTimeout,
+			SlowStart:   cfg.SlowStart,
+		},
+	}
+
+	ups = cnf.createUpstream(&cafeIngressEx, "coffee", backend, "default", "", cfg)
+	if !reflect.DeepEqual(ups.UpstreamServers, expected) {
+		t.Errorf("createUpstream() returned servers %+v, but expected %+v", ups.UpstreamServers, expected)
+	}
+}
+
+func TestGenerateNginxCfgForMergeableIngressesWithErrorPages(t *testing.T) {
+	mergeableIngresses := createMergeableCafeIngress()
+	mergeableIngresses.Master.Ingress.Annotations[ErrorPagesAnnotation] = "404 service=errors-svc:80"
+	mergeableIngresses.Master.Endpoints["errors-svc80"] = []string{"10.0.0.10:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfgForMergeableIngresses(mergeableIngresses)
+
+	expectedUpstreams := []string{
+		"default-cafe-ingress-coffee-minion--errors-svc-80",
+		"default-cafe-ingress-tea-minion--errors-svc-80",
+	}
+	for _, name := range expectedUpstreams {
+		found := false
+		for _, ups := range result.Upstreams {
+			if ups.Name == name {
+				found = true
+				if len(ups.UpstreamServers) != 1 || ups.UpstreamServers[0].Address != "10.0.0.10" {
+					t.Errorf("generateNginxCfgForMergeableIngresses returned upstream servers %+v for %v, but expected 10.0.0.10:80", ups.UpstreamServers, name)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("generateNginxCfgForMergeableIngresses didn't return the upstream %v of the error page service", name)
+		}
+	}
+
+	for _, minion := range mergeableIngresses.Minions {
+		if !reflect.DeepEqual(minion.Endpoints["errors-svc80"], []string{"10.0.0.10:80"}) {
+			t.Errorf("generateNginxCfgForMergeableIngresses didn't add the endpoints of the error page service to the minion %v", minion.Ingress.Name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package configs
+
+import (
+	"strings"
+
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceErrors is returned by the updates of many resources (like UpdateConfig, UpdateEndpoints or DeleteSecret),
+// when the configuration of some resources wasn't updated. The configuration of the other resources is updated
+// and NGINX is reloaded, so that one invalid resource doesn't block the updates of the others.
+type ResourceErrors struct {
+	ingresses      map[string]error
+	virtualServers map[string]error
+	messages       []string
+}
+
+func newResourceErrors() *ResourceErrors {
+	return &ResourceErrors{
+		ingresses:      make(map[string]error),
+		virtualServers: make(map[string]error),
+	}
+}
+
+func getResourceKey(meta *meta_v1.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
+
+func (errs *ResourceErrors) addIngressError(ingEx *IngressEx, err error) {
+	errs.ingresses[getResourceKey(&ingEx.Ingress.ObjectMeta)] = err
+	errs.messages = append(errs.messages, err.Error())
+}
+
+// addMergeableIngressError records the error for the master and all the minions, because they share the configuration file
+func (errs *ResourceErrors) addMergeableIngressError(mergeableIngs *MergeableIngresses, err error) {
+	errs.ingresses[getResourceKey(&mergeableIngs.Master.Ingress.ObjectMeta)] = err
+	for _, minion := range mergeableIngs.Minions {
+		errs.ingresses[getResourceKey(&minion.Ingress.ObjectMeta)] = err
+	}
+	errs.messages = append(errs.messages, err.Error())
+}
+
+func (errs *ResourceErrors) addVirtualServerError(virtualServerEx *VirtualServerEx, err error) {
+	errs.virtualServers[getResourceKey(&virtualServerEx.VirtualServer.ObjectMeta)] = err
+	errs.messages = append(errs.messages, err.Error())
+}
+
+// getError returns nil if the configuration of all the resources was updated
+func (errs *ResourceErrors) getError() error {
+	if len(errs.messages) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (errs *ResourceErrors) Error() string {
+	return strings.Join(errs.messages, "; ")
+}
+
+// IngressError returns the error of the Ingress resource or nil if its configuration was updated
+func (errs *ResourceErrors) IngressError(ing *extensions.Ingress) error {
+	return errs.ingresses[getResourceKey(&ing.ObjectMeta)]
+}
+
+// VirtualServerError returns the error of the VirtualServer resource or nil if its configuration was updated
+func (errs *ResourceErrors) VirtualServerError(vs *conf_v1alpha1.VirtualServer) error {
+	return errs.virtualServers[getResourceKey(&vs.ObjectMeta)]
+}
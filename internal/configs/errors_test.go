@@ -0,0 +1,94 @@
+package configs
+
+import (
+	"errors"
+	"testing"
+
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createTestIngressEx(namespace string, name string) *IngressEx {
+	return &IngressEx{
+		Ingress: &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+		},
+	}
+}
+
+func TestResourceErrors(t *testing.T) {
+	resErrs := newResourceErrors()
+	if err := resErrs.getError(); err != nil {
+		t.Errorf("getError() returned %v for no errors", err)
+	}
+
+	cafeErr := errors.New("cafe error")
+	resErrs.addIngressError(createTestIngressEx("default", "cafe"), cafeErr)
+
+	mergeableErr := errors.New("mergeable error")
+	mergeableIngs := &MergeableIngresses{
+		Master:  createTestIngressEx("default", "master"),
+		Minions: []*IngressEx{createTestIngressEx("default", "minion")},
+	}
+	resErrs.addMergeableIngressError(mergeableIngs, mergeableErr)
+
+	vsErr := errors.New("virtualserver error")
+	vsEx := &VirtualServerEx{
+		VirtualServer: &conf_v1alpha1.VirtualServer{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace: "default",
+				Name:      "cafe",
+			},
+		},
+	}
+	resErrs.addVirtualServerError(vsEx, vsErr)
+
+	if resErrs.getError() == nil {
+		t.Fatalf("getError() returned nil for the errors")
+	}
+
+	expectedMessage := "cafe error; mergeable error; virtualserver error"
+	if resErrs.Error() != expectedMessage {
+		t.Errorf("Error() returned %q but expected %q", resErrs.Error(), expectedMessage)
+	}
+
+	tests := []struct {
+		name     string
+		expected error
+	}{
+		{
+			name:     "cafe",
+			expected: cafeErr,
+		},
+		{
+			name:     "master",
+			expected: mergeableErr,
+		},
+		{
+			name:     "minion",
+			expected: mergeableErr,
+		},
+		{
+			name:     "tea",
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		result := resErrs.IngressError(createTestIngressEx("default", test.name).Ingress)
+		if result != test.expected {
+			t.Errorf("IngressError() returned %v for the Ingress %v but expected %v", result, test.name, test.expected)
+		}
+	}
+
+	if result := resErrs.VirtualServerError(vsEx.VirtualServer); result != vsErr {
+		t.Errorf("VirtualServerError() returned %v but expected %v", result, vsErr)
+	}
+	if result := resErrs.IngressError(createTestIngressEx("other", "cafe").Ingress); result != nil {
+		t.Errorf("IngressError() returned %v for the Ingress of another namespace", result)
+	}
+}
@@ -97,3 +97,49 @@ func ParseSlowStart(s string) (string, error) {
 	}
 	return "", errors.New("Invalid time string")
 }
+
+// ParseTime ensures that the string value is a valid time in the NGINX format.
+func ParseTime(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if validNginxTime.MatchString(s) {
+		return s, nil
+	}
+	return "", errors.New("Invalid time string")
+}
+
+var validNginxRate = regexp.MustCompile(`^[0-9]+r/[sm]$`)
+
+// ParseRate ensures that the string value is a valid request rate in the NGINX format, for example, 10r/s.
+func ParseRate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if validNginxRate.MatchString(s) {
+		return s, nil
+	}
+	return "", errors.New("Invalid rate string")
+}
+
+var validNginxSize = regexp.MustCompile(`^[0-9]+[kKmM]?$`)
+
+// ParseSize ensures that the string value is a valid size in the NGINX format.
+func ParseSize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if validNginxSize.MatchString(s) {
+		return s, nil
+	}
+	return "", errors.New("Invalid size string")
+}
+
+var validNginxVariables = regexp.MustCompile(`^(\$[A-Za-z0-9_]+)+$`)
+
+// ParseVariables ensures that the string value consists only of NGINX variables, for example, $binary_remote_addr.
+func ParseVariables(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if validNginxVariables.MatchString(s) {
+		return s, nil
+	}
+	return "", errors.New("Invalid variables string")
+}
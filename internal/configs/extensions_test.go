@@ -118,3 +118,63 @@ func TestParseSlowStart(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRate(t *testing.T) {
+	var testsWithValidInput = []string{"1r/s", "10r/s", "100r/m"}
+	var invalidInput = []string{"", "10", "10r", "r/s", "10r/h", "-1r/s", "10 r/s"}
+	for _, test := range testsWithValidInput {
+		result, err := ParseRate(test)
+		if err != nil {
+			t.Errorf("TestParseRate(%q) returned an error for valid input", test)
+		}
+		if test != result {
+			t.Errorf("TestParseRate(%q) returned %q expected %q", test, result, test)
+		}
+	}
+	for _, test := range invalidInput {
+		result, err := ParseRate(test)
+		if err == nil {
+			t.Errorf("TestParseRate(%q) didn't return error. Returned: %q", test, result)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	var testsWithValidInput = []string{"1024", "10k", "10K", "1m", "1M"}
+	var invalidInput = []string{"", "m", "10g", "-1m", "1.5m"}
+	for _, test := range testsWithValidInput {
+		result, err := ParseSize(test)
+		if err != nil {
+			t.Errorf("TestParseSize(%q) returned an error for valid input", test)
+		}
+		if test != result {
+			t.Errorf("TestParseSize(%q) returned %q expected %q", test, result, test)
+		}
+	}
+	for _, test := range invalidInput {
+		result, err := ParseSize(test)
+		if err == nil {
+			t.Errorf("TestParseSize(%q) didn't return error. Returned: %q", test, result)
+		}
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	var testsWithValidInput = []string{"$binary_remote_addr", "$http_x_api_key", "$host$uri"}
+	var invalidInput = []string{"", "binary_remote_addr", "$", "$http-x-api-key", "$host $uri", "${host}"}
+	for _, test := range testsWithValidInput {
+		result, err := ParseVariables(test)
+		if err != nil {
+			t.Errorf("TestParseVariables(%q) returned an error for valid input", test)
+		}
+		if test != result {
+			t.Errorf("TestParseVariables(%q) returned %q expected %q", test, result, test)
+		}
+	}
+	for _, test := range invalidInput {
+		result, err := ParseVariables(test)
+		if err == nil {
+			t.Errorf("TestParseVariables(%q) didn't return error. Returned: %q", test, result)
+		}
+	}
+}
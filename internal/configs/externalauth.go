@@ -0,0 +1,202 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AuthURLAnnotation is the annotation where the URL of an external authentication service is specified.
+const AuthURLAnnotation = "nginx.org/auth-url"
+
+// AuthServiceAnnotation is the annotation where a Service of an external authentication service is specified.
+const AuthServiceAnnotation = "nginx.org/auth-service"
+
+// AuthResponseHeadersAnnotation is the annotation where the headers of the response of an external authentication service
+// that are passed to the upstreams are specified.
+const AuthResponseHeadersAnnotation = "nginx.org/auth-response-headers"
+
+// AuthSigninAnnotation is the annotation where the URL clients are redirected to if the authentication fails is specified.
+const AuthSigninAnnotation = "nginx.org/auth-signin"
+
+// ExternalAuth holds the configuration of the external authentication of an Ingress.
+// Either URL or Service is set.
+type ExternalAuth struct {
+	URL             string
+	Service         *extensions.IngressBackend
+	Path            string
+	ResponseHeaders []string
+	SigninURL       string
+}
+
+// ParseExternalAuth parses the external authentication annotations of the Ingress. It returns nil if the
+// external authentication is not configured.
+func ParseExternalAuth(ing *extensions.Ingress) (*ExternalAuth, error) {
+	authURL, urlExists := ing.Annotations[AuthURLAnnotation]
+	authService, serviceExists := ing.Annotations[AuthServiceAnnotation]
+
+	if !urlExists && !serviceExists {
+		return nil, nil
+	}
+	if urlExists && serviceExists {
+		return nil, fmt.Errorf("Only one of %v and %v can be specified", AuthURLAnnotation, AuthServiceAnnotation)
+	}
+
+	externalAuth := &ExternalAuth{}
+
+	if urlExists {
+		if err := validateHTTPURL(authURL); err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", AuthURLAnnotation, err)
+		}
+		externalAuth.URL = authURL
+	} else {
+		backend, path, err := parseServiceReference(authService)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", AuthServiceAnnotation, err)
+		}
+		externalAuth.Service = backend
+		externalAuth.Path = path
+	}
+
+	if headers, exists := ing.Annotations[AuthResponseHeadersAnnotation]; exists {
+		for _, header := range strings.Split(headers, ",") {
+			header = strings.TrimSpace(header)
+			if !headerNameRegexp.MatchString(header) {
+				return nil, fmt.Errorf("Invalid header name %q in %v: must contain only letters, digits and '-'", header, AuthResponseHeadersAnnotation)
+			}
+			externalAuth.ResponseHeaders = append(externalAuth.ResponseHeaders, header)
+		}
+	}
+
+	if signinURL, exists := ing.Annotations[AuthSigninAnnotation]; exists {
+		if err := validateHTTPURL(signinURL); err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", AuthSigninAnnotation, err)
+		}
+		externalAuth.SigninURL = signinURL
+	}
+
+	return externalAuth, nil
+}
+
+// parseServiceReference parses a Service reference of the format <service>:<port>[<path>]. The port is a number or a name.
+func parseServiceReference(service string) (*extensions.IngressBackend, string, error) {
+	path := "/"
+	if i := strings.Index(service, "/"); i >= 0 {
+		service, path = service[:i], service[i:]
+	}
+	if strings.ContainsAny(path, " \t;{}\"'$") {
+		return nil, "", fmt.Errorf("Invalid path %q", path)
+	}
+
+	parts := strings.Split(service, ":")
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("Invalid format %q: must be <service>:<port>[<path>]", service)
+	}
+
+	if errs := validation.IsDNS1035Label(parts[0]); len(errs) > 0 {
+		return nil, "", fmt.Errorf("Invalid service name %q: %s", parts[0], strings.Join(errs, ", "))
+	}
+
+	port := intstr.Parse(parts[1])
+	if port.Type == intstr.Int {
+		if errs := validation.IsValidPortNum(port.IntValue()); len(errs) > 0 {
+			return nil, "", fmt.Errorf("Invalid port %q: %s", parts[1], strings.Join(errs, ", "))
+		}
+	} else if errs := validation.IsValidPortName(parts[1]); len(errs) > 0 {
+		return nil, "", fmt.Errorf("Invalid port %q: %s", parts[1], strings.Join(errs, ", "))
+	}
+
+	return &extensions.IngressBackend{ServiceName: parts[0], ServicePort: port}, path, nil
+}
+
+func validateHTTPURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL %q must have the http or https scheme", rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL %q must have a host", rawURL)
+	}
+	if strings.ContainsAny(rawURL, " \t;{}\"'$") {
+		return fmt.Errorf("URL %q must not contain whitespace, ';', '{', '}', quotes or '$'", rawURL)
+	}
+	return nil
+}
+
+func getExternalAuth(ingEx *IngressEx) *ExternalAuth {
+	externalAuth, err := ParseExternalAuth(ingEx.Ingress)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		return nil
+	}
+	return externalAuth
+}
+
+// createAuthRequest creates the configuration of the auth location of the server and of the locations that use it.
+// For a Service, the upstream of the Service is added to the upstreams map.
+func (cnf *Configurator) createAuthRequest(ingEx *IngressEx, externalAuth *ExternalAuth, upstreams map[string]Upstream,
+	cfg *Config) (AuthRequestLocation, *AuthRequest) {
+	location := AuthRequestLocation{
+		Path: getPathForAuthLocation(ingEx.Ingress),
+	}
+
+	if externalAuth.Service != nil {
+		name := getNameForUpstream(ingEx.Ingress, emptyHost, externalAuth.Service)
+		if _, exists := upstreams[name]; !exists {
+			ups := cnf.createUpstream(ingEx, name, externalAuth.Service, ingEx.Ingress.Namespace, "", cfg)
+			// the default backend must not respond to the authentication subrequests
+			if len(ingEx.Endpoints[externalAuth.Service.ServiceName+externalAuth.Service.ServicePort.String()]) == 0 {
+				ups.UpstreamServers = nil
+				if !cnf.isPlus() {
+					ups.UpstreamServers = NewUpstreamWithDefaultServer(name).UpstreamServers
+				}
+			}
+			upstreams[name] = ups
+		}
+		location.ProxyPass = "http://" + name + externalAuth.Path
+	} else {
+		location.ProxyPass = externalAuth.URL
+	}
+
+	authRequest := &AuthRequest{
+		Location: location.Path,
+	}
+
+	for _, header := range externalAuth.ResponseHeaders {
+		variable := strings.ToLower(strings.Replace(header, "-", "_", -1))
+		authRequest.ResponseHeaders = append(authRequest.ResponseHeaders, AuthResponseHeader{
+			Name:     header,
+			Variable: "auth_resp_" + variable,
+			Source:   "$upstream_http_" + variable,
+		})
+	}
+
+	if externalAuth.SigninURL != "" {
+		separator := "?"
+		if strings.Contains(externalAuth.SigninURL, "?") {
+			separator = "&"
+		}
+		location.SigninLocationName = getNameForSigninLocation(ingEx.Ingress)
+		// rd is the URL the sign-in service redirects the client back to
+		location.SigninURL = externalAuth.SigninURL + separator + "rd=$scheme://$host$request_uri"
+		authRequest.SigninLocationName = location.SigninLocationName
+	}
+
+	return location, authRequest
+}
+
+func getPathForAuthLocation(ing *extensions.Ingress) string {
+	return fmt.Sprintf("/internal_auth_%v_%v", ing.Namespace, ing.Name)
+}
+
+func getNameForSigninLocation(ing *extensions.Ingress) string {
+	return fmt.Sprintf("@auth_signin_%v_%v", ing.Namespace, ing.Name)
+}
@@ -0,0 +1,248 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func createIngressWithAnnotations(annotations map[string]string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "cafe-ingress",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestParseExternalAuth(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    *ExternalAuth
+		msg         string
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    nil,
+			msg:         "no external authentication",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation:             "http://auth.example.com/validate",
+				AuthResponseHeadersAnnotation: "X-User, X-Email",
+				AuthSigninAnnotation:          "https://login.example.com/signin",
+			},
+			expected: &ExternalAuth{
+				URL:             "http://auth.example.com/validate",
+				ResponseHeaders: []string{"X-User", "X-Email"},
+				SigninURL:       "https://login.example.com/signin",
+			},
+			msg: "auth URL with response headers and sign-in URL",
+		},
+		{
+			annotations: map[string]string{
+				AuthServiceAnnotation: "auth-svc:8080/validate",
+			},
+			expected: &ExternalAuth{
+				Service: &extensions.IngressBackend{
+					ServiceName: "auth-svc",
+					ServicePort: intstr.FromInt(8080),
+				},
+				Path: "/validate",
+			},
+			msg: "auth service with a port number and a path",
+		},
+		{
+			annotations: map[string]string{
+				AuthServiceAnnotation: "auth-svc:http",
+			},
+			expected: &ExternalAuth{
+				Service: &extensions.IngressBackend{
+					ServiceName: "auth-svc",
+					ServicePort: intstr.FromString("http"),
+				},
+				Path: "/",
+			},
+			msg: "auth service with a port name",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := ParseExternalAuth(createIngressWithAnnotations(test.annotations))
+		if err != nil {
+			t.Errorf("ParseExternalAuth() returned an error for the case of %s: %v", test.msg, err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("ParseExternalAuth() returned %+v, but expected %+v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
+func TestParseExternalAuthFails(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		msg         string
+	}{
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation:     "http://auth.example.com",
+				AuthServiceAnnotation: "auth-svc:80",
+			},
+			msg: "both auth URL and auth service",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation: "ftp://auth.example.com",
+			},
+			msg: "auth URL with an invalid scheme",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation: "http:///validate",
+			},
+			msg: "auth URL without a host",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation: "http://auth.example.com/$uri",
+			},
+			msg: "auth URL with a variable",
+		},
+		{
+			annotations: map[string]string{
+				AuthServiceAnnotation: "auth-svc",
+			},
+			msg: "auth service without a port",
+		},
+		{
+			annotations: map[string]string{
+				AuthServiceAnnotation: "auth_svc:80",
+			},
+			msg: "auth service with an invalid name",
+		},
+		{
+			annotations: map[string]string{
+				AuthServiceAnnotation: "auth-svc:70000",
+			},
+			msg: "auth service with an invalid port",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation:             "http://auth.example.com",
+				AuthResponseHeadersAnnotation: "X-User,X_Email",
+			},
+			msg: "invalid response header",
+		},
+		{
+			annotations: map[string]string{
+				AuthURLAnnotation:    "http://auth.example.com",
+				AuthSigninAnnotation: "login.example.com",
+			},
+			msg: "sign-in URL without a scheme",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseExternalAuth(createIngressWithAnnotations(test.annotations))
+		if err == nil {
+			t.Errorf("ParseExternalAuth() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestGenerateNginxCfgWithExternalAuth(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[AuthServiceAnnotation] = "auth-svc:80/validate"
+	cafeIngressEx.Ingress.Annotations[AuthResponseHeadersAnnotation] = "X-User"
+	cafeIngressEx.Ingress.Annotations[AuthSigninAnnotation] = "https://login.example.com/signin?app=cafe"
+	cafeIngressEx.Endpoints["auth-svc80"] = []string{"10.0.0.5:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+
+	expectedLocations := []AuthRequestLocation{
+		{
+			Path:               "/internal_auth_default_cafe-ingress",
+			ProxyPass:          "http://default-cafe-ingress--auth-svc-80/validate",
+			SigninLocationName: "@auth_signin_default_cafe-ingress",
+			SigninURL:          "https://login.example.com/signin?app=cafe&rd=$scheme://$host$request_uri",
+		},
+	}
+	if !reflect.DeepEqual(result.Servers[0].AuthRequestLocations, expectedLocations) {
+		t.Errorf("generateNginxCfg returned AuthRequestLocations %+v, but expected %+v", result.Servers[0].AuthRequestLocations, expectedLocations)
+	}
+
+	expectedAuthRequest := &AuthRequest{
+		Location: "/internal_auth_default_cafe-ingress",
+		ResponseHeaders: []AuthResponseHeader{
+			{
+				Name:     "X-User",
+				Variable: "auth_resp_x_user",
+				Source:   "$upstream_http_x_user",
+			},
+		},
+		SigninLocationName: "@auth_signin_default_cafe-ingress",
+	}
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.AuthRequest, expectedAuthRequest) {
+			t.Errorf("generateNginxCfg returned AuthRequest %+v for location %v, but expected %+v", loc.AuthRequest, loc.Path, expectedAuthRequest)
+		}
+	}
+
+	found := false
+	for _, ups := range result.Upstreams {
+		if ups.Name == "default-cafe-ingress--auth-svc-80" {
+			found = true
+			if len(ups.UpstreamServers) != 1 || ups.UpstreamServers[0].Address != "10.0.0.5" {
+				t.Errorf("generateNginxCfg returned upstream servers %+v for the auth service, but expected 10.0.0.5:80", ups.UpstreamServers)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("generateNginxCfg didn't return the upstream of the auth service")
+	}
+}
+
+func TestGenerateNginxCfgForMergeableIngressesWithExternalAuth(t *testing.T) {
+	mergeableIngresses := createMergeableCafeIngress()
+	mergeableIngresses.Master.Ingress.Annotations[AuthServiceAnnotation] = "auth-svc:80/validate"
+	mergeableIngresses.Master.Endpoints["auth-svc80"] = []string{"10.0.0.5:80"}
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfgForMergeableIngresses(mergeableIngresses)
+
+	expectedUpstreams := []string{
+		"default-cafe-ingress-coffee-minion--auth-svc-80",
+		"default-cafe-ingress-tea-minion--auth-svc-80",
+	}
+	for _, name := range expectedUpstreams {
+		found := false
+		for _, ups := range result.Upstreams {
+			if ups.Name == name {
+				found = true
+				if len(ups.UpstreamServers) != 1 || ups.UpstreamServers[0].Address != "10.0.0.5" {
+					t.Errorf("generateNginxCfgForMergeableIngresses returned upstream servers %+v for %v, but expected 10.0.0.5:80", ups.UpstreamServers, name)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("generateNginxCfgForMergeableIngresses didn't return the upstream %v of the auth service", name)
+		}
+	}
+
+	for _, minion := range mergeableIngresses.Minions {
+		if !reflect.DeepEqual(minion.Endpoints["auth-svc80"], []string{"10.0.0.5:80"}) {
+			t.Errorf("generateNginxCfgForMergeableIngresses didn't add the endpoints of the auth service to the minion %v", minion.Ingress.Name)
+		}
+	}
+}
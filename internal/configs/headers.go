@@ -0,0 +1,259 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// ProxySetHeadersAnnotation is the annotation that sets the headers of the requests passed to the upstreams.
+const ProxySetHeadersAnnotation = "nginx.org/proxy-set-headers"
+
+// AddHeadersAnnotation is the annotation that adds headers to the responses to the clients.
+const AddHeadersAnnotation = "nginx.org/add-headers"
+
+// PathProxySetHeadersAnnotation is the annotation that sets the headers of the requests passed to the upstreams
+// for individual paths of an Ingress.
+const PathProxySetHeadersAnnotation = "nginx.org/path-proxy-set-headers"
+
+// PathAddHeadersAnnotation is the annotation that adds headers to the responses to the clients
+// for individual paths of an Ingress.
+const PathAddHeadersAnnotation = "nginx.org/path-add-headers"
+
+// The request headers that the templates already set for every location. Setting them again would pass
+// a second copy of the header to the upstream.
+var reservedProxySetHeaders = map[string]bool{
+	"host":                true,
+	"x-real-ip":           true,
+	"x-forwarded-for":     true,
+	"x-forwarded-host":    true,
+	"x-forwarded-port":    true,
+	"x-forwarded-proto":   true,
+	"upgrade":             true,
+	"connection":          true,
+	"x-ssl-client-verify": true,
+	"x-ssl-client-s-dn":   true,
+	"x-ssl-client-cert":   true,
+}
+
+// The response headers that are configured by other annotations and ConfigMap keys.
+var reservedAddHeaders = map[string]bool{
+	"strict-transport-security": true,
+}
+
+// HeaderRule is a header with the Name and the Value, which can contain NGINX variables
+type HeaderRule struct {
+	Name  string
+	Value string
+}
+
+// ParseProxySetHeaders parses the headers of the requests passed to the upstreams. The headers are separated
+// by new lines and have the format "<name>: <value>". An empty value removes the header of the client request.
+func ParseProxySetHeaders(headers string) ([]HeaderRule, error) {
+	return parseHeaderRules(headers, reservedProxySetHeaders)
+}
+
+// ParseAddHeaders parses the headers added to the responses to the clients. The headers are separated
+// by new lines and have the format "<name>: <value>".
+func ParseAddHeaders(headers string) ([]HeaderRule, error) {
+	return parseHeaderRules(headers, reservedAddHeaders)
+}
+
+// ParsePathProxySetHeaders parses the value of the nginx.org/path-proxy-set-headers annotation. The headers are
+// separated by new lines and have the format "<path> <name>: <value>".
+func ParsePathProxySetHeaders(annotation string) (map[string][]HeaderRule, error) {
+	return parsePathHeaderRules(annotation, reservedProxySetHeaders)
+}
+
+// ParsePathAddHeaders parses the value of the nginx.org/path-add-headers annotation. The headers are
+// separated by new lines and have the format "<path> <name>: <value>".
+func ParsePathAddHeaders(annotation string) (map[string][]HeaderRule, error) {
+	return parsePathHeaderRules(annotation, reservedAddHeaders)
+}
+
+func parseHeaderRules(headers string, reserved map[string]bool) ([]HeaderRule, error) {
+	var rules []HeaderRule
+
+	for _, line := range strings.Split(headers, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		rule, err := parseHeaderRule(line, reserved)
+		if err != nil {
+			return nil, err
+		}
+		if findHeaderRule(rules, rule.Name) != -1 {
+			return nil, fmt.Errorf("Duplicate header %v", rule.Name)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parsePathHeaderRules(annotation string, reserved map[string]bool) (map[string][]HeaderRule, error) {
+	result := make(map[string][]HeaderRule)
+
+	for _, line := range strings.Split(annotation, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i == -1 {
+			return nil, fmt.Errorf("Invalid header declaration %q, must be <path> <name>: <value>", line)
+		}
+
+		path := line[:i]
+		rule, err := parseHeaderRule(line[i+1:], reserved)
+		if err != nil {
+			return nil, err
+		}
+		if findHeaderRule(result[path], rule.Name) != -1 {
+			return nil, fmt.Errorf("Duplicate header %v for path %v", rule.Name, path)
+		}
+
+		result[path] = append(result[path], rule)
+	}
+
+	return result, nil
+}
+
+func parseHeaderRule(declaration string, reserved map[string]bool) (HeaderRule, error) {
+	nameValue := strings.SplitN(declaration, ":", 2)
+	if len(nameValue) != 2 {
+		return HeaderRule{}, fmt.Errorf("Invalid header declaration %q, must be <name>: <value>", strings.TrimSpace(declaration))
+	}
+
+	name := strings.TrimSpace(nameValue[0])
+	if !headerNameRegexp.MatchString(name) {
+		return HeaderRule{}, fmt.Errorf("Invalid header name %q: must contain only letters, digits and '-'", name)
+	}
+	if reserved[strings.ToLower(name)] {
+		return HeaderRule{}, fmt.Errorf("Header %v is set by the Ingress Controller and can't be changed", name)
+	}
+
+	return HeaderRule{
+		Name:  name,
+		Value: strings.TrimSpace(nameValue[1]),
+	}, nil
+}
+
+// findHeaderRule returns the index of the rule of the header or -1. The names of the headers are case-insensitive.
+func findHeaderRule(rules []HeaderRule, name string) int {
+	for i := range rules {
+		if strings.EqualFold(rules[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeHeaderRules merges the rules of a more specific level into the rules of a less specific level,
+// for example, the rules of a path into the rules of the Ingress. A rule of the more specific level replaces
+// the rule of the same header, and the other rules are appended.
+func mergeHeaderRules(rules []HeaderRule, overrides []HeaderRule) []HeaderRule {
+	if len(overrides) == 0 {
+		return rules
+	}
+
+	result := make([]HeaderRule, len(rules), len(rules)+len(overrides))
+	copy(result, rules)
+
+	for _, override := range overrides {
+		if i := findHeaderRule(result, override.Name); i != -1 {
+			result[i] = override
+		} else {
+			result = append(result, override)
+		}
+	}
+
+	return result
+}
+
+func getPathProxySetHeaders(ingEx *IngressEx) map[string][]HeaderRule {
+	headers := make(map[string][]HeaderRule)
+
+	if annotation, exists := ingEx.Ingress.Annotations[PathProxySetHeadersAnnotation]; exists {
+		parsedHeaders, err := ParsePathProxySetHeaders(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, PathProxySetHeadersAnnotation, err)
+		} else {
+			headers = parsedHeaders
+		}
+	}
+
+	return headers
+}
+
+func getPathAddHeaders(ingEx *IngressEx) map[string][]HeaderRule {
+	headers := make(map[string][]HeaderRule)
+
+	if annotation, exists := ingEx.Ingress.Annotations[PathAddHeadersAnnotation]; exists {
+		parsedHeaders, err := ParsePathAddHeaders(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, PathAddHeadersAnnotation, err)
+		} else {
+			headers = parsedHeaders
+		}
+	}
+
+	return headers
+}
+
+// createProxySetHeaders creates the request headers of a location. The headers of the responses of the external
+// authentication service are already passed to the upstream, so the rules of those headers are ignored.
+func createProxySetHeaders(ingEx *IngressEx, rules []HeaderRule, authRequest *AuthRequest) []Header {
+	var headers []Header
+
+	for _, rule := range rules {
+		if authRequest != nil && isAuthResponseHeader(authRequest, rule.Name) {
+			glog.Errorf("Ingress %s/%s: header %v is set from the response of the external authentication service, ignoring",
+				ingEx.Ingress.Namespace, ingEx.Ingress.Name, rule.Name)
+			continue
+		}
+		headers = append(headers, Header{
+			Name:  rule.Name,
+			Value: quoteString(rule.Value),
+		})
+	}
+
+	return headers
+}
+
+// createAddHeaders creates the response headers of a location. The CORS headers of a location with a CORS policy
+// are added by the policy, so the rules of those headers are ignored.
+func createAddHeaders(ingEx *IngressEx, rules []HeaderRule, cors *CORS) []Header {
+	var headers []Header
+
+	for _, rule := range rules {
+		if cors != nil && isCORSHeader(cors, rule.Name) {
+			glog.Errorf("Ingress %s/%s: header %v is added by the CORS policy, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, rule.Name)
+			continue
+		}
+		headers = append(headers, Header{
+			Name:  rule.Name,
+			Value: quoteString(rule.Value),
+		})
+	}
+
+	return headers
+}
+
+func isAuthResponseHeader(authRequest *AuthRequest, name string) bool {
+	for _, header := range authRequest.ResponseHeaders {
+		if strings.EqualFold(header.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCORSHeader(cors *CORS, name string) bool {
+	return strings.EqualFold(name, "Access-Control-Allow-Origin") ||
+		(cors.AllowCredentials && strings.EqualFold(name, "Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,251 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProxySetHeaders(t *testing.T) {
+	headers := "X-Request-Start: t=${msec}\n\n  X-Client-Addr :$remote_addr  \nAuthorization:\n"
+	expected := []HeaderRule{
+		{
+			Name:  "X-Request-Start",
+			Value: "t=${msec}",
+		},
+		{
+			Name:  "X-Client-Addr",
+			Value: "$remote_addr",
+		},
+		{
+			Name:  "Authorization",
+			Value: "",
+		},
+	}
+
+	result, err := ParseProxySetHeaders(headers)
+	if err != nil {
+		t.Fatalf("ParseProxySetHeaders() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseProxySetHeaders() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParseHeadersInvalidFormat(t *testing.T) {
+	tests := []struct {
+		headers string
+		msg     string
+	}{
+		{
+			headers: "X-Frame-Options DENY",
+			msg:     "no colon",
+		},
+		{
+			headers: ": DENY",
+			msg:     "no name",
+		},
+		{
+			headers: "X Frame Options: DENY",
+			msg:     "invalid name",
+		},
+		{
+			headers: "X-Frame-Options: DENY\nx-frame-options: SAMEORIGIN",
+			msg:     "duplicate name",
+		},
+		{
+			headers: "Strict-Transport-Security: max-age=0",
+			msg:     "reserved name",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseAddHeaders(test.headers)
+		if err == nil {
+			t.Errorf("ParseAddHeaders() returned no error for the case of %s", test.msg)
+		}
+	}
+
+	_, err := ParseProxySetHeaders("Host: example.com")
+	if err == nil {
+		t.Errorf("ParseProxySetHeaders() returned no error for the case of reserved name")
+	}
+}
+
+func TestParsePathAddHeaders(t *testing.T) {
+	annotation := "/coffee X-Frame-Options: DENY\n/coffee Cache-Control: no-cache, no-store\n\t^/tea/(green|black)$ X-Tea: $1"
+	expected := map[string][]HeaderRule{
+		"/coffee": {
+			{
+				Name:  "X-Frame-Options",
+				Value: "DENY",
+			},
+			{
+				Name:  "Cache-Control",
+				Value: "no-cache, no-store",
+			},
+		},
+		"^/tea/(green|black)$": {
+			{
+				Name:  "X-Tea",
+				Value: "$1",
+			},
+		},
+	}
+
+	result, err := ParsePathAddHeaders(annotation)
+	if err != nil {
+		t.Fatalf("ParsePathAddHeaders() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParsePathAddHeaders() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParsePathHeadersInvalidFormat(t *testing.T) {
+	tests := []struct {
+		annotation string
+		msg        string
+	}{
+		{
+			annotation: "X-Frame-Options: DENY",
+			msg:        "no path",
+		},
+		{
+			annotation: "/coffee",
+			msg:        "no header",
+		},
+		{
+			annotation: "/coffee X-Frame-Options: DENY\n/coffee X-Frame-Options: SAMEORIGIN",
+			msg:        "duplicate name",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParsePathAddHeaders(test.annotation)
+		if err == nil {
+			t.Errorf("ParsePathAddHeaders() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestMergeHeaderRules(t *testing.T) {
+	rules := []HeaderRule{
+		{
+			Name:  "X-Frame-Options",
+			Value: "SAMEORIGIN",
+		},
+		{
+			Name:  "Cache-Control",
+			Value: "no-cache",
+		},
+	}
+	overrides := []HeaderRule{
+		{
+			Name:  "x-frame-options",
+			Value: "DENY",
+		},
+		{
+			Name:  "X-Content-Type-Options",
+			Value: "nosniff",
+		},
+	}
+	expected := []HeaderRule{
+		{
+			Name:  "x-frame-options",
+			Value: "DENY",
+		},
+		{
+			Name:  "Cache-Control",
+			Value: "no-cache",
+		},
+		{
+			Name:  "X-Content-Type-Options",
+			Value: "nosniff",
+		},
+	}
+
+	result := mergeHeaderRules(rules, overrides)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("mergeHeaderRules() returned %v, but expected %v", result, expected)
+	}
+	if rules[0].Value != "SAMEORIGIN" {
+		t.Errorf("mergeHeaderRules() modified the rules")
+	}
+}
+
+func TestGenerateNginxCfgWithHeaders(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[ProxySetHeadersAnnotation] = "X-Env: production"
+	cafeIngressEx.Ingress.Annotations[AddHeadersAnnotation] = "X-Frame-Options: DENY\nAccess-Control-Allow-Origin: *"
+	cafeIngressEx.Ingress.Annotations[PathProxySetHeadersAnnotation] = "/tea X-Env: staging"
+	cafeIngressEx.Ingress.Annotations[PathAddHeadersAnnotation] = "/coffee Cache-Control: no-store"
+	cafeIngressEx.Ingress.Annotations[CORSAllowOriginAnnotation] = "https://example.com"
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	cnf.config.ProxySetHeaders = []HeaderRule{
+		{
+			Name:  "X-Request-Start",
+			Value: "t=${msec}",
+		},
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	expectedProxySetHeaders := map[string][]Header{
+		"/coffee": {
+			{
+				Name:  "X-Request-Start",
+				Value: `"t=${msec}"`,
+			},
+			{
+				Name:  "X-Env",
+				Value: `"production"`,
+			},
+		},
+		"/tea": {
+			{
+				Name:  "X-Request-Start",
+				Value: `"t=${msec}"`,
+			},
+			{
+				Name:  "X-Env",
+				Value: `"staging"`,
+			},
+		},
+	}
+	// Access-Control-Allow-Origin is added by the CORS policy
+	expectedAddHeaders := map[string][]Header{
+		"/coffee": {
+			{
+				Name:  "X-Frame-Options",
+				Value: `"DENY"`,
+			},
+			{
+				Name:  "Cache-Control",
+				Value: `"no-store"`,
+			},
+		},
+		"/tea": {
+			{
+				Name:  "X-Frame-Options",
+				Value: `"DENY"`,
+			},
+		},
+	}
+
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.ProxySetHeaders, expectedProxySetHeaders[loc.Path]) {
+			t.Errorf("generateNginxCfg returned request headers %v for location %v, but expected %v", loc.ProxySetHeaders, loc.Path, expectedProxySetHeaders[loc.Path])
+		}
+		if !reflect.DeepEqual(loc.AddHeaders, expectedAddHeaders[loc.Path]) {
+			t.Errorf("generateNginxCfg returned response headers %v for location %v, but expected %v", loc.AddHeaders, loc.Path, expectedAddHeaders[loc.Path])
+		}
+	}
+}
@@ -10,12 +10,16 @@ import (
 // IngressEx holds an Ingress along with Secrets and Endpoints of the services
 // that are referenced in this Ingress
 type IngressEx struct {
-	Ingress          *extensions.Ingress
-	TLSSecrets       map[string]*api_v1.Secret
-	JWTKey           JWTKey
-	Endpoints        map[string][]string
-	HealthChecks     map[string]*api_v1.Probe
-	ExternalNameSvcs map[string]bool
+	Ingress            *extensions.Ingress
+	TLSSecrets         map[string]*api_v1.Secret
+	JWTKey             JWTKey
+	BasicAuthSecret    BasicAuthSecret
+	ClientCASecret     ClientCASecret
+	TrustedCertSecret  TrustedCertSecret
+	UpstreamTLSSecrets map[string]*api_v1.Secret
+	Endpoints          map[string][]string
+	HealthChecks       map[string]*api_v1.Probe
+	ExternalNameSvcs   map[string]bool
 }
 
 // MergeableIngresses is a mergeable ingress of a master and minions
@@ -30,15 +34,42 @@ type JWTKey struct {
 	Secret *api_v1.Secret
 }
 
+// BasicAuthSecret represents a secret that holds an htpasswd file
+type BasicAuthSecret struct {
+	Name   string
+	Secret *api_v1.Secret
+}
+
+// ClientCASecret represents a secret that holds the certificate of a CA for the verification of client certificates
+type ClientCASecret struct {
+	Name   string
+	Secret *api_v1.Secret
+}
+
+// TrustedCertSecret represents a secret that holds the chain of certificates for the verification of OCSP responses
+type TrustedCertSecret struct {
+	Name   string
+	Secret *api_v1.Secret
+}
+
 var masterBlacklist = map[string]bool{
 	"nginx.org/rewrites":                      true,
 	"nginx.org/ssl-services":                  true,
 	"nginx.org/grpc-services":                 true,
+	UpstreamTLSAnnotation:                     true,
+	TLSPassthroughAnnotation:                  true,
 	"nginx.org/websocket-services":            true,
 	"nginx.com/sticky-cookie-services":        true,
 	"nginx.com/health-checks":                 true,
 	"nginx.com/health-checks-mandatory":       true,
 	"nginx.com/health-checks-mandatory-queue": true,
+	SplitsAnnotation:                          true,
+	MatchesAnnotation:                         true,
+	PathTypesAnnotation:                       true,
+	RegexRewritesAnnotation:                   true,
+	AppRootAnnotation:                         true,
+	PathProxySetHeadersAnnotation:             true,
+	PathAddHeadersAnnotation:                  true,
 }
 
 var minionBlacklist = map[string]bool{
@@ -53,6 +84,13 @@ var minionBlacklist = map[string]bool{
 	"nginx.org/listen-ports":             true,
 	"nginx.org/listen-ports-ssl":         true,
 	"nginx.org/server-snippets":          true,
+	ClientCASecretAnnotation:             true,
+	TLSPassthroughAnnotation:             true,
+	"nginx.org/ssl-verify-client":        true,
+	"nginx.org/ssl-verify-depth":         true,
+	"nginx.org/ssl-stapling":             true,
+	"nginx.org/ssl-stapling-verify":      true,
+	TrustedCertSecretAnnotation:          true,
 }
 
 var minionInheritanceList = map[string]bool{
@@ -68,6 +106,27 @@ var minionInheritanceList = map[string]bool{
 	"nginx.org/keepalive":                true,
 	"nginx.org/max-fails":                true,
 	"nginx.org/fail-timeout":             true,
+	"nginx.org/limit-req-rate":           true,
+	"nginx.org/limit-req-key":            true,
+	"nginx.org/limit-req-zone-size":      true,
+	"nginx.org/limit-req-burst":          true,
+	"nginx.org/limit-req-nodelay":        true,
+	"nginx.org/limit-req-reject-code":    true,
+	AuthURLAnnotation:                    true,
+	AuthServiceAnnotation:                true,
+	AuthResponseHeadersAnnotation:        true,
+	AuthSigninAnnotation:                 true,
+	AllowListAnnotation:                  true,
+	DenyListAnnotation:                   true,
+	CORSAllowOriginAnnotation:            true,
+	CORSAllowMethodsAnnotation:           true,
+	CORSAllowHeadersAnnotation:           true,
+	CORSAllowCredentialsAnnotation:       true,
+	CORSMaxAgeAnnotation:                 true,
+	ErrorPagesAnnotation:                 true,
+	PathTypeAnnotation:                   true,
+	ProxySetHeadersAnnotation:            true,
+	AddHeadersAnnotation:                 true,
 }
 
 func (ingEx *IngressEx) String() string {
@@ -2,10 +2,14 @@ package configs
 
 // IngressNginxConfig describes an NGINX configuration
 type IngressNginxConfig struct {
-	Upstreams []Upstream
-	Servers   []Server
-	Keepalive string
-	Ingress   Ingress
+	Upstreams    []Upstream
+	SplitClients []SplitClient
+	Maps         []Map
+	Servers      []Server
+	Keepalive    string
+	Ingress      Ingress
+	// LimitReqZones are rendered in the main config, because the zones of all Ingress resources share the http context
+	LimitReqZones []LimitReqZone
 }
 
 // Ingress holds information about an Ingress resource
@@ -25,6 +29,48 @@ type Upstream struct {
 	QueueTimeout    int64
 }
 
+// SplitClient describes an NGINX split_clients block, which distributes requests among upstreams
+type SplitClient struct {
+	Source        string
+	Variable      string
+	Distributions []Distribution
+}
+
+// Distribution maps a percentage of requests of a SplitClient to a value
+type Distribution struct {
+	Weight string
+	Value  string
+}
+
+// Map describes an NGINX map, which sets a variable depending on the value of the source
+type Map struct {
+	Source     string
+	Variable   string
+	Parameters []Parameter
+}
+
+// Parameter maps a source value of a Map to a resulting value of the variable
+type Parameter struct {
+	Value  string
+	Result string
+}
+
+// LimitReqZone describes an NGINX limit_req_zone
+type LimitReqZone struct {
+	Name string
+	Key  string
+	Size string
+	Rate string
+}
+
+// LimitReq describes an NGINX limit_req in a location along with the status code of the rejected requests
+type LimitReq struct {
+	Zone       string
+	Burst      int
+	NoDelay    bool
+	RejectCode int
+}
+
 // UpstreamServer describes a server in an NGINX upstream
 type UpstreamServer struct {
 	Address     string
@@ -55,8 +101,7 @@ type Server struct {
 	ServerTokens          string
 	Locations             []Location
 	SSL                   bool
-	SSLCertificate        string
-	SSLCertificateKey     string
+	SSLCertificates       []SSLCertificate
 	SSLCiphers            string
 	GRPCOnly              bool
 	StatusZone            string
@@ -70,6 +115,8 @@ type Server struct {
 	HSTSBehindProxy       bool
 	ProxyHideHeaders      []string
 	ProxyPassHeaders      []string
+	// TLSPassthrough is true if the server receives the connections on port 443 through the internal HTTPS listener
+	TLSPassthrough bool
 
 	HealthChecks map[string]HealthCheck
 
@@ -81,6 +128,18 @@ type Server struct {
 	JWTAuth              *JWTAuth
 	JWTRedirectLocations []JWTRedirectLocation
 
+	BasicAuth *BasicAuth
+
+	ClientCertVerification *ClientCertVerification
+
+	SSLStapling *SSLStapling
+
+	AccessControl *AccessControl
+
+	AuthRequestLocations []AuthRequestLocation
+
+	ErrorPageLocations []ErrorPageLocation
+
 	Ports    []int
 	SSLPorts []int
 }
@@ -99,6 +158,96 @@ type JWTAuth struct {
 	RedirectLocationName string
 }
 
+// ProxySSL holds the TLS configuration of the connections to an upstream.
+// Certificate is a file with both the certificate and the key
+type ProxySSL struct {
+	TrustedCertificate string
+	Verify             bool
+	Name               string
+	ServerName         bool
+	Certificate        string
+}
+
+// ClientCertVerification holds the configuration of the verification of client certificates
+type ClientCertVerification struct {
+	CA     string
+	Verify string
+	Depth  int
+}
+
+// SSLCertificate describes a certificate and its key. A server can have several certificates of different types, such as RSA and ECDSA.
+type SSLCertificate struct {
+	Certificate string
+	Key         string
+}
+
+// SSLStapling holds the configuration of the OCSP stapling
+// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_stapling
+type SSLStapling struct {
+	Verify             bool
+	TrustedCertificate string
+}
+
+// BasicAuth holds HTTP basic authentication configuration
+type BasicAuth struct {
+	Secret string
+	Realm  string
+}
+
+// AccessControl holds the client IP addresses and CIDR blocks that are allowed or denied access.
+// The addresses are checked after the realip module replaces the client address
+type AccessControl struct {
+	Allow []string
+	Deny  []string
+}
+
+// ErrorPageLocation describes an internal location that returns an error page either from ProxyPass or as Body
+type ErrorPageLocation struct {
+	Path      string
+	ProxyPass string
+	Body      string
+}
+
+// ErrorPage describes the error page of a location for the status codes Codes
+type ErrorPage struct {
+	Codes    string
+	Location string
+}
+
+// CORS holds the CORS configuration of a location. AllowOrigin is a quoted string or a variable
+type CORS struct {
+	AllowOrigin      string
+	Vary             bool
+	AllowMethods     string
+	AllowHeaders     string
+	AllowCredentials bool
+	MaxAge           *int
+}
+
+// AuthRequestLocation describes an internal location that proxies the authentication subrequests to an external
+// authentication service along with a named location that redirects the clients to the sign-in URL
+type AuthRequestLocation struct {
+	Path               string
+	ProxyPass          string
+	SigninLocationName string
+	SigninURL          string
+}
+
+// AuthRequest holds the configuration of the external authentication of a location
+type AuthRequest struct {
+	Location           string
+	ResponseHeaders    []AuthResponseHeader
+	SigninLocationName string
+}
+
+// AuthResponseHeader describes a header of the response of an external authentication service, which is passed
+// to the upstream in the header Name through the variable Variable set from Source
+type AuthResponseHeader struct {
+	Name     string
+	Variable string
+	Source   string
+}
+
 // Location describes an NGINX location
 type Location struct {
 	LocationSnippets     []string
@@ -116,10 +265,37 @@ type Location struct {
 	ProxyBufferSize      string
 	ProxyMaxTempFileSize string
 	JWTAuth              *JWTAuth
+	BasicAuth            *BasicAuth
+	AuthRequest          *AuthRequest
+	AccessControl        *AccessControl
+	CORS                 *CORS
+	ErrorPages           []ErrorPage
+	ProxySSL             *ProxySSL
+	Internal             bool
+	LimitReq             *LimitReq
+	// MatchVariable is the variable with the internal location a request is routed to, if set
+	MatchVariable string
+	RegexRewrites []RegexRewrite
+	// ProxySetHeaders and AddHeaders include the headers of the ConfigMap, the Ingress and the path
+	ProxySetHeaders []Header
+	AddHeaders      []Header
 
 	MinionIngress *Ingress
 }
 
+// Header describes a request or a response header of a location. Value is quoted
+type Header struct {
+	Name  string
+	Value string
+}
+
+// RegexRewrite describes an NGINX rewrite directive of a location. Regex and Replacement are quoted
+type RegexRewrite struct {
+	Regex       string
+	Replacement string
+	Flag        string
+}
+
 // MainConfig describe the main NGINX configuration file
 type MainConfig struct {
 	ServerNamesHashBucketSize      string
@@ -155,11 +331,51 @@ type MainConfig struct {
 	ResolverTimeout        string
 	KeepaliveTimeout       string
 	KeepaliveRequests      int64
+	LimitReqZones          []LimitReqZone
+	StreamUpstreams        []StreamUpstream
+	StreamServers          []StreamServer
+	// DefaultBackendEndpoints are the endpoints of the default backend Service in the <address>:<port> format
+	DefaultBackendEndpoints []string
+	SetRealIPFrom           []string
+	TLSPassthrough          bool
+	TLSPassthroughHosts     []TLSPassthroughHost
+	// TLSPassthroughExcludedHosts are the TLS hosts of the Ingress resources without TLS passthrough, which match
+	// a wildcard host of TLS passthrough and are passed to the internal HTTPS listener
+	TLSPassthroughExcludedHosts []string
+}
+
+// TLSPassthroughHost describes a host whose TLS connections are passed to the upstream through the unix socket Socket
+type TLSPassthroughHost struct {
+	Host string
+	// ServerName is the host in the map of the server names, which is a regex for a wildcard host
+	ServerName   string
+	Socket       string
+	UpstreamName string
+}
+
+// StreamUpstream describes an NGINX upstream in the stream context
+type StreamUpstream struct {
+	Name            string
+	UpstreamServers []StreamUpstreamServer
+}
+
+// StreamUpstreamServer describes a server in an NGINX upstream in the stream context
+type StreamUpstreamServer struct {
+	Address string
+	Port    string
+}
+
+// StreamServer describes an NGINX server in the stream context
+type StreamServer struct {
+	Port       int
+	UDP        bool
+	StatusZone string
+	ProxyPass  string
 }
 
 // NewUpstreamWithDefaultServer creates an upstream with the default server.
 // proxy_pass to an upstream with the default server returns 502.
-// We use it for services that have no endpoints
+// We use it for services that have no endpoints if the default backend Service is not configured
 func NewUpstreamWithDefaultServer(name string) Upstream {
 	return Upstream{
 		Name: name,
@@ -173,3 +389,17 @@ func NewUpstreamWithDefaultServer(name string) Upstream {
 		},
 	}
 }
+
+// NewStreamUpstreamWithDefaultServer creates a stream upstream with the default server.
+// We use it for services that have no endpoints
+func NewStreamUpstreamWithDefaultServer(name string) StreamUpstream {
+	return StreamUpstream{
+		Name: name,
+		UpstreamServers: []StreamUpstreamServer{
+			{
+				Address: "127.0.0.1",
+				Port:    "8181",
+			},
+		},
+	}
+}
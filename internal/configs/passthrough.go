@@ -0,0 +1,228 @@
+package configs
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+// TLSPassthroughAnnotation is the annotation that enables TLS passthrough for the hosts of an Ingress.
+const TLSPassthroughAnnotation = "nginx.org/ssl-passthrough"
+
+// tlsPassthroughPort is the port of the stream server that routes TLS connections by the server name (SNI).
+// The HTTPS servers don't listen on it and receive the connections for their hosts from the stream server instead.
+const tlsPassthroughPort = 443
+
+// ParseTLSPassthrough parses the nginx.org/ssl-passthrough annotation of the Ingress. With TLS passthrough,
+// every rule of the Ingress must have a different host and exactly one path "/", whose service receives the TLS
+// connections for the host.
+func ParseTLSPassthrough(ing *extensions.Ingress) (bool, error) {
+	passthrough, exists, err := GetMapKeyAsBool(ing.Annotations, TLSPassthroughAnnotation, ing)
+	if !exists || err != nil {
+		return false, err
+	}
+	if !passthrough {
+		return false, nil
+	}
+
+	if len(ing.Spec.Rules) == 0 {
+		return false, fmt.Errorf("%v requires at least one rule", TLSPassthroughAnnotation)
+	}
+	hosts := make(map[string]bool)
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			return false, fmt.Errorf("%v requires a host in every rule", TLSPassthroughAnnotation)
+		}
+		if hosts[rule.Host] {
+			return false, fmt.Errorf("%v requires a different host in every rule, the host %v is used in several rules", TLSPassthroughAnnotation, rule.Host)
+		}
+		hosts[rule.Host] = true
+		if rule.HTTP == nil || len(rule.HTTP.Paths) != 1 || pathOrDefault(rule.HTTP.Paths[0].Path) != "/" {
+			return false, fmt.Errorf("%v requires exactly one path / for the host %v", TLSPassthroughAnnotation, rule.Host)
+		}
+	}
+
+	return true, nil
+}
+
+func isTLSPassthroughIngress(ingEx *IngressEx) bool {
+	passthrough, err := ParseTLSPassthrough(ingEx.Ingress)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		return false
+	}
+	return passthrough
+}
+
+// addOrUpdateTLSPassthroughIngress adds the hosts of the Ingress to the stream context of the main NGINX configuration file.
+// The Ingress doesn't have an NGINX configuration file of its own.
+func (cnf *Configurator) addOrUpdateTLSPassthroughIngress(ingEx *IngressEx) error {
+	if !cnf.isTLSPassthroughEnabled {
+		return fmt.Errorf("%v requires TLS passthrough to be enabled with the -enable-tls-passthrough command-line argument", TLSPassthroughAnnotation)
+	}
+
+	name := objectMetaToFileName(&ingEx.Ingress.ObjectMeta)
+	prev, exists := cnf.ingresses[name]
+	if exists && !isTLSPassthroughIngress(prev) {
+		cnf.nginx.DeleteIngress(name)
+		if err := cnf.updateLimitReqZones(name, nil); err != nil {
+			return err
+		}
+	}
+	cnf.ingresses[name] = ingEx
+
+	if err := cnf.updateMainConfig(); err != nil {
+		// the main config keeps the previous hosts of the Ingress
+		if exists && isTLSPassthroughIngress(prev) {
+			cnf.ingresses[name] = prev
+		} else {
+			delete(cnf.ingresses, name)
+		}
+		return fmt.Errorf("Error when updating the TLS passthrough hosts: %v", err)
+	}
+	return nil
+}
+
+// generateTLSPassthroughConfig generates the stream upstreams and the hosts of the Ingress resources with TLS passthrough.
+// The result is sorted so that the main NGINX configuration file is stable. A host can only be used by one Ingress:
+// the oldest Ingress gets the host, like in the conflict check of the Ingress resources with TLS passthrough.
+func (cnf *Configurator) generateTLSPassthroughConfig() ([]StreamUpstream, []TLSPassthroughHost) {
+	var ingExes []*IngressEx
+	for _, ingEx := range cnf.ingresses {
+		if isTLSPassthroughIngress(ingEx) {
+			ingExes = append(ingExes, ingEx)
+		}
+	}
+	sort.Slice(ingExes, func(i, j int) bool {
+		return HasTLSPassthroughPrecedence(ingExes[i].Ingress, ingExes[j].Ingress)
+	})
+
+	var upstreams []StreamUpstream
+	var hosts []TLSPassthroughHost
+	usedHosts := make(map[string]bool)
+
+	for _, ingEx := range ingExes {
+		for _, rule := range ingEx.Ingress.Spec.Rules {
+			if usedHosts[rule.Host] {
+				glog.Warningf("Ingress %s/%s: the host %v is already used by another Ingress with TLS passthrough, ignoring",
+					ingEx.Ingress.Namespace, ingEx.Ingress.Name, rule.Host)
+				continue
+			}
+			usedHosts[rule.Host] = true
+
+			backend := &rule.HTTP.Paths[0].Backend
+			upsName := getNameForUpstream(ingEx.Ingress, rule.Host, backend)
+
+			upstreams = append(upstreams, createStreamUpstream(upsName, ingEx.Endpoints[backend.ServiceName+backend.ServicePort.String()]))
+			hosts = append(hosts, TLSPassthroughHost{
+				Host:         rule.Host,
+				ServerName:   getServerNameForHost(rule.Host),
+				Socket:       getSocketForTLSPassthroughHost(ingEx.Ingress, rule.Host),
+				UpstreamName: upsName,
+			})
+		}
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	return upstreams, hosts
+}
+
+// HasTLSPassthroughPrecedence checks if the Ingress takes precedence over the other Ingress for a host with TLS
+// passthrough. The oldest Ingress takes precedence; if the creation timestamps are equal, the Ingress with
+// the lexicographically smaller namespace/name wins.
+func HasTLSPassthroughPrecedence(ing *extensions.Ingress, other *extensions.Ingress) bool {
+	if !ing.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return ing.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return ing.Namespace+"/"+ing.Name < other.Namespace+"/"+other.Name
+}
+
+// generateTLSPassthroughExcludedHosts returns the TLS hosts of the Ingress resources without TLS passthrough that match
+// a wildcard host with TLS passthrough. The stream server passes their connections to the internal HTTPS listener,
+// so that an exact host takes precedence over a wildcard host, like in the server names of NGINX.
+func (cnf *Configurator) generateTLSPassthroughExcludedHosts(passthroughHosts []TLSPassthroughHost) []string {
+	wildcardHosts := make(map[string]bool)
+	exactHosts := make(map[string]bool)
+	for _, h := range passthroughHosts {
+		if isWildcardHost(h.Host) {
+			wildcardHosts[h.Host] = true
+		} else {
+			exactHosts[h.Host] = true
+		}
+	}
+	if len(wildcardHosts) == 0 {
+		return nil
+	}
+
+	excluded := make(map[string]bool)
+	for _, ingEx := range cnf.ingresses {
+		if isTLSPassthroughIngress(ingEx) {
+			continue
+		}
+		for _, tls := range ingEx.Ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				if !exactHosts[host] && wildcardHosts[getWildcardHostForHost(host)] {
+					excluded[host] = true
+				}
+			}
+		}
+	}
+
+	var hosts []string
+	for host := range excluded {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// isTLSPassthroughExcludedHostsChanged checks if the TLS hosts of the Ingress resources without TLS passthrough
+// that are excluded from the wildcard hosts of TLS passthrough differ from the current main NGINX configuration file.
+func (cnf *Configurator) isTLSPassthroughExcludedHostsChanged() bool {
+	if !cnf.isTLSPassthroughEnabled {
+		return false
+	}
+	_, passthroughHosts := cnf.generateTLSPassthroughConfig()
+	return !reflect.DeepEqual(cnf.generateTLSPassthroughExcludedHosts(passthroughHosts), cnf.tlsPassthroughExcludedHosts)
+}
+
+// getSocketForTLSPassthroughHost returns the unix socket of the stream server of the host. The name of the socket is
+// a hash, because the length of the path of a unix socket is limited.
+func getSocketForTLSPassthroughHost(ing *extensions.Ingress, host string) string {
+	hash := sha1.Sum([]byte(ing.Namespace + "/" + ing.Name + "/" + host))
+	return fmt.Sprintf("unix:/var/run/nginx-tls-passthrough-%x.sock", hash[:8])
+}
+
+// setTLSPassthroughListener configures the server to receive the connections on port 443 from the stream server
+// through the internal HTTPS listener. The stream server passes the address of the client with the PROXY protocol.
+func setTLSPassthroughListener(server *Server) {
+	for _, port := range server.SSLPorts {
+		if port == tlsPassthroughPort {
+			server.TLSPassthrough = true
+		}
+	}
+	if !server.TLSPassthrough {
+		return
+	}
+
+	switch {
+	case server.RealIPHeader == "" && len(server.SetRealIPFrom) == 0:
+		server.RealIPHeader = "proxy_protocol"
+	case server.RealIPHeader == "proxy_protocol":
+	default:
+		// trusting the header from the internal listener would let any client set its address
+		glog.Warningf("Server %v: only the proxy_protocol real-ip-header is supported with TLS passthrough, the client address of HTTPS requests is not available",
+			server.Name)
+		return
+	}
+
+	server.SetRealIPFrom = append(append([]string{}, server.SetRealIPFrom...), "unix:")
+}
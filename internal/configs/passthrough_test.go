@@ -0,0 +1,316 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func createPassthroughIngressEx(paths ...string) *IngressEx {
+	var httpPaths []extensions.HTTPIngressPath
+	for _, path := range paths {
+		httpPaths = append(httpPaths, extensions.HTTPIngressPath{
+			Path: path,
+			Backend: extensions.IngressBackend{
+				ServiceName: "app-svc",
+				ServicePort: intstr.FromInt(443),
+			},
+		})
+	}
+
+	return &IngressEx{
+		Ingress: &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "app-ingress",
+				Namespace: "default",
+				Annotations: map[string]string{
+					TLSPassthroughAnnotation: "true",
+				},
+			},
+			Spec: extensions.IngressSpec{
+				Rules: []extensions.IngressRule{
+					{
+						Host: "app.example.com",
+						IngressRuleValue: extensions.IngressRuleValue{
+							HTTP: &extensions.HTTPIngressRuleValue{
+								Paths: httpPaths,
+							},
+						},
+					},
+				},
+			},
+		},
+		Endpoints: map[string][]string{
+			"app-svc443": {"10.0.0.1:8443"},
+		},
+	}
+}
+
+func TestParseTLSPassthrough(t *testing.T) {
+	ingEx := createPassthroughIngressEx("/")
+	result, err := ParseTLSPassthrough(ingEx.Ingress)
+	if !result || err != nil {
+		t.Errorf("ParseTLSPassthrough() returned %v, %v, but expected true, nil", result, err)
+	}
+
+	ingEx = createPassthroughIngressEx("/tea", "/coffee")
+	ingEx.Ingress.Annotations[TLSPassthroughAnnotation] = "false"
+	result, err = ParseTLSPassthrough(ingEx.Ingress)
+	if result || err != nil {
+		t.Errorf("ParseTLSPassthrough() returned %v, %v for a disabled TLS passthrough, but expected false, nil", result, err)
+	}
+}
+
+func TestParseTLSPassthroughFails(t *testing.T) {
+	noHost := createPassthroughIngressEx("/")
+	noHost.Ingress.Spec.Rules[0].Host = ""
+	noRules := createPassthroughIngressEx()
+	noRules.Ingress.Spec.Rules = nil
+	invalidValue := createPassthroughIngressEx("/")
+	invalidValue.Ingress.Annotations[TLSPassthroughAnnotation] = "yes"
+	duplicateHost := createPassthroughIngressEx("/")
+	duplicateHost.Ingress.Spec.Rules = append(duplicateHost.Ingress.Spec.Rules, duplicateHost.Ingress.Spec.Rules[0])
+
+	tests := []struct {
+		ingEx *IngressEx
+		msg   string
+	}{
+		{
+			ingEx: createPassthroughIngressEx("/tea", "/coffee"),
+			msg:   "multiple paths",
+		},
+		{
+			ingEx: createPassthroughIngressEx("/tea"),
+			msg:   "path other than /",
+		},
+		{
+			ingEx: noHost,
+			msg:   "rule without a host",
+		},
+		{
+			ingEx: noRules,
+			msg:   "no rules",
+		},
+		{
+			ingEx: invalidValue,
+			msg:   "invalid value",
+		},
+		{
+			ingEx: duplicateHost,
+			msg:   "host in several rules",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseTLSPassthrough(test.ingEx.Ingress)
+		if err == nil {
+			t.Errorf("ParseTLSPassthrough() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestAddOrUpdateTLSPassthroughIngress(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	ingEx := createPassthroughIngressEx("/")
+
+	err = cnf.AddOrUpdateIngress(ingEx)
+	if err == nil {
+		t.Errorf("AddOrUpdateIngress returned no error for TLS passthrough when it is not enabled")
+	}
+
+	cnf.isTLSPassthroughEnabled = true
+
+	err = cnf.AddOrUpdateIngress(ingEx)
+	if err != nil {
+		t.Fatalf("AddOrUpdateIngress returned an error: %v", err)
+	}
+
+	expectedUpstreams := []StreamUpstream{
+		{
+			Name: "default-app-ingress-app.example.com-app-svc-443",
+			UpstreamServers: []StreamUpstreamServer{
+				{
+					Address: "10.0.0.1",
+					Port:    "8443",
+				},
+			},
+		},
+	}
+	expectedHosts := []TLSPassthroughHost{
+		{
+			Host:         "app.example.com",
+			ServerName:   "app.example.com",
+			Socket:       getSocketForTLSPassthroughHost(ingEx.Ingress, "app.example.com"),
+			UpstreamName: "default-app-ingress-app.example.com-app-svc-443",
+		},
+	}
+
+	upstreams, hosts := cnf.generateTLSPassthroughConfig()
+	if !reflect.DeepEqual(upstreams, expectedUpstreams) {
+		t.Errorf("generateTLSPassthroughConfig returned upstreams %+v, but expected %+v", upstreams, expectedUpstreams)
+	}
+	if !reflect.DeepEqual(hosts, expectedHosts) {
+		t.Errorf("generateTLSPassthroughConfig returned hosts %+v, but expected %+v", hosts, expectedHosts)
+	}
+
+	err = cnf.DeleteIngress("default/app-ingress")
+	if err != nil {
+		t.Fatalf("DeleteIngress returned an error: %v", err)
+	}
+
+	upstreams, hosts = cnf.generateTLSPassthroughConfig()
+	if len(upstreams) != 0 || len(hosts) != 0 {
+		t.Errorf("generateTLSPassthroughConfig returned upstreams %+v and hosts %+v for a deleted Ingress, but expected none", upstreams, hosts)
+	}
+}
+
+func TestGenerateTLSPassthroughConfigWithConflictingHosts(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	now := time.Now()
+	newerIngEx := createPassthroughIngressEx("/")
+	newerIngEx.Ingress.Name = "a-newer-ingress"
+	newerIngEx.Ingress.CreationTimestamp = meta_v1.NewTime(now)
+	newerIngEx.Ingress.Spec.Rules = append(newerIngEx.Ingress.Spec.Rules, *newerIngEx.Ingress.Spec.Rules[0].DeepCopy())
+	newerIngEx.Ingress.Spec.Rules[1].Host = "other.example.com"
+	cnf.ingresses["default-a-newer-ingress"] = newerIngEx
+
+	olderIngEx := createPassthroughIngressEx("/")
+	olderIngEx.Ingress.CreationTimestamp = meta_v1.NewTime(now.Add(-time.Minute))
+	cnf.ingresses["default-app-ingress"] = olderIngEx
+
+	upstreams, hosts := cnf.generateTLSPassthroughConfig()
+
+	expectedHosts := []TLSPassthroughHost{
+		{
+			Host:         "app.example.com",
+			ServerName:   "app.example.com",
+			Socket:       getSocketForTLSPassthroughHost(olderIngEx.Ingress, "app.example.com"),
+			UpstreamName: "default-app-ingress-app.example.com-app-svc-443",
+		},
+		{
+			Host:         "other.example.com",
+			ServerName:   "other.example.com",
+			Socket:       getSocketForTLSPassthroughHost(newerIngEx.Ingress, "other.example.com"),
+			UpstreamName: "default-a-newer-ingress-other.example.com-app-svc-443",
+		},
+	}
+	if !reflect.DeepEqual(hosts, expectedHosts) {
+		t.Errorf("generateTLSPassthroughConfig returned hosts %+v, but expected %+v", hosts, expectedHosts)
+	}
+	if len(upstreams) != 2 {
+		t.Errorf("generateTLSPassthroughConfig returned upstreams %+v, but expected one upstream for every host", upstreams)
+	}
+}
+
+func TestGenerateTLSPassthroughExcludedHosts(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	passthroughIngEx := createPassthroughIngressEx("/")
+	passthroughIngEx.Ingress.Spec.Rules[0].Host = "*.example.com"
+	cnf.ingresses["default-app-ingress"] = passthroughIngEx
+
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Spec.TLS = []extensions.IngressTLS{
+		{
+			Hosts:      []string{"cafe.example.com", "tea.cafe.example.com", "*.example.com", "cafe.example.org"},
+			SecretName: "cafe-secret",
+		},
+	}
+	cnf.ingresses["default-cafe-ingress"] = &cafeIngressEx
+
+	_, passthroughHosts := cnf.generateTLSPassthroughConfig()
+	expected := []string{"cafe.example.com"}
+
+	result := cnf.generateTLSPassthroughExcludedHosts(passthroughHosts)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("generateTLSPassthroughExcludedHosts returned %v, but expected %v", result, expected)
+	}
+
+	// an exact host with TLS passthrough takes precedence over the Ingress without TLS passthrough
+	passthroughHosts = append(passthroughHosts, TLSPassthroughHost{Host: "cafe.example.com"})
+
+	result = cnf.generateTLSPassthroughExcludedHosts(passthroughHosts)
+	if len(result) != 0 {
+		t.Errorf("generateTLSPassthroughExcludedHosts returned %v, but expected none", result)
+	}
+}
+
+func TestSetTLSPassthroughListener(t *testing.T) {
+	tests := []struct {
+		server   Server
+		expected Server
+		msg      string
+	}{
+		{
+			server: Server{
+				SSLPorts: []int{443},
+			},
+			expected: Server{
+				SSLPorts:       []int{443},
+				TLSPassthrough: true,
+				RealIPHeader:   "proxy_protocol",
+				SetRealIPFrom:  []string{"unix:"},
+			},
+			msg: "default listener",
+		},
+		{
+			server: Server{
+				SSLPorts:      []int{443},
+				RealIPHeader:  "proxy_protocol",
+				SetRealIPFrom: []string{"10.0.0.0/8"},
+			},
+			expected: Server{
+				SSLPorts:       []int{443},
+				TLSPassthrough: true,
+				RealIPHeader:   "proxy_protocol",
+				SetRealIPFrom:  []string{"10.0.0.0/8", "unix:"},
+			},
+			msg: "PROXY protocol from a load balancer",
+		},
+		{
+			server: Server{
+				SSLPorts:      []int{443},
+				RealIPHeader:  "X-Forwarded-For",
+				SetRealIPFrom: []string{"10.0.0.0/8"},
+			},
+			expected: Server{
+				SSLPorts:       []int{443},
+				TLSPassthrough: true,
+				RealIPHeader:   "X-Forwarded-For",
+				SetRealIPFrom:  []string{"10.0.0.0/8"},
+			},
+			msg: "unsupported real IP header",
+		},
+		{
+			server: Server{
+				SSLPorts: []int{8443},
+			},
+			expected: Server{
+				SSLPorts: []int{8443},
+			},
+			msg: "custom listener",
+		},
+	}
+
+	for _, test := range tests {
+		setTLSPassthroughListener(&test.server)
+		if !reflect.DeepEqual(test.server, test.expected) {
+			t.Errorf("setTLSPassthroughListener() returned %+v, but expected %+v for the case of %s", test.server, test.expected, test.msg)
+		}
+	}
+}
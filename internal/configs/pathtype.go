@@ -0,0 +1,172 @@
+package configs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// PathTypeAnnotation is the annotation that sets the type of the paths of an Ingress.
+const PathTypeAnnotation = "nginx.org/path-type"
+
+// PathTypesAnnotation is the annotation that sets the types of individual paths of an Ingress.
+// It takes precedence over the nginx.org/path-type annotation.
+const PathTypesAnnotation = "nginx.org/path-types"
+
+// The types of the paths. A path without a type is a prefix path, which is overridden by the regex paths that match
+// the request, as a location without a modifier in NGINX.
+// http://nginx.org/en/docs/http/ngx_http_core_module.html#location
+const (
+	pathTypeExact                = "exact"
+	pathTypePrefix               = "prefix"
+	pathTypeRegex                = "regex"
+	pathTypeRegexCaseInsensitive = "regex-case-insensitive"
+)
+
+var pathTypeModifiers = map[string]string{
+	pathTypeExact:                "=",
+	pathTypePrefix:               "^~",
+	pathTypeRegex:                "~",
+	pathTypeRegexCaseInsensitive: "~*",
+}
+
+// ParsePathType parses the type of a path.
+func ParsePathType(pathType string) (string, error) {
+	pathType = strings.TrimSpace(pathType)
+	if _, exists := pathTypeModifiers[pathType]; !exists {
+		return "", fmt.Errorf("Invalid path type %q, must be one of %v, %v, %v or %v", pathType,
+			pathTypeExact, pathTypePrefix, pathTypeRegex, pathTypeRegexCaseInsensitive)
+	}
+	return pathType, nil
+}
+
+// ParsePathTypes parses the value of the nginx.org/path-types annotation. The annotation has the format
+// "<path>=<type>[;...]". A path can contain '=', because the type follows the last '=' of the declaration.
+func ParsePathTypes(annotation string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		if strings.TrimSpace(declaration) == "" {
+			continue
+		}
+
+		i := strings.LastIndex(declaration, "=")
+		if i == -1 {
+			return nil, fmt.Errorf("Invalid path type declaration %q, must be <path>=<type>", declaration)
+		}
+
+		path := strings.TrimSpace(declaration[:i])
+		if path == "" {
+			return nil, fmt.Errorf("Invalid path type declaration %q, the path is empty", declaration)
+		}
+		if _, exists := result[path]; exists {
+			return nil, fmt.Errorf("Duplicate path type for path %v", path)
+		}
+
+		pathType, err := ParsePathType(declaration[i+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		result[path] = pathType
+	}
+
+	return result, nil
+}
+
+// pathTypes holds the types of the paths of an Ingress
+type pathTypes struct {
+	defaultType string
+	types       map[string]string
+}
+
+func (p pathTypes) get(path string) string {
+	if pathType, exists := p.types[path]; exists {
+		return pathType
+	}
+	return p.defaultType
+}
+
+func getPathTypes(ingEx *IngressEx) pathTypes {
+	var result pathTypes
+
+	if annotation, exists := ingEx.Ingress.Annotations[PathTypeAnnotation]; exists {
+		pathType, err := ParsePathType(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, PathTypeAnnotation, err)
+		} else {
+			result.defaultType = pathType
+		}
+	}
+
+	if annotation, exists := ingEx.Ingress.Annotations[PathTypesAnnotation]; exists {
+		types, err := ParsePathTypes(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, PathTypesAnnotation, err)
+		} else {
+			result.types = types
+		}
+	}
+
+	return result
+}
+
+func isRegexPathType(pathType string) bool {
+	return pathType == pathTypeRegex || pathType == pathTypeRegexCaseInsensitive
+}
+
+// validatePath validates the path of the type. A regex must be a valid RE2 regular expression, which is a subset of the
+// PCRE syntax of NGINX. Other paths must start with '/' and can't contain the characters that end the location directive.
+func validatePath(path string, pathType string) error {
+	if isRegexPathType(pathType) {
+		if _, err := regexp.Compile(path); err != nil {
+			return fmt.Errorf("Invalid regular expression %v: %v", path, err)
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("Path %v must start with /", path)
+	}
+	if strings.ContainsAny(path, " \t\r\n;{}\"'") {
+		return fmt.Errorf("Path %v can't contain whitespace, ';', '{', '}' or quotes", path)
+	}
+
+	return nil
+}
+
+// getLocationPath returns the argument of the location directive for the path of the type.
+// A regex is quoted, so that characters like '{' and ';' don't end the directive.
+func getLocationPath(path string, pathType string) string {
+	if pathType == "" {
+		return path
+	}
+	if isRegexPathType(pathType) {
+		return fmt.Sprintf("%v %v", pathTypeModifiers[pathType], quoteString(path))
+	}
+	return fmt.Sprintf("%v %v", pathTypeModifiers[pathType], path)
+}
+
+// quoteString quotes the string for a parameter of an NGINX directive. NGINX unescapes '\' and '"' in quoted strings.
+func quoteString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// isRegexLocationPath checks if the argument of the location directive is a regex
+func isRegexLocationPath(path string) bool {
+	return strings.HasPrefix(path, "~")
+}
+
+// sortLocations moves the regex locations after the other locations, keeping their order. NGINX checks the regex
+// locations in the order of the configuration, so the regex locations of a server are ordered by the Ingress resources
+// and the paths, which doesn't change from one version of the configuration to another.
+func sortLocations(locations []Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		return !isRegexLocationPath(locations[i].Path) && isRegexLocationPath(locations[j].Path)
+	})
+}
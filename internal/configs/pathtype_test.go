@@ -0,0 +1,296 @@
+package configs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParsePathTypes(t *testing.T) {
+	annotation := "/coffee=exact; /tea=prefix;^/api/v[0-9]+/(?P<name>[a-z=]+)$=regex;\\.(png|jpg)$ = regex-case-insensitive;"
+	expected := map[string]string{
+		"/coffee":                          pathTypeExact,
+		"/tea":                             pathTypePrefix,
+		"^/api/v[0-9]+/(?P<name>[a-z=]+)$": pathTypeRegex,
+		"\\.(png|jpg)$":                    pathTypeRegexCaseInsensitive,
+	}
+
+	result, err := ParsePathTypes(annotation)
+	if err != nil {
+		t.Fatalf("ParsePathTypes() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParsePathTypes() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParsePathTypesInvalidFormat(t *testing.T) {
+	tests := []struct {
+		annotation string
+		msg        string
+	}{
+		{
+			annotation: "/coffee",
+			msg:        "no type",
+		},
+		{
+			annotation: "=exact",
+			msg:        "no path",
+		},
+		{
+			annotation: "/coffee=suffix",
+			msg:        "invalid type",
+		},
+		{
+			annotation: "/coffee=exact;/coffee=prefix",
+			msg:        "duplicate path",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParsePathTypes(test.annotation)
+		if err == nil {
+			t.Errorf("ParsePathTypes() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		pathType string
+		isValid  bool
+	}{
+		{
+			path:     "/coffee",
+			pathType: pathTypeExact,
+			isValid:  true,
+		},
+		{
+			path:     "/tea/",
+			pathType: pathTypePrefix,
+			isValid:  true,
+		},
+		{
+			path:     "^/api/v[0-9]{1,2}/",
+			pathType: pathTypeRegex,
+			isValid:  true,
+		},
+		{
+			path:     "coffee",
+			pathType: pathTypeExact,
+			isValid:  false,
+		},
+		{
+			path:     "/coffee;return",
+			pathType: pathTypePrefix,
+			isValid:  false,
+		},
+		{
+			path:     "/tea {",
+			pathType: pathTypeExact,
+			isValid:  false,
+		},
+		{
+			path:     "^/api/(v[0-9]+",
+			pathType: pathTypeRegex,
+			isValid:  false,
+		},
+		{
+			path:     "^/(?!api)",
+			pathType: pathTypeRegexCaseInsensitive,
+			isValid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		err := validatePath(test.path, test.pathType)
+		if test.isValid && err != nil {
+			t.Errorf("validatePath(%q, %q) returned an error: %v", test.path, test.pathType, err)
+		}
+		if !test.isValid && err == nil {
+			t.Errorf("validatePath(%q, %q) returned no error", test.path, test.pathType)
+		}
+	}
+}
+
+func TestGetLocationPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		pathType string
+		expected string
+	}{
+		{
+			path:     "/coffee",
+			pathType: "",
+			expected: "/coffee",
+		},
+		{
+			path:     "/coffee",
+			pathType: pathTypeExact,
+			expected: "= /coffee",
+		},
+		{
+			path:     "/tea",
+			pathType: pathTypePrefix,
+			expected: "^~ /tea",
+		},
+		{
+			path:     `^/api/v[0-9]{1,2}/"\d+"`,
+			pathType: pathTypeRegex,
+			expected: `~ "^/api/v[0-9]{1,2}/\"\\d+\""`,
+		},
+		{
+			path:     `\.(png|jpg)$`,
+			pathType: pathTypeRegexCaseInsensitive,
+			expected: `~* "\\.(png|jpg)$"`,
+		},
+	}
+
+	for _, test := range tests {
+		result := getLocationPath(test.path, test.pathType)
+		if result != test.expected {
+			t.Errorf("getLocationPath(%q, %q) returned %q, but expected %q", test.path, test.pathType, result, test.expected)
+		}
+	}
+}
+
+func TestSortLocations(t *testing.T) {
+	locations := []Location{
+		{Path: `~ "^/api/v2"`},
+		{Path: "/coffee"},
+		{Path: `~* "\\.png$"`},
+		{Path: "= /tea"},
+		{Path: `~ "^/api"`},
+		{Path: "^~ /juice"},
+	}
+	expected := []Location{
+		{Path: "/coffee"},
+		{Path: "= /tea"},
+		{Path: "^~ /juice"},
+		{Path: `~ "^/api/v2"`},
+		{Path: `~* "\\.png$"`},
+		{Path: `~ "^/api"`},
+	}
+
+	sortLocations(locations)
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("sortLocations() returned %v, but expected %v", locations, expected)
+	}
+}
+
+func TestGenerateNginxCfgWithPathTypes(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[PathTypeAnnotation] = "exact"
+	cafeIngressEx.Ingress.Annotations[PathTypesAnnotation] = "^/tea/(green|black)$=regex;^/tea/(=regex;/=prefix"
+	cafeIngressEx.Ingress.Annotations["nginx.org/rewrites"] = "serviceName=tea-svc rewrite=/"
+	paths := &cafeIngressEx.Ingress.Spec.Rules[0].HTTP.Paths
+	*paths = []extensions.HTTPIngressPath{
+		{
+			Path: "^/tea/(green|black)$",
+			Backend: extensions.IngressBackend{
+				ServiceName: "tea-svc",
+				ServicePort: intstr.FromString("80"),
+			},
+		},
+		{
+			Path: "^/tea/(",
+			Backend: extensions.IngressBackend{
+				ServiceName: "tea-svc",
+				ServicePort: intstr.FromString("80"),
+			},
+		},
+		{
+			Path: "/coffee",
+			Backend: extensions.IngressBackend{
+				ServiceName: "coffee-svc",
+				ServicePort: intstr.FromString("80"),
+			},
+		},
+		{
+			Path: "/",
+			Backend: extensions.IngressBackend{
+				ServiceName: "coffee-svc",
+				ServicePort: intstr.FromString("80"),
+			},
+		},
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	type location struct {
+		path    string
+		rewrite string
+	}
+	expected := []location{
+		{path: "= /coffee"},
+		{path: "^~ /"},
+		{path: `~ "^/tea/(green|black)$"`},
+	}
+
+	var locations []location
+	for _, loc := range result.Servers[0].Locations {
+		locations = append(locations, location{path: loc.Path, rewrite: loc.Rewrite})
+	}
+
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("generateNginxCfg returned locations %+v, but expected %+v", locations, expected)
+	}
+}
+
+func TestExecuteIngressConfigTemplateWithInternalLocationsAndRegexPath(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations[PathTypesAnnotation] = "^/=regex"
+	cafeIngressEx.Ingress.Annotations[MatchesAnnotation] = "serviceName=tea-svc header=X-Canary:always target=tea-canary-svc"
+	cafeIngressEx.Ingress.Annotations[AuthServiceAnnotation] = "auth-svc:80/validate"
+	cafeIngressEx.Ingress.Annotations[ErrorPagesAnnotation] = "404 service=errors-svc:80"
+	cafeIngressEx.Endpoints["tea-canary-svc80"] = []string{"10.0.0.3:80"}
+	cafeIngressEx.Endpoints["auth-svc80"] = []string{"10.0.0.5:80"}
+	cafeIngressEx.Endpoints["errors-svc80"] = []string{"10.0.0.10:80"}
+	paths := &cafeIngressEx.Ingress.Spec.Rules[0].HTTP.Paths
+	*paths = append(*paths, extensions.HTTPIngressPath{
+		Path: "^/",
+		Backend: extensions.IngressBackend{
+			ServiceName: "coffee-svc",
+			ServicePort: intstr.FromString("80"),
+		},
+	})
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	content, err := cnf.templateExecutor.ExecuteIngressConfigTemplate(&result)
+	if err != nil {
+		t.Fatalf("ExecuteIngressConfigTemplate() returned an unexpected error: %v", err)
+	}
+
+	// the regex path matches the URIs of the internal locations, so they must be exact locations to take precedence
+	variable := "match_default_hcafe_hingress_hcafe_dexample_dcom_htea_hsvc_h80"
+	expectedLocations := []string{
+		"location = /internal_" + variable + "_0 {",
+		"location = /internal_" + variable + "_default {",
+		"location = /internal_auth_default_cafe-ingress {",
+		"location = /internal_error_page_default_cafe-ingress_0 {",
+		`location ~ "^/" {`,
+	}
+	for _, location := range expectedLocations {
+		if !strings.Contains(string(content), location) {
+			t.Errorf("ExecuteIngressConfigTemplate() didn't return the %q location in the config:\n%s", location, content)
+		}
+	}
+}
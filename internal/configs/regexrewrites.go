@@ -0,0 +1,227 @@
+package configs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// RegexRewritesAnnotation is the annotation that rewrites the URIs of the requests for the paths with regular expressions.
+const RegexRewritesAnnotation = "nginx.org/regex-rewrites"
+
+// AppRootAnnotation is the annotation that redirects the requests for the paths to the roots of the applications.
+const AppRootAnnotation = "nginx.org/app-root"
+
+// The flags of the rewrites. The rewritten URI is passed to the upstream with the break flag,
+// while the redirect and permanent flags return a redirect to the client.
+// http://nginx.org/en/docs/http/ngx_http_rewrite_module.html#rewrite
+const (
+	rewriteFlagBreak     = "break"
+	rewriteFlagRedirect  = "redirect"
+	rewriteFlagPermanent = "permanent"
+)
+
+// RegexRewriteRule is a rewrite of the URIs that match Regex with Replacement, which can reference the capture groups of Regex
+type RegexRewriteRule struct {
+	Regex       string
+	Replacement string
+	Flag        string
+}
+
+var captureGroupReferenceRegexp = regexp.MustCompile(`\$([0-9])`)
+
+// ParseRegexRewrites parses the value of the nginx.org/regex-rewrites annotation. The annotation has the format
+// "path=<path> regex=<regex> replacement=<replacement> [flag=<flag>][;...]", where <flag> is break (the default),
+// redirect or permanent. The rewrites of the same path are applied in order.
+func ParseRegexRewrites(annotation string) (map[string][]RegexRewriteRule, error) {
+	result := make(map[string][]RegexRewriteRule)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		if strings.TrimSpace(declaration) == "" {
+			continue
+		}
+
+		params, err := parseRewriteDeclaration(declaration, "path", "regex", "replacement", "flag")
+		if err != nil {
+			return nil, err
+		}
+
+		rule := RegexRewriteRule{
+			Regex:       params["regex"],
+			Replacement: params["replacement"],
+			Flag:        params["flag"],
+		}
+		if rule.Flag == "" {
+			rule.Flag = rewriteFlagBreak
+		}
+		if err := validateRegexRewriteRule(rule); err != nil {
+			return nil, err
+		}
+
+		result[params["path"]] = append(result[params["path"]], rule)
+	}
+
+	return result, nil
+}
+
+// ParseAppRoots parses the value of the nginx.org/app-root annotation. The annotation has the format
+// "path=<path> root=<root>[;...]". The requests for exactly <path> are redirected to <root>.
+func ParseAppRoots(annotation string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		if strings.TrimSpace(declaration) == "" {
+			continue
+		}
+
+		params, err := parseRewriteDeclaration(declaration, "path", "root")
+		if err != nil {
+			return nil, err
+		}
+
+		path, root := params["path"], params["root"]
+		if root == "" {
+			return nil, fmt.Errorf("Invalid app root declaration %q, the root is required", declaration)
+		}
+		if err := validatePath(root, pathTypeExact); err != nil {
+			return nil, fmt.Errorf("Invalid app root of path %v: %v", path, err)
+		}
+		if root == path {
+			return nil, fmt.Errorf("The app root of path %v can't be the path itself", path)
+		}
+		if _, exists := result[path]; exists {
+			return nil, fmt.Errorf("Duplicate app root for path %v", path)
+		}
+
+		result[path] = root
+	}
+
+	return result, nil
+}
+
+// parseRewriteDeclaration parses a declaration of <key>=<value> pairs separated by whitespace. The keys must be
+// among the allowed keys, and the first allowed key is required.
+func parseRewriteDeclaration(declaration string, allowedKeys ...string) (map[string]string, error) {
+	params := make(map[string]string)
+
+	for _, part := range strings.Fields(declaration) {
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 || keyValue[1] == "" {
+			return nil, fmt.Errorf("Invalid parameter %q in declaration %q, must be <key>=<value>", part, declaration)
+		}
+
+		key, value := keyValue[0], keyValue[1]
+		allowed := false
+		for _, k := range allowedKeys {
+			if key == k {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("Unknown parameter %v in declaration %q, must be one of %v", key, declaration, strings.Join(allowedKeys, ", "))
+		}
+		if _, exists := params[key]; exists {
+			return nil, fmt.Errorf("Duplicate parameter %v in declaration %q", key, declaration)
+		}
+
+		params[key] = value
+	}
+
+	if params[allowedKeys[0]] == "" {
+		return nil, fmt.Errorf("Invalid declaration %q, %v is required", declaration, allowedKeys[0])
+	}
+
+	return params, nil
+}
+
+// validateRegexRewriteRule validates the rule. The regex must be a valid RE2 regular expression, which is a subset
+// of the PCRE syntax of NGINX, and the replacement can only reference the capture groups of the regex.
+func validateRegexRewriteRule(rule RegexRewriteRule) error {
+	if rule.Regex == "" || rule.Replacement == "" {
+		return fmt.Errorf("Invalid rewrite: the regex and the replacement are required")
+	}
+
+	re, err := regexp.Compile(rule.Regex)
+	if err != nil {
+		return fmt.Errorf("Invalid regular expression %v: %v", rule.Regex, err)
+	}
+
+	for _, ref := range captureGroupReferenceRegexp.FindAllStringSubmatch(rule.Replacement, -1) {
+		group, _ := strconv.Atoi(ref[1])
+		if group > re.NumSubexp() {
+			return fmt.Errorf("Replacement %v references the capture group %v, but the regex %v has %v capture groups",
+				rule.Replacement, group, rule.Regex, re.NumSubexp())
+		}
+	}
+
+	switch rule.Flag {
+	case rewriteFlagBreak, rewriteFlagRedirect, rewriteFlagPermanent:
+	default:
+		return fmt.Errorf("Invalid flag %v, must be one of %v, %v or %v", rule.Flag, rewriteFlagBreak, rewriteFlagRedirect, rewriteFlagPermanent)
+	}
+
+	return nil
+}
+
+func getRegexRewrites(ingEx *IngressEx) map[string][]RegexRewriteRule {
+	rewrites := make(map[string][]RegexRewriteRule)
+
+	if annotation, exists := ingEx.Ingress.Annotations[RegexRewritesAnnotation]; exists {
+		parsedRewrites, err := ParseRegexRewrites(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, RegexRewritesAnnotation, err)
+		} else {
+			rewrites = parsedRewrites
+		}
+	}
+
+	return rewrites
+}
+
+func getAppRoots(ingEx *IngressEx) map[string]string {
+	appRoots := make(map[string]string)
+
+	if annotation, exists := ingEx.Ingress.Annotations[AppRootAnnotation]; exists {
+		parsedAppRoots, err := ParseAppRoots(annotation)
+		if err != nil {
+			glog.Errorf("In %v %v contains invalid declaration: %v, ignoring", ingEx.Ingress.Name, AppRootAnnotation, err)
+		} else {
+			appRoots = parsedAppRoots
+		}
+	}
+
+	return appRoots
+}
+
+// createRegexRewrites creates the rewrites of the location of the path. The redirect to the app root comes first,
+// because the other rewrites can change the URI of the request for the path. An app root is not supported for a regex path,
+// which doesn't match a single URI.
+func createRegexRewrites(ingEx *IngressEx, path string, pathType string, rules []RegexRewriteRule, appRoot string) []RegexRewrite {
+	var rewrites []RegexRewrite
+
+	if appRoot != "" {
+		if isRegexPathType(pathType) {
+			glog.Errorf("Ingress %s/%s: the app root of the regex path %v is not supported, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, path)
+		} else {
+			rewrites = append(rewrites, RegexRewrite{
+				Regex:       quoteString("^" + regexp.QuoteMeta(path) + "$"),
+				Replacement: quoteString(appRoot),
+				Flag:        rewriteFlagRedirect,
+			})
+		}
+	}
+
+	for _, rule := range rules {
+		rewrites = append(rewrites, RegexRewrite{
+			Regex:       quoteString(rule.Regex),
+			Replacement: quoteString(rule.Replacement),
+			Flag:        rule.Flag,
+		})
+	}
+
+	return rewrites
+}
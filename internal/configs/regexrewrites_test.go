@@ -0,0 +1,250 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParseRegexRewrites(t *testing.T) {
+	annotation := "path=/app regex=^/app/(.*)$ replacement=/$1; path=/app regex=^/(v[0-9]+)/(.*)$ replacement=/$2?version=$1;" +
+		"path=/old regex=^/old/(?P<page>.*)$ replacement=https://example.com/$1 flag=permanent"
+	expected := map[string][]RegexRewriteRule{
+		"/app": {
+			{
+				Regex:       "^/app/(.*)$",
+				Replacement: "/$1",
+				Flag:        "break",
+			},
+			{
+				Regex:       "^/(v[0-9]+)/(.*)$",
+				Replacement: "/$2?version=$1",
+				Flag:        "break",
+			},
+		},
+		"/old": {
+			{
+				Regex:       "^/old/(?P<page>.*)$",
+				Replacement: "https://example.com/$1",
+				Flag:        "permanent",
+			},
+		},
+	}
+
+	result, err := ParseRegexRewrites(annotation)
+	if err != nil {
+		t.Fatalf("ParseRegexRewrites() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseRegexRewrites() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParseRegexRewritesInvalidFormat(t *testing.T) {
+	tests := []struct {
+		annotation string
+		msg        string
+	}{
+		{
+			annotation: "regex=^/app/(.*)$ replacement=/$1",
+			msg:        "no path",
+		},
+		{
+			annotation: "path=/app replacement=/$1",
+			msg:        "no regex",
+		},
+		{
+			annotation: "path=/app regex=^/app/(.*)$",
+			msg:        "no replacement",
+		},
+		{
+			annotation: "path=/app regex=^/app/(.*$ replacement=/$1",
+			msg:        "invalid regex",
+		},
+		{
+			annotation: "path=/app regex=^/app/(?!admin)(.*)$ replacement=/$1",
+			msg:        "regex with a lookahead",
+		},
+		{
+			annotation: "path=/app regex=^/app/(.*)$ replacement=/$2",
+			msg:        "reference to a missing capture group",
+		},
+		{
+			annotation: "path=/app regex=^/app/(.*)$ replacement=/$1 flag=last",
+			msg:        "invalid flag",
+		},
+		{
+			annotation: "path=/app regex=^/app/(.*)$ replacement=/$1 target=/",
+			msg:        "unknown parameter",
+		},
+		{
+			annotation: "path=/app path=/tea regex=^/app/(.*)$ replacement=/$1",
+			msg:        "duplicate parameter",
+		},
+		{
+			annotation: "path=/app regex ^/app/(.*)$ replacement=/$1",
+			msg:        "parameter without a value",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseRegexRewrites(test.annotation)
+		if err == nil {
+			t.Errorf("ParseRegexRewrites() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestParseAppRoots(t *testing.T) {
+	annotation := "path=/ root=/app; path=/shop root=/shop/home"
+	expected := map[string]string{
+		"/":     "/app",
+		"/shop": "/shop/home",
+	}
+
+	result, err := ParseAppRoots(annotation)
+	if err != nil {
+		t.Fatalf("ParseAppRoots() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseAppRoots() returned %v, but expected %v", result, expected)
+	}
+}
+
+func TestParseAppRootsInvalidFormat(t *testing.T) {
+	tests := []struct {
+		annotation string
+		msg        string
+	}{
+		{
+			annotation: "root=/app",
+			msg:        "no path",
+		},
+		{
+			annotation: "path=/",
+			msg:        "no root",
+		},
+		{
+			annotation: "path=/ root=app",
+			msg:        "relative root",
+		},
+		{
+			annotation: "path=/app root=/app",
+			msg:        "root is the path",
+		},
+		{
+			annotation: "path=/ root=/app;path=/ root=/shop",
+			msg:        "duplicate path",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseAppRoots(test.annotation)
+		if err == nil {
+			t.Errorf("ParseAppRoots() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestCreateRegexRewrites(t *testing.T) {
+	ingEx := createCafeIngressEx()
+	rules := []RegexRewriteRule{
+		{
+			Regex:       `^/app/(\w+)$`,
+			Replacement: "/$1",
+			Flag:        "break",
+		},
+	}
+
+	expected := []RegexRewrite{
+		{
+			Regex:       `"^/app$"`,
+			Replacement: `"/app/home"`,
+			Flag:        "redirect",
+		},
+		{
+			Regex:       `"^/app/(\\w+)$"`,
+			Replacement: `"/$1"`,
+			Flag:        "break",
+		},
+	}
+
+	result := createRegexRewrites(&ingEx, "/app", pathTypeExact, rules, "/app/home")
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("createRegexRewrites() returned %v, but expected %v", result, expected)
+	}
+
+	// the app root is ignored for a regex path
+	result = createRegexRewrites(&ingEx, "^/app", pathTypeRegex, rules, "/app/home")
+	if !reflect.DeepEqual(result, expected[1:]) {
+		t.Errorf("createRegexRewrites() returned %v, but expected %v", result, expected[1:])
+	}
+}
+
+func TestGenerateNginxCfgWithRegexRewrites(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations["nginx.org/grpc-services"] = "tea-svc"
+	cafeIngressEx.Ingress.Annotations["nginx.org/rewrites"] = "serviceName=coffee-svc rewrite=/beans/"
+	cafeIngressEx.Ingress.Annotations[RegexRewritesAnnotation] = "path=/coffee regex=^/coffee/(.*)$ replacement=/$1;" +
+		"path=/tea regex=^/tea/(.*)$ replacement=/tea.TeaService/$1"
+	cafeIngressEx.Ingress.Annotations[AppRootAnnotation] = "path=/ root=/coffee"
+	cafeIngressEx.Ingress.Spec.Rules[0].HTTP.Paths = append(cafeIngressEx.Ingress.Spec.Rules[0].HTTP.Paths, extensions.HTTPIngressPath{
+		Path: "/",
+		Backend: extensions.IngressBackend{
+			ServiceName: "juice-svc",
+			ServicePort: intstr.FromString("80"),
+		},
+	})
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	// gRPC requires HTTP2
+	cnf.config.HTTP2 = true
+
+	pems := map[string][]string{
+		"cafe.example.com": {"/etc/nginx/secrets/default-cafe-secret"},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, pems, false)
+
+	expected := map[string][]RegexRewrite{
+		"/coffee": {
+			{
+				Regex:       `"^/coffee/(.*)$"`,
+				Replacement: `"/$1"`,
+				Flag:        "break",
+			},
+		},
+		"/tea": {
+			{
+				Regex:       `"^/tea/(.*)$"`,
+				Replacement: `"/tea.TeaService/$1"`,
+				Flag:        "break",
+			},
+		},
+		"/": {
+			{
+				Regex:       `"^/$"`,
+				Replacement: `"/coffee"`,
+				Flag:        "redirect",
+			},
+		},
+	}
+
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.RegexRewrites, expected[loc.Path]) {
+			t.Errorf("generateNginxCfg returned rewrites %v for location %v, but expected %v", loc.RegexRewrites, loc.Path, expected[loc.Path])
+		}
+		// the regex rewrites replace the rewrite of the service
+		if loc.Rewrite != "" {
+			t.Errorf("generateNginxCfg returned rewrite %v for location %v, but expected none", loc.Rewrite, loc.Path)
+		}
+		if loc.Path == "/tea" && !loc.GRPC {
+			t.Errorf("generateNginxCfg returned a location /tea without gRPC")
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package configs
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"time"
 
 	api_v1 "k8s.io/api/core/v1"
 )
@@ -11,10 +15,30 @@ const (
 	TLS = iota
 	// JWK Secret
 	JWK
+	// Htpasswd Secret
+	Htpasswd
+	// CA Secret
+	CA
 )
 
+// HtpasswdSecretKey is the key of the data field of a Secret where the htpasswd file must be stored.
+const HtpasswdSecretKey = "htpasswd"
+
+// CASecretKey is the key of the data field of a Secret where the certificate of a certificate authority must be stored.
+const CASecretKey = "ca.crt"
+
 // ValidateTLSSecret validates the secret. If it is valid, the function returns nil.
+// The certificates and the key must be valid PEM, the key must match the certificate of the server,
+// the chain must be ordered from the certificate of the server to the root and every certificate must be valid now.
 func ValidateTLSSecret(secret *api_v1.Secret) error {
+	if err := validateTLSSecretKeys(secret); err != nil {
+		return err
+	}
+
+	return validateCertificateAndKey(secret.Data[api_v1.TLSCertKey], secret.Data[api_v1.TLSPrivateKeyKey], time.Now())
+}
+
+func validateTLSSecretKeys(secret *api_v1.Secret) error {
 	if _, exists := secret.Data[api_v1.TLSCertKey]; !exists {
 		return fmt.Errorf("Secret doesn't have %v", api_v1.TLSCertKey)
 	}
@@ -26,6 +50,102 @@ func ValidateTLSSecret(secret *api_v1.Secret) error {
 	return nil
 }
 
+func validateCertificateAndKey(certPEM []byte, keyPEM []byte, now time.Time) error {
+	var certs []*x509.Certificate
+
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%v must only have certificates, found %v", api_v1.TLSCertKey, block.Type)
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("Invalid certificate %d in %v: %v", len(certs)+1, api_v1.TLSCertKey, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return fmt.Errorf("%v doesn't have a PEM certificate", api_v1.TLSCertKey)
+	}
+
+	// the key must be of a supported type and match the public key of the certificate of the server
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return fmt.Errorf("Invalid %v: %v", api_v1.TLSPrivateKeyKey, err)
+	}
+
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return fmt.Errorf("Certificate %d (%v) in %v is not issued by the next certificate (%v): the chain must start with the certificate of the server, followed by the certificates of the issuers: %v",
+				i+1, certs[i].Subject, api_v1.TLSCertKey, certs[i+1].Subject, err)
+		}
+	}
+
+	// the validity period is checked last, so that the callers can tell a well-formed Secret from a malformed one
+	for i, cert := range certs {
+		if now.Before(cert.NotBefore) {
+			return &CertificateValidityError{
+				message: fmt.Sprintf("Certificate %d (%v) in %v is not valid before %v", i+1, cert.Subject, api_v1.TLSCertKey, cert.NotBefore.UTC().Format(time.RFC3339)),
+			}
+		}
+		if now.After(cert.NotAfter) {
+			return &CertificateValidityError{
+				message: fmt.Sprintf("Certificate %d (%v) in %v expired on %v", i+1, cert.Subject, api_v1.TLSCertKey, cert.NotAfter.UTC().Format(time.RFC3339)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// CertificateValidityError is returned by ValidateTLSSecret for a well-formed TLS Secret with a certificate
+// that is expired or not valid yet.
+type CertificateValidityError struct {
+	message string
+}
+
+func (e *CertificateValidityError) Error() string {
+	return e.message
+}
+
+// IsCertificateValidityError checks if the error of ValidateTLSSecret is only about the validity period of a certificate.
+func IsCertificateValidityError(err error) bool {
+	_, ok := err.(*CertificateValidityError)
+	return ok
+}
+
+// ValidateTLSSecretHost checks that the host is one of the Subject Alternative Names of the certificate of the TLS Secret.
+// The Common Name of the certificate is not considered.
+func ValidateTLSSecretHost(secret *api_v1.Secret, host string) error {
+	cert, err := ParseTLSSecretCertificate(secret)
+	if err != nil {
+		return err
+	}
+
+	return cert.VerifyHostname(host)
+}
+
+// ParseTLSSecretCertificate parses the first certificate of the TLS Secret, which is the certificate of the server.
+// The other certificates of the Secret are the intermediate certificates of the chain.
+func ParseTLSSecretCertificate(secret *api_v1.Secret) (*x509.Certificate, error) {
+	rest := secret.Data[api_v1.TLSCertKey]
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("Secret doesn't have a certificate in %v", api_v1.TLSCertKey)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
 // ValidateJWKSecret validates the secret. If it is valid, the function returns nil.
 func ValidateJWKSecret(secret *api_v1.Secret) error {
 	if _, exists := secret.Data[JWTKeyKey]; !exists {
@@ -35,14 +155,43 @@ func ValidateJWKSecret(secret *api_v1.Secret) error {
 	return nil
 }
 
+// ValidateHtpasswdSecret validates the secret. If it is valid, the function returns nil.
+func ValidateHtpasswdSecret(secret *api_v1.Secret) error {
+	if _, exists := secret.Data[HtpasswdSecretKey]; !exists {
+		return fmt.Errorf("Secret doesn't have %v", HtpasswdSecretKey)
+	}
+
+	return nil
+}
+
+// ValidateCASecret validates the secret. If it is valid, the function returns nil.
+func ValidateCASecret(secret *api_v1.Secret) error {
+	if _, exists := secret.Data[CASecretKey]; !exists {
+		return fmt.Errorf("Secret doesn't have %v", CASecretKey)
+	}
+
+	// the file of a TLS Secret holds the certificate and the key rather than the CA
+	if _, exists := secret.Data[api_v1.TLSCertKey]; exists {
+		return fmt.Errorf("Secret must not have %v", api_v1.TLSCertKey)
+	}
+
+	return nil
+}
+
 // GetSecretKind returns the kind of the Secret.
 func GetSecretKind(secret *api_v1.Secret) (int, error) {
-	if err := ValidateTLSSecret(secret); err == nil {
+	if err := validateTLSSecretKeys(secret); err == nil {
 		return TLS, nil
 	}
 	if err := ValidateJWKSecret(secret); err == nil {
 		return JWK, nil
 	}
+	if err := ValidateHtpasswdSecret(secret); err == nil {
+		return Htpasswd, nil
+	}
+	if err := ValidateCASecret(secret); err == nil {
+		return CA, nil
+	}
 
 	return 0, fmt.Errorf("Unknown Secret")
 }
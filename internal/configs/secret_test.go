@@ -0,0 +1,308 @@
+package configs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// createTestCertificate creates a certificate for the hosts. The certificate is self-signed if the issuer is nil.
+func createTestCertificate(commonName string, hosts []string, notBefore time.Time, notAfter time.Time, issuer *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              hosts,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		IsCA:                  len(hosts) == 0,
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// createTestTLSSecretData creates the data of a TLS Secret with a self-signed certificate for the hosts, which is valid for a year.
+func createTestTLSSecretData(hosts ...string) map[string][]byte {
+	cert := createTestCertificate(hosts[0], hosts, time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour), nil)
+
+	return map[string][]byte{
+		api_v1.TLSCertKey:       cert.certPEM,
+		api_v1.TLSPrivateKeyKey: cert.keyPEM,
+	}
+}
+
+func concatPEM(pems ...[]byte) []byte {
+	var result []byte
+	for _, p := range pems {
+		result = append(result, p...)
+	}
+	return result
+}
+
+func TestParseTLSSecretCertificate(t *testing.T) {
+	notAfter := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ca := createTestCertificate("cafe-ca", nil, notAfter.Add(-48*time.Hour), notAfter.Add(24*time.Hour), nil)
+	serverCert := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, notAfter.Add(-24*time.Hour), notAfter, ca)
+
+	secret := &api_v1.Secret{
+		Data: map[string][]byte{
+			api_v1.TLSCertKey: concatPEM([]byte("# the chain of cafe.example.com\n"), serverCert.certPEM, ca.certPEM),
+		},
+	}
+
+	cert, err := ParseTLSSecretCertificate(secret)
+	if err != nil {
+		t.Fatalf("ParseTLSSecretCertificate() returned an error: %v", err)
+	}
+	if cert.Subject.CommonName != "cafe.example.com" {
+		t.Errorf("ParseTLSSecretCertificate() returned the certificate of %v, but expected cafe.example.com", cert.Subject.CommonName)
+	}
+	if !cert.NotAfter.Equal(notAfter) {
+		t.Errorf("ParseTLSSecretCertificate() returned a certificate that expires on %v, but expected %v", cert.NotAfter, notAfter)
+	}
+}
+
+func TestParseTLSSecretCertificateFails(t *testing.T) {
+	tests := []struct {
+		certificate []byte
+		msg         string
+	}{
+		{
+			certificate: nil,
+			msg:         "no certificate",
+		},
+		{
+			certificate: []byte("certificate"),
+			msg:         "not a PEM",
+		},
+		{
+			certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("certificate")}),
+			msg:         "invalid certificate",
+		},
+		{
+			certificate: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}),
+			msg:         "key instead of a certificate",
+		},
+	}
+
+	for _, test := range tests {
+		secret := &api_v1.Secret{
+			Data: map[string][]byte{
+				api_v1.TLSCertKey: test.certificate,
+			},
+		}
+		_, err := ParseTLSSecretCertificate(secret)
+		if err == nil {
+			t.Errorf("ParseTLSSecretCertificate() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestValidateCertificateAndKey(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ca := createTestCertificate("cafe-ca", nil, now.Add(-48*time.Hour), now.Add(48*time.Hour), nil)
+	serverCert := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, now.Add(-24*time.Hour), now.Add(24*time.Hour), ca)
+
+	tests := []struct {
+		certPEM []byte
+		keyPEM  []byte
+		msg     string
+	}{
+		{
+			certPEM: serverCert.certPEM,
+			keyPEM:  serverCert.keyPEM,
+			msg:     "certificate of the server",
+		},
+		{
+			certPEM: concatPEM(serverCert.certPEM, ca.certPEM),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "chain",
+		},
+	}
+
+	for _, test := range tests {
+		err := validateCertificateAndKey(test.certPEM, test.keyPEM, now)
+		if err != nil {
+			t.Errorf("validateCertificateAndKey() returned an error for the case of %s: %v", test.msg, err)
+		}
+	}
+}
+
+func TestValidateCertificateAndKeyFails(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ca := createTestCertificate("cafe-ca", nil, now.Add(-48*time.Hour), now.Add(48*time.Hour), nil)
+	serverCert := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, now.Add(-24*time.Hour), now.Add(24*time.Hour), ca)
+	otherCert := createTestCertificate("tea.example.com", []string{"tea.example.com"}, now.Add(-24*time.Hour), now.Add(24*time.Hour), nil)
+	expiredCert := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, now.Add(-48*time.Hour), now.Add(-24*time.Hour), ca)
+	futureCert := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, now.Add(24*time.Hour), now.Add(48*time.Hour), ca)
+	expiredCA := createTestCertificate("cafe-ca", nil, now.Add(-48*time.Hour), now.Add(-time.Hour), nil)
+	certOfExpiredCA := createTestCertificate("cafe.example.com", []string{"cafe.example.com"}, now.Add(-24*time.Hour), now.Add(24*time.Hour), expiredCA)
+
+	tests := []struct {
+		certPEM       []byte
+		keyPEM        []byte
+		validityError bool
+		msg           string
+	}{
+		{
+			certPEM: []byte("certificate"),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "certificate is not a PEM",
+		},
+		{
+			certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("certificate")}),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "invalid certificate",
+		},
+		{
+			certPEM: concatPEM(serverCert.certPEM, serverCert.keyPEM),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "key in the certificates",
+		},
+		{
+			certPEM: serverCert.certPEM,
+			keyPEM:  []byte("key"),
+			msg:     "key is not a PEM",
+		},
+		{
+			certPEM: serverCert.certPEM,
+			keyPEM:  otherCert.keyPEM,
+			msg:     "key doesn't match the certificate",
+		},
+		{
+			certPEM: concatPEM(ca.certPEM, serverCert.certPEM),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "chain in the wrong order",
+		},
+		{
+			certPEM: concatPEM(serverCert.certPEM, otherCert.certPEM),
+			keyPEM:  serverCert.keyPEM,
+			msg:     "chain with a certificate of another issuer",
+		},
+		{
+			certPEM:       expiredCert.certPEM,
+			keyPEM:        expiredCert.keyPEM,
+			validityError: true,
+			msg:           "expired certificate",
+		},
+		{
+			certPEM:       futureCert.certPEM,
+			keyPEM:        futureCert.keyPEM,
+			validityError: true,
+			msg:           "certificate is not valid yet",
+		},
+		{
+			certPEM:       concatPEM(certOfExpiredCA.certPEM, expiredCA.certPEM),
+			keyPEM:        certOfExpiredCA.keyPEM,
+			validityError: true,
+			msg:           "expired certificate in the chain",
+		},
+	}
+
+	for _, test := range tests {
+		err := validateCertificateAndKey(test.certPEM, test.keyPEM, now)
+		if err == nil {
+			t.Errorf("validateCertificateAndKey() returned no error for the case of %s", test.msg)
+			continue
+		}
+		if IsCertificateValidityError(err) != test.validityError {
+			t.Errorf("IsCertificateValidityError() returned %v for the error %q for the case of %s", !test.validityError, err, test.msg)
+		}
+	}
+}
+
+func TestValidateTLSSecretHost(t *testing.T) {
+	secret := &api_v1.Secret{
+		Data: createTestTLSSecretData("cafe.example.com", "*.tea.example.com"),
+	}
+
+	tests := []struct {
+		host    string
+		isValid bool
+	}{
+		{
+			host:    "cafe.example.com",
+			isValid: true,
+		},
+		{
+			host:    "green.tea.example.com",
+			isValid: true,
+		},
+		{
+			host:    "coffee.example.com",
+			isValid: false,
+		},
+		{
+			host:    "tea.example.com",
+			isValid: false,
+		},
+		{
+			host:    "*.tea.example.com",
+			isValid: true,
+		},
+		{
+			host:    "*.example.com",
+			isValid: false,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateTLSSecretHost(secret, test.host)
+		if test.isValid && err != nil {
+			t.Errorf("ValidateTLSSecretHost() returned an error for host %v: %v", test.host, err)
+		}
+		if !test.isValid && err == nil {
+			t.Errorf("ValidateTLSSecretHost() returned no error for host %v", test.host)
+		}
+	}
+
+	// the Common Name is not a Subject Alternative Name
+	cert := createTestCertificate("cafe.example.com", nil, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), nil)
+	secret.Data[api_v1.TLSCertKey] = cert.certPEM
+	if err := ValidateTLSSecretHost(secret, "cafe.example.com"); err == nil {
+		t.Errorf("ValidateTLSSecretHost() returned no error for a certificate without Subject Alternative Names")
+	}
+}
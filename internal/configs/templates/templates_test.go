@@ -26,6 +26,8 @@ var testUps = configs.Upstream{
 	},
 }
 
+var corsMaxAge = 3600
+
 var headers = map[string]string{"Test-Header": "test-header-value"}
 var healthCheck = configs.HealthCheck{
 	UpstreamName: "test",
@@ -48,12 +50,25 @@ var ingCfg = configs.IngressNginxConfig{
 				Token:                "$cookie_auth_token",
 				RedirectLocationName: "@login_url-default-cafe-ingres",
 			},
-			SSL:               true,
-			SSLCertificate:    "secret.pem",
-			SSLCertificateKey: "secret.pem",
-			SSLCiphers:        "NULL",
-			SSLPorts:          []int{443},
-			SSLRedirect:       true,
+			SSL: true,
+			SSLCertificates: []configs.SSLCertificate{
+				{
+					Certificate: "secret-rsa.pem",
+					Key:         "secret-rsa.pem",
+				},
+				{
+					Certificate: "secret-ecdsa.pem",
+					Key:         "secret-ecdsa.pem",
+				},
+			},
+			SSLStapling: &configs.SSLStapling{
+				Verify:             true,
+				TrustedCertificate: "chain.pem",
+			},
+			SSLCiphers:     "NULL",
+			SSLPorts:       []int{443, 8443},
+			SSLRedirect:    true,
+			TLSPassthrough: true,
 			Locations: []configs.Location{
 				{
 					Path:                "/tea",
@@ -66,13 +81,129 @@ var ingCfg = configs.IngressNginxConfig{
 						Realm: "closed site",
 						Token: "$cookie_auth_token",
 					},
+					AuthRequest: &configs.AuthRequest{
+						Location: "/internal_auth_default_cafe-ingress",
+						ResponseHeaders: []configs.AuthResponseHeader{
+							{
+								Name:     "X-User",
+								Variable: "auth_resp_x_user",
+								Source:   "$upstream_http_x_user",
+							},
+						},
+						SigninLocationName: "@auth_signin_default_cafe-ingress",
+					},
+					AccessControl: &configs.AccessControl{
+						Allow: []string{"10.0.0.0/8"},
+						Deny:  []string{"10.0.0.1"},
+					},
+					CORS: &configs.CORS{
+						AllowOrigin:      "$cors_origin_default_cafe_ingress",
+						Vary:             true,
+						AllowMethods:     "GET, POST",
+						AllowHeaders:     "Content-Type",
+						AllowCredentials: true,
+						MaxAge:           &corsMaxAge,
+					},
+					ErrorPages: []configs.ErrorPage{
+						{
+							Codes:    "404",
+							Location: "/internal_error_page_default_cafe-ingress_0",
+						},
+						{
+							Codes:    "502 503",
+							Location: "/internal_error_page_default_cafe-ingress_1",
+						},
+					},
+					BasicAuth: &configs.BasicAuth{
+						Secret: "/etc/nginx/secrets/default-cafe-htpasswd",
+						Realm:  "Cafe App",
+					},
+					SSL: true,
+					ProxySSL: &configs.ProxySSL{
+						TrustedCertificate: "/etc/nginx/secrets/default-tea-ca",
+						Verify:             true,
+						Name:               "tea-svc.default.svc",
+						ServerName:         true,
+						Certificate:        "/etc/nginx/secrets/default-tea-client",
+					},
+					LimitReq: &configs.LimitReq{
+						Zone:       "ing_default_cafe-ingress",
+						Burst:      20,
+						NoDelay:    true,
+						RejectCode: 429,
+					},
+					ProxySetHeaders: []configs.Header{
+						{
+							Name:  "X-Request-Start",
+							Value: `"t=${msec}"`,
+						},
+					},
+					AddHeaders: []configs.Header{
+						{
+							Name:  "X-Frame-Options",
+							Value: `"DENY"`,
+						},
+					},
 					MinionIngress: &configs.Ingress{
 						Name:      "tea-minion",
 						Namespace: "default",
 					},
 				},
+				{
+					Path:                `~ "^/coffee/(espresso|latte)$"`,
+					Upstream:            testUps,
+					ProxyConnectTimeout: "10s",
+					ProxyReadTimeout:    "10s",
+					ClientMaxBodySize:   "2m",
+					GRPC:                true,
+					RegexRewrites: []configs.RegexRewrite{
+						{
+							Regex:       `"^/coffee/(.*)$"`,
+							Replacement: `"/coffee.CoffeeService/$1"`,
+							Flag:        "break",
+						},
+					},
+					ProxySetHeaders: []configs.Header{
+						{
+							Name:  "X-Client-Addr",
+							Value: `"$remote_addr"`,
+						},
+					},
+					AddHeaders: []configs.Header{
+						{
+							Name:  "Cache-Control",
+							Value: `"no-store"`,
+						},
+					},
+				},
 			},
 			HealthChecks: map[string]configs.HealthCheck{"test": healthCheck},
+			AccessControl: &configs.AccessControl{
+				Allow: []string{"192.168.1.0/24", "2001:db8::/32"},
+			},
+			ClientCertVerification: &configs.ClientCertVerification{
+				CA:     "/etc/nginx/secrets/default-cafe-ca",
+				Verify: "on",
+				Depth:  2,
+			},
+			ErrorPageLocations: []configs.ErrorPageLocation{
+				{
+					Path:      "/internal_error_page_default_cafe-ingress_0",
+					ProxyPass: "http://default-cafe-ingress--errors-svc-80/404.html",
+				},
+				{
+					Path: "/internal_error_page_default_cafe-ingress_1",
+					Body: "<html><body>Try again later</body></html>",
+				},
+			},
+			AuthRequestLocations: []configs.AuthRequestLocation{
+				{
+					Path:               "/internal_auth_default_cafe-ingress",
+					ProxyPass:          "http://auth.example.com/validate",
+					SigninLocationName: "@auth_signin_default_cafe-ingress",
+					SigninURL:          "https://login.example.com?rd=$scheme://$host$request_uri",
+				},
+			},
 			JWTRedirectLocations: []configs.JWTRedirectLocation{
 				{
 					Name:     "@login_url-default-cafe-ingress",
@@ -82,6 +213,52 @@ var ingCfg = configs.IngressNginxConfig{
 		},
 	},
 	Upstreams: []configs.Upstream{testUps},
+	SplitClients: []configs.SplitClient{
+		{
+			Source:   "$request_id",
+			Variable: "split_test",
+			Distributions: []configs.Distribution{
+				{
+					Weight: "90%",
+					Value:  "test",
+				},
+				{
+					Weight: "10%",
+					Value:  "test",
+				},
+			},
+		},
+	},
+	Maps: []configs.Map{
+		{
+			Source:   "$http_x_canary",
+			Variable: "match_test",
+			Parameters: []configs.Parameter{
+				{
+					Value:  `"always"`,
+					Result: "/internal_match_test_0",
+				},
+				{
+					Value:  "default",
+					Result: "/internal_match_test_default",
+				},
+			},
+		},
+		{
+			Source:   "$http_origin",
+			Variable: "cors_origin_default_cafe_ingress",
+			Parameters: []configs.Parameter{
+				{
+					Value:  "default",
+					Result: `""`,
+				},
+				{
+					Value:  `"~^https://[a-z]+\.example\.com$"`,
+					Result: "$http_origin",
+				},
+			},
+		},
+	},
 	Keepalive: "16",
 	Ingress: configs.Ingress{
 		Name:      "cafe-ingress",
@@ -105,6 +282,50 @@ var mainCfg = configs.MainConfig{
 	ResolverTimeout:        "15s",
 	KeepaliveTimeout:       "65s",
 	KeepaliveRequests:      100,
+	LimitReqZones: []configs.LimitReqZone{
+		{
+			Name: "ing_default_cafe-ingress",
+			Key:  "$binary_remote_addr",
+			Size: "10m",
+			Rate: "10r/s",
+		},
+	},
+	StreamUpstreams: []configs.StreamUpstream{
+		{
+			Name: "ts_default_dns",
+			UpstreamServers: []configs.StreamUpstreamServer{
+				{
+					Address: "10.0.0.20",
+					Port:    "53",
+				},
+			},
+		},
+	},
+	StreamServers: []configs.StreamServer{
+		{
+			Port:       5353,
+			UDP:        true,
+			StatusZone: "ts_default_dns",
+			ProxyPass:  "ts_default_dns",
+		},
+	},
+	DefaultBackendEndpoints: []string{"10.0.0.30:8080"},
+	TLSPassthrough:          true,
+	TLSPassthroughHosts: []configs.TLSPassthroughHost{
+		{
+			Host:         "app.example.com",
+			ServerName:   "app.example.com",
+			Socket:       "unix:/var/run/nginx-tls-passthrough-0123456789abcdef.sock",
+			UpstreamName: "default-app-ingress-app.example.com-app-svc-443",
+		},
+		{
+			Host:         "*.apps.example.com",
+			ServerName:   `~^[^.]+\.apps\.example\.com$`,
+			Socket:       "unix:/var/run/nginx-tls-passthrough-fedcba9876543210.sock",
+			UpstreamName: "default-apps-ingress-_wildcard.apps.example.com-apps-svc-443",
+		},
+	},
+	TLSPassthroughExcludedHosts: []string{"cafe.apps.example.com"},
 }
 
 func TestIngressForNGINXPlus(t *testing.T) {
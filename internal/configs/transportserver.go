@@ -0,0 +1,121 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+)
+
+// TransportServerEx holds a TransportServer along with the endpoints of its upstream service.
+type TransportServerEx struct {
+	TransportServer *conf_v1alpha1.TransportServer
+	Endpoints       []string
+}
+
+func (tsx *TransportServerEx) String() string {
+	if tsx == nil {
+		return "<nil>"
+	}
+
+	if tsx.TransportServer == nil {
+		return "TransportServerEx has no TransportServer"
+	}
+
+	return fmt.Sprintf("%s/%s", tsx.TransportServer.Namespace, tsx.TransportServer.Name)
+}
+
+// AddOrUpdateTransportServer adds or updates NGINX configuration for the TransportServer resource.
+// TransportServers are configured in the stream context of the main NGINX configuration file.
+func (cnf *Configurator) AddOrUpdateTransportServer(transportServerEx *TransportServerEx) error {
+	name := getNameForTransportServer(transportServerEx.TransportServer)
+	prev, exists := cnf.transportServers[name]
+	cnf.transportServers[name] = transportServerEx
+
+	if err := cnf.updateMainConfig(); err != nil {
+		// the main config keeps the previous stream server of the TransportServer
+		if exists {
+			cnf.transportServers[name] = prev
+		} else {
+			delete(cnf.transportServers, name)
+		}
+		return fmt.Errorf("Error adding or updating TransportServer %v: %v", transportServerEx, err)
+	}
+	if err := cnf.nginx.Reload(); err != nil {
+		return fmt.Errorf("Error reloading NGINX for TransportServer %v: %v", transportServerEx, err)
+	}
+	return nil
+}
+
+// DeleteTransportServer deletes NGINX configuration for the TransportServer resource.
+func (cnf *Configurator) DeleteTransportServer(key string) error {
+	delete(cnf.transportServers, getNameForTransportServerFromKey(key))
+
+	if err := cnf.updateMainConfig(); err != nil {
+		return fmt.Errorf("Error when removing TransportServer %v: %v", key, err)
+	}
+	if err := cnf.nginx.Reload(); err != nil {
+		return fmt.Errorf("Error when removing TransportServer %v: %v", key, err)
+	}
+	return nil
+}
+
+// HasTransportServer checks if the TransportServer resource is present in NGINX configuration.
+func (cnf *Configurator) HasTransportServer(ts *conf_v1alpha1.TransportServer) bool {
+	_, exists := cnf.transportServers[getNameForTransportServer(ts)]
+	return exists
+}
+
+// generateStreamConfig generates the stream upstreams and servers for the TransportServers.
+// The result is sorted by the names of the TransportServers so that the main NGINX configuration file is stable.
+func (cnf *Configurator) generateStreamConfig() ([]StreamUpstream, []StreamServer) {
+	names := make([]string, 0, len(cnf.transportServers))
+	for name := range cnf.transportServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var upstreams []StreamUpstream
+	var servers []StreamServer
+
+	for _, name := range names {
+		tsEx := cnf.transportServers[name]
+		ts := tsEx.TransportServer
+
+		upstreams = append(upstreams, createStreamUpstream(name, tsEx.Endpoints))
+		servers = append(servers, StreamServer{
+			Port:       ts.Spec.Listener.Port,
+			UDP:        ts.Spec.Listener.Protocol == conf_v1alpha1.ProtocolUDP,
+			StatusZone: name,
+			ProxyPass:  name,
+		})
+	}
+
+	return upstreams, servers
+}
+
+func createStreamUpstream(name string, endpoints []string) StreamUpstream {
+	if len(endpoints) == 0 {
+		return NewStreamUpstreamWithDefaultServer(name)
+	}
+
+	ups := StreamUpstream{Name: name}
+	for _, endp := range endpoints {
+		addressport := strings.Split(endp, ":")
+		ups.UpstreamServers = append(ups.UpstreamServers, StreamUpstreamServer{
+			Address: addressport[0],
+			Port:    addressport[1],
+		})
+	}
+
+	return ups
+}
+
+func getNameForTransportServer(ts *conf_v1alpha1.TransportServer) string {
+	return fmt.Sprintf("ts_%s_%s", ts.Namespace, ts.Name)
+}
+
+func getNameForTransportServerFromKey(key string) string {
+	return fmt.Sprintf("ts_%s", strings.Replace(key, "/", "_", -1))
+}
@@ -0,0 +1,110 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createTransportServerEx(name string, port int, protocol string, endpoints []string) *TransportServerEx {
+	return &TransportServerEx{
+		TransportServer: &conf_v1alpha1.TransportServer{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: conf_v1alpha1.TransportServerSpec{
+				Listener: conf_v1alpha1.TransportServerListener{
+					Port:     port,
+					Protocol: protocol,
+				},
+				Upstream: conf_v1alpha1.TransportServerUpstream{
+					Service: name + "-svc",
+					Port:    uint16(port),
+				},
+			},
+		},
+		Endpoints: endpoints,
+	}
+}
+
+func TestGenerateStreamConfig(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+
+	dnsEx := createTransportServerEx("dns", 53, "UDP", []string{"10.0.0.20:53", "10.0.0.21:53"})
+	mysqlEx := createTransportServerEx("mysql", 3306, "TCP", nil)
+	cnf.transportServers[getNameForTransportServer(mysqlEx.TransportServer)] = mysqlEx
+	cnf.transportServers[getNameForTransportServer(dnsEx.TransportServer)] = dnsEx
+
+	expectedUpstreams := []StreamUpstream{
+		{
+			Name: "ts_default_dns",
+			UpstreamServers: []StreamUpstreamServer{
+				{
+					Address: "10.0.0.20",
+					Port:    "53",
+				},
+				{
+					Address: "10.0.0.21",
+					Port:    "53",
+				},
+			},
+		},
+		NewStreamUpstreamWithDefaultServer("ts_default_mysql"),
+	}
+	expectedServers := []StreamServer{
+		{
+			Port:       53,
+			UDP:        true,
+			StatusZone: "ts_default_dns",
+			ProxyPass:  "ts_default_dns",
+		},
+		{
+			Port:       3306,
+			UDP:        false,
+			StatusZone: "ts_default_mysql",
+			ProxyPass:  "ts_default_mysql",
+		},
+	}
+
+	upstreams, servers := cnf.generateStreamConfig()
+	if !reflect.DeepEqual(upstreams, expectedUpstreams) {
+		t.Errorf("generateStreamConfig returned upstreams \n%+v, but expected \n%+v", upstreams, expectedUpstreams)
+	}
+	if !reflect.DeepEqual(servers, expectedServers) {
+		t.Errorf("generateStreamConfig returned servers \n%+v, but expected \n%+v", servers, expectedServers)
+	}
+}
+
+func TestAddOrUpdateAndDeleteTransportServer(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	tsEx := createTransportServerEx("dns", 53, "UDP", []string{"10.0.0.20:53"})
+
+	if cnf.HasTransportServer(tsEx.TransportServer) {
+		t.Errorf("HasTransportServer returned true before the TransportServer was added")
+	}
+
+	err = cnf.AddOrUpdateTransportServer(tsEx)
+	if err != nil {
+		t.Errorf("AddOrUpdateTransportServer returned an error: %v", err)
+	}
+	if !cnf.HasTransportServer(tsEx.TransportServer) {
+		t.Errorf("HasTransportServer returned false after the TransportServer was added")
+	}
+
+	err = cnf.DeleteTransportServer("default/dns")
+	if err != nil {
+		t.Errorf("DeleteTransportServer returned an error: %v", err)
+	}
+	if cnf.HasTransportServer(tsEx.TransportServer) {
+		t.Errorf("HasTransportServer returned true after the TransportServer was deleted")
+	}
+}
@@ -0,0 +1,189 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// UpstreamTLSAnnotation is the annotation where the TLS settings of the connections to the SSL services are specified.
+const UpstreamTLSAnnotation = "nginx.org/upstream-tls"
+
+// UpstreamTLS holds the TLS settings of the connections to a service.
+type UpstreamTLS struct {
+	TrustedCASecret string
+	Verify          bool
+	Name            string
+	ServerName      bool
+	ClientSecret    string
+}
+
+// ParseUpstreamTLS parses the nginx.org/upstream-tls annotation of the Ingress. The annotation has the format
+// "serviceName=<service> [trusted-ca-secret=<secret>] [verify=on|off] [name=<name>] [server-name=on|off] [client-secret=<secret>][;...]".
+// The services must be listed in the nginx.org/ssl-services annotation. It returns nil if the annotation is not set.
+func ParseUpstreamTLS(ing *extensions.Ingress) (map[string]UpstreamTLS, error) {
+	annotation, exists := ing.Annotations[UpstreamTLSAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	sslServices := make(map[string]bool)
+	if services, exists := ing.Annotations["nginx.org/ssl-services"]; exists {
+		for _, svc := range strings.Split(services, ",") {
+			sslServices[svc] = true
+		}
+	}
+
+	result := make(map[string]UpstreamTLS)
+
+	for _, declaration := range strings.Split(annotation, ";") {
+		declaration = strings.TrimSpace(declaration)
+		if declaration == "" {
+			continue
+		}
+
+		serviceName, upstreamTLS, err := parseUpstreamTLSDeclaration(declaration, ing.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %v: %v", UpstreamTLSAnnotation, err)
+		}
+		if !sslServices[serviceName] {
+			return nil, fmt.Errorf("Invalid %v: service %v is not listed in nginx.org/ssl-services", UpstreamTLSAnnotation, serviceName)
+		}
+		if _, exists := result[serviceName]; exists {
+			return nil, fmt.Errorf("Invalid %v: duplicate declaration for the service %v", UpstreamTLSAnnotation, serviceName)
+		}
+
+		result[serviceName] = upstreamTLS
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("Invalid %v: no services are specified", UpstreamTLSAnnotation)
+	}
+
+	return result, nil
+}
+
+func parseUpstreamTLSDeclaration(declaration string, namespace string) (string, UpstreamTLS, error) {
+	var serviceName string
+	var verify, serverName string
+	var upstreamTLS UpstreamTLS
+
+	for _, field := range strings.Fields(declaration) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return "", UpstreamTLS{}, fmt.Errorf("invalid parameter %q in %s: must be of the format <name>=<value>", field, declaration)
+		}
+
+		name, value := parts[0], parts[1]
+		switch name {
+		case "serviceName":
+			serviceName = value
+		case "trusted-ca-secret":
+			upstreamTLS.TrustedCASecret = value
+		case "client-secret":
+			upstreamTLS.ClientSecret = value
+		case "verify":
+			verify = value
+		case "server-name":
+			serverName = value
+		case "name":
+			for _, msg := range validation.IsDNS1123Subdomain(value) {
+				return "", UpstreamTLS{}, fmt.Errorf("invalid name %q in %s: %v", value, declaration, msg)
+			}
+			upstreamTLS.Name = value
+		default:
+			return "", UpstreamTLS{}, fmt.Errorf("unknown parameter %q in %s", name, declaration)
+		}
+	}
+
+	if serviceName == "" {
+		return "", UpstreamTLS{}, fmt.Errorf("serviceName is missing in %s", declaration)
+	}
+
+	for _, secret := range []string{upstreamTLS.TrustedCASecret, upstreamTLS.ClientSecret} {
+		if secret == "" {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(secret) {
+			return "", UpstreamTLS{}, fmt.Errorf("invalid secret name %q in %s: %v", secret, declaration, msg)
+		}
+	}
+
+	// the certificate of the service is verified by default if a CA is specified
+	upstreamTLS.Verify = upstreamTLS.TrustedCASecret != ""
+	switch verify {
+	case "":
+	case "on":
+		if upstreamTLS.TrustedCASecret == "" {
+			return "", UpstreamTLS{}, fmt.Errorf("verify=on requires trusted-ca-secret in %s", declaration)
+		}
+		upstreamTLS.Verify = true
+	case "off":
+		upstreamTLS.Verify = false
+	default:
+		return "", UpstreamTLS{}, fmt.Errorf("invalid value %q of verify in %s: must be on or off", verify, declaration)
+	}
+
+	switch serverName {
+	case "", "on":
+		upstreamTLS.ServerName = true
+	case "off":
+		upstreamTLS.ServerName = false
+	default:
+		return "", UpstreamTLS{}, fmt.Errorf("invalid value %q of server-name in %s: must be on or off", serverName, declaration)
+	}
+
+	// the default name is the DNS name of the service rather than the name of the upstream
+	if upstreamTLS.Name == "" {
+		upstreamTLS.Name = fmt.Sprintf("%v.%v.svc", serviceName, namespace)
+	}
+
+	return serviceName, upstreamTLS, nil
+}
+
+func getUpstreamTLS(ingEx *IngressEx) map[string]UpstreamTLS {
+	upstreamTLS, err := ParseUpstreamTLS(ingEx.Ingress)
+	if err != nil {
+		glog.Errorf("Ingress %s/%s: %v, ignoring", ingEx.Ingress.Namespace, ingEx.Ingress.Name, err)
+		return nil
+	}
+	return upstreamTLS
+}
+
+// createProxySSL creates the TLS configuration of a location for an SSL service. A missing Secret is replaced
+// with the certificate of the default server, so that the verification of the service fails.
+func (cnf *Configurator) createProxySSL(ingEx *IngressEx, upstreamTLS UpstreamTLS) *ProxySSL {
+	proxySSL := &ProxySSL{
+		Verify:     upstreamTLS.Verify,
+		Name:       upstreamTLS.Name,
+		ServerName: upstreamTLS.ServerName,
+	}
+
+	if upstreamTLS.TrustedCASecret != "" {
+		proxySSL.TrustedCertificate = cnf.getUpstreamTLSSecretFileName(ingEx, upstreamTLS.TrustedCASecret)
+	}
+	if upstreamTLS.ClientSecret != "" {
+		proxySSL.Certificate = cnf.getUpstreamTLSSecretFileName(ingEx, upstreamTLS.ClientSecret)
+	}
+
+	return proxySSL
+}
+
+func (cnf *Configurator) getUpstreamTLSSecretFileName(ingEx *IngressEx, secretName string) string {
+	if ingEx.UpstreamTLSSecrets[secretName] == nil {
+		glog.Warningf("Ingress %s/%s: the Secret %v of %v is missing or invalid", ingEx.Ingress.Namespace, ingEx.Ingress.Name, secretName, UpstreamTLSAnnotation)
+		return pemFileNameForMissingTLSSecret
+	}
+	return cnf.nginx.GetSecretFileName(ingEx.Ingress.Namespace + "-" + secretName)
+}
+
+func (cnf *Configurator) updateUpstreamTLSSecrets(ingEx *IngressEx) {
+	for _, secret := range ingEx.UpstreamTLSSecrets {
+		if secret != nil {
+			cnf.addOrUpdateSecret(secret)
+		}
+	}
+}
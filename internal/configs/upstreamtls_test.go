@@ -0,0 +1,196 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseUpstreamTLS(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    map[string]UpstreamTLS
+		msg         string
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    nil,
+			msg:         "no upstream TLS",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc trusted-ca-secret=tea-ca",
+			},
+			expected: map[string]UpstreamTLS{
+				"tea-svc": {
+					TrustedCASecret: "tea-ca",
+					Verify:          true,
+					Name:            "tea-svc.default.svc",
+					ServerName:      true,
+				},
+			},
+			msg: "CA with the defaults",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc,coffee-svc",
+				UpstreamTLSAnnotation: "serviceName=tea-svc trusted-ca-secret=tea-ca verify=off name=tea.example.com server-name=off client-secret=tea-client;" +
+					"serviceName=coffee-svc",
+			},
+			expected: map[string]UpstreamTLS{
+				"tea-svc": {
+					TrustedCASecret: "tea-ca",
+					Verify:          false,
+					Name:            "tea.example.com",
+					ServerName:      false,
+					ClientSecret:    "tea-client",
+				},
+				"coffee-svc": {
+					Name:       "coffee-svc.default.svc",
+					ServerName: true,
+				},
+			},
+			msg: "all parameters and a service without verification",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := ParseUpstreamTLS(createIngressWithAnnotations(test.annotations))
+		if err != nil {
+			t.Errorf("ParseUpstreamTLS() returned an error for the case of %s: %v", test.msg, err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("ParseUpstreamTLS() returned %+v, but expected %+v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+}
+
+func TestParseUpstreamTLSFails(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		msg         string
+	}{
+		{
+			annotations: map[string]string{
+				UpstreamTLSAnnotation: "serviceName=tea-svc trusted-ca-secret=tea-ca",
+			},
+			msg: "service is not an SSL service",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "trusted-ca-secret=tea-ca",
+			},
+			msg: "missing service",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc verify=on",
+			},
+			msg: "verification without CA",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc trusted-ca-secret=tea-ca verify=yes",
+			},
+			msg: "invalid verify",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc name=tea;example.com",
+			},
+			msg: "invalid name",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc trusted-ca=tea-ca",
+			},
+			msg: "unknown parameter",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    "serviceName=tea-svc;serviceName=tea-svc server-name=off",
+			},
+			msg: "duplicate service",
+		},
+		{
+			annotations: map[string]string{
+				"nginx.org/ssl-services": "tea-svc",
+				UpstreamTLSAnnotation:    " ; ",
+			},
+			msg: "no services",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseUpstreamTLS(createIngressWithAnnotations(test.annotations))
+		if err == nil {
+			t.Errorf("ParseUpstreamTLS() returned no error for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestGenerateNginxCfgWithUpstreamTLS(t *testing.T) {
+	cafeIngressEx := createCafeIngressEx()
+	cafeIngressEx.Ingress.Annotations["nginx.org/ssl-services"] = "tea-svc,coffee-svc"
+	cafeIngressEx.Ingress.Annotations[UpstreamTLSAnnotation] = "serviceName=tea-svc trusted-ca-secret=tea-ca client-secret=tea-client;" +
+		"serviceName=coffee-svc trusted-ca-secret=coffee-ca"
+	cafeIngressEx.UpstreamTLSSecrets = map[string]*api_v1.Secret{
+		"tea-ca": {
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "tea-ca",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				CASecretKey: []byte("ca"),
+			},
+		},
+		"tea-client": {
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "tea-client",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				api_v1.TLSCertKey:       []byte("cert"),
+				api_v1.TLSPrivateKeyKey: []byte("key"),
+			},
+		},
+		"coffee-ca": nil,
+	}
+
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Errorf("Failed to create a test configurator: %v", err)
+	}
+
+	expected := map[string]*ProxySSL{
+		"/tea": {
+			TrustedCertificate: "/etc/nginx/secrets/default-tea-ca",
+			Verify:             true,
+			Name:               "tea-svc.default.svc",
+			ServerName:         true,
+			Certificate:        "/etc/nginx/secrets/default-tea-client",
+		},
+		"/coffee": {
+			TrustedCertificate: pemFileNameForMissingTLSSecret,
+			Verify:             true,
+			Name:               "coffee-svc.default.svc",
+			ServerName:         true,
+		},
+	}
+
+	result := cnf.generateNginxCfg(&cafeIngressEx, map[string][]string{}, false)
+	for _, loc := range result.Servers[0].Locations {
+		if !reflect.DeepEqual(loc.ProxySSL, expected[loc.Path]) {
+			t.Errorf("generateNginxCfg returned ProxySSL %+v for location %v, but expected %+v", loc.ProxySSL, loc.Path, expected[loc.Path])
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// VirtualServerEx holds a VirtualServer along with the resources that are referenced in this VirtualServer.
+type VirtualServerEx struct {
+	VirtualServer *conf_v1alpha1.VirtualServer
+	Endpoints     map[string][]string
+	TLSSecret     *api_v1.Secret
+}
+
+func (vsx *VirtualServerEx) String() string {
+	if vsx == nil {
+		return "<nil>"
+	}
+
+	if vsx.VirtualServer == nil {
+		return "VirtualServerEx has no VirtualServer"
+	}
+
+	return fmt.Sprintf("%s/%s", vsx.VirtualServer.Namespace, vsx.VirtualServer.Name)
+}
+
+// GenerateEndpointsKey generates a key for the Endpoints map in VirtualServerEx.
+func GenerateEndpointsKey(serviceNamespace string, serviceName string, port uint16) string {
+	return fmt.Sprintf("%s/%s:%d", serviceNamespace, serviceName, port)
+}
+
+// AddOrUpdateVirtualServer adds or updates NGINX configuration for the VirtualServer resource.
+func (cnf *Configurator) AddOrUpdateVirtualServer(virtualServerEx *VirtualServerEx) error {
+	if err := cnf.addOrUpdateVirtualServer(virtualServerEx); err != nil {
+		return fmt.Errorf("Error adding or updating VirtualServer %v/%v: %v", virtualServerEx.VirtualServer.Namespace, virtualServerEx.VirtualServer.Name, err)
+	}
+	if err := cnf.nginx.Reload(); err != nil {
+		return fmt.Errorf("Error reloading NGINX for VirtualServer %v/%v: %v", virtualServerEx.VirtualServer.Namespace, virtualServerEx.VirtualServer.Name, err)
+	}
+	return nil
+}
+
+func (cnf *Configurator) addOrUpdateVirtualServer(virtualServerEx *VirtualServerEx) error {
+	pemFile := cnf.updateVirtualServerTLSSecret(virtualServerEx)
+	nginxCfg := cnf.generateNginxCfgForVirtualServer(virtualServerEx, pemFile)
+	name := getFileNameForVirtualServer(virtualServerEx.VirtualServer)
+	content, err := cnf.templateExecutor.ExecuteIngressConfigTemplate(&nginxCfg)
+	if err != nil {
+		return fmt.Errorf("Error generating VirtualServer Config %v: %v", name, err)
+	}
+	if err := cnf.nginx.UpdateIngressConfigFile(name, content); err != nil {
+		return err
+	}
+	cnf.virtualServers[name] = virtualServerEx
+	return nil
+}
+
+// updateVirtualServerTLSSecret writes the TLS Secret of the VirtualServer to disk and returns the name of the pem file.
+// The name is empty if TLS termination is not enabled for the VirtualServer.
+func (cnf *Configurator) updateVirtualServerTLSSecret(virtualServerEx *VirtualServerEx) string {
+	if virtualServerEx.VirtualServer.Spec.TLS == nil {
+		return ""
+	}
+	if virtualServerEx.TLSSecret == nil {
+		return pemFileNameForMissingTLSSecret
+	}
+	return cnf.addOrUpdateSecret(virtualServerEx.TLSSecret)
+}
+
+func (cnf *Configurator) generateNginxCfgForVirtualServer(virtualServerEx *VirtualServerEx, pemFile string) IngressNginxConfig {
+	vs := virtualServerEx.VirtualServer
+	cfg := cnf.config
+
+	upstreams := make(map[string]Upstream)
+	for _, u := range vs.Spec.Upstreams {
+		upsName := getNameForVirtualServerUpstream(vs, u.Name)
+		endpoints := virtualServerEx.Endpoints[GenerateEndpointsKey(vs.Namespace, u.Service, u.Port)]
+		upstreams[upsName] = cnf.createUpstreamForVirtualServer(upsName, u, endpoints)
+	}
+
+	server := Server{
+		Name:                  vs.Spec.Host,
+		ServerTokens:          cfg.ServerTokens,
+		HTTP2:                 cfg.HTTP2,
+		RedirectToHTTPS:       cfg.RedirectToHTTPS,
+		SSLRedirect:           cfg.SSLRedirect,
+		ProxyProtocol:         cfg.ProxyProtocol,
+		HSTS:                  cfg.HSTS,
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		HSTSBehindProxy:       cfg.HSTSBehindProxy,
+		StatusZone:            vs.Spec.Host,
+		RealIPHeader:          cfg.RealIPHeader,
+		SetRealIPFrom:         cfg.SetRealIPFrom,
+		RealIPRecursive:       cfg.RealIPRecursive,
+		ProxyHideHeaders:      cfg.ProxyHideHeaders,
+		ProxyPassHeaders:      cfg.ProxyPassHeaders,
+		ServerSnippets:        cfg.ServerSnippets,
+		Ports:                 cfg.Ports,
+		SSLPorts:              cfg.SSLPorts,
+	}
+
+	if pemFile != "" {
+		server.SSL = true
+		server.SSLCertificates = []SSLCertificate{
+			{
+				Certificate: pemFile,
+				Key:         pemFile,
+			},
+		}
+		if cnf.isTLSPassthroughEnabled {
+			setTLSPassthroughListener(&server)
+		}
+		if pemFile == pemFileNameForMissingTLSSecret {
+			server.SSLCiphers = "NULL"
+		}
+	}
+
+	var locations []Location
+	for _, r := range vs.Spec.Routes {
+		upsName := getNameForVirtualServerUpstream(vs, r.Upstream)
+		loc := createLocation(r.Path, upstreams[upsName], cfg, false, "", false, false)
+		locations = append(locations, loc)
+	}
+	server.Locations = locations
+
+	var keepalive string
+	if cfg.Keepalive > 0 {
+		keepalive = strconv.FormatInt(cfg.Keepalive, 10)
+	}
+
+	return IngressNginxConfig{
+		Upstreams: upstreamMapToSlice(upstreams),
+		Servers:   []Server{server},
+		Keepalive: keepalive,
+		Ingress: Ingress{
+			Name:      vs.Name,
+			Namespace: vs.Namespace,
+		},
+	}
+}
+
+func (cnf *Configurator) createUpstreamForVirtualServer(name string, upstream conf_v1alpha1.Upstream, endpoints []string) Upstream {
+	var ups Upstream
+	if cnf.isPlus() {
+		ups = Upstream{Name: name}
+	} else {
+		ups = NewUpstreamWithDefaultServer(name)
+	}
+
+	ups.LBMethod = cnf.config.LBMethod
+	if upstream.LBMethod != "" {
+		var lbMethod string
+		var err error
+		if cnf.isPlus() {
+			lbMethod, err = ParseLBMethodForPlus(upstream.LBMethod)
+		} else {
+			lbMethod, err = ParseLBMethod(upstream.LBMethod)
+		}
+		if err != nil {
+			glog.Errorf("Upstream %v: %v, using the default load balancing method", name, err)
+		} else {
+			ups.LBMethod = lbMethod
+		}
+	}
+
+	maxFails := cnf.config.MaxFails
+	if upstream.MaxFails != nil {
+		maxFails = *upstream.MaxFails
+	}
+
+	failTimeout := cnf.config.FailTimeout
+	if upstream.FailTimeout != "" {
+		failTimeout = upstream.FailTimeout
+	}
+
+	var upsServers []UpstreamServer
+	for _, endp := range endpoints {
+		addressport := strings.Split(endp, ":")
+		upsServers = append(upsServers, UpstreamServer{
+			Address:     addressport[0],
+			Port:        addressport[1],
+			MaxFails:    maxFails,
+			FailTimeout: failTimeout,
+			SlowStart:   cnf.config.SlowStart,
+		})
+	}
+	if len(upsServers) > 0 {
+		ups.UpstreamServers = upsServers
+	}
+
+	return ups
+}
+
+// DeleteVirtualServer deletes NGINX configuration for the VirtualServer resource.
+func (cnf *Configurator) DeleteVirtualServer(key string) error {
+	name := getFileNameForVirtualServerFromKey(key)
+	cnf.nginx.DeleteIngress(name)
+	delete(cnf.virtualServers, name)
+
+	if err := cnf.nginx.Reload(); err != nil {
+		return fmt.Errorf("Error when removing VirtualServer %v: %v", key, err)
+	}
+	return nil
+}
+
+// HasVirtualServer checks if the VirtualServer resource is present in NGINX configuration.
+func (cnf *Configurator) HasVirtualServer(vs *conf_v1alpha1.VirtualServer) bool {
+	name := getFileNameForVirtualServer(vs)
+	_, exists := cnf.virtualServers[name]
+	return exists
+}
+
+func getNameForVirtualServerUpstream(vs *conf_v1alpha1.VirtualServer, upstreamName string) string {
+	return fmt.Sprintf("vs_%s_%s_%s", vs.Namespace, vs.Name, upstreamName)
+}
+
+func getFileNameForVirtualServer(vs *conf_v1alpha1.VirtualServer) string {
+	return fmt.Sprintf("vs_%s_%s", vs.Namespace, vs.Name)
+}
+
+func getFileNameForVirtualServerFromKey(key string) string {
+	return fmt.Sprintf("vs_%s", strings.Replace(key, "/", "_", -1))
+}
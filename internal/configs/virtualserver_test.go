@@ -0,0 +1,226 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createCafeVirtualServerEx() *VirtualServerEx {
+	maxFails := 5
+	return &VirtualServerEx{
+		VirtualServer: &conf_v1alpha1.VirtualServer{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "cafe",
+				Namespace: "default",
+			},
+			Spec: conf_v1alpha1.VirtualServerSpec{
+				Host: "cafe.example.com",
+				TLS: &conf_v1alpha1.TLS{
+					Secret: "cafe-secret",
+				},
+				Upstreams: []conf_v1alpha1.Upstream{
+					{
+						Name:    "tea",
+						Service: "tea-svc",
+						Port:    80,
+					},
+					{
+						Name:        "coffee",
+						Service:     "coffee-svc",
+						Port:        80,
+						LBMethod:    "least_conn",
+						MaxFails:    &maxFails,
+						FailTimeout: "30s",
+					},
+				},
+				Routes: []conf_v1alpha1.Route{
+					{
+						Path:     "/tea",
+						Upstream: "tea",
+					},
+					{
+						Path:     "/coffee",
+						Upstream: "coffee",
+					},
+				},
+			},
+		},
+		Endpoints: map[string][]string{
+			"default/tea-svc:80": {
+				"10.0.0.20:80",
+			},
+			"default/coffee-svc:80": {
+				"10.0.0.30:80",
+				"10.0.0.31:80",
+			},
+		},
+	}
+}
+
+func TestGenerateNginxCfgForVirtualServer(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	vsEx := createCafeVirtualServerEx()
+	cfg := NewDefaultConfig()
+
+	teaUpstream := Upstream{
+		Name:     "vs_default_cafe_tea",
+		LBMethod: cfg.LBMethod,
+		UpstreamServers: []UpstreamServer{
+			{
+				Address:     "10.0.0.20",
+				Port:        "80",
+				MaxFails:    cfg.MaxFails,
+				FailTimeout: cfg.FailTimeout,
+			},
+		},
+	}
+	coffeeUpstream := Upstream{
+		Name:     "vs_default_cafe_coffee",
+		LBMethod: "least_conn",
+		UpstreamServers: []UpstreamServer{
+			{
+				Address:     "10.0.0.30",
+				Port:        "80",
+				MaxFails:    5,
+				FailTimeout: "30s",
+			},
+			{
+				Address:     "10.0.0.31",
+				Port:        "80",
+				MaxFails:    5,
+				FailTimeout: "30s",
+			},
+		},
+	}
+
+	expected := IngressNginxConfig{
+		Upstreams: []Upstream{coffeeUpstream, teaUpstream},
+		Servers: []Server{
+			{
+				Name:         "cafe.example.com",
+				ServerTokens: cfg.ServerTokens,
+				StatusZone:   "cafe.example.com",
+				SSL:          true,
+				SSLCertificates: []SSLCertificate{
+					{
+						Certificate: "/etc/nginx/secrets/default-cafe-secret",
+						Key:         "/etc/nginx/secrets/default-cafe-secret",
+					},
+				},
+				SSLRedirect: true,
+				HSTSMaxAge:  2592000,
+				Ports:       []int{80},
+				SSLPorts:    []int{443},
+				Locations: []Location{
+					createLocation("/tea", teaUpstream, cfg, false, "", false, false),
+					createLocation("/coffee", coffeeUpstream, cfg, false, "", false, false),
+				},
+			},
+		},
+		Ingress: Ingress{
+			Name:      "cafe",
+			Namespace: "default",
+		},
+	}
+
+	result := cnf.generateNginxCfgForVirtualServer(vsEx, "/etc/nginx/secrets/default-cafe-secret")
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("generateNginxCfgForVirtualServer returned \n%+v, but expected \n%+v", result, expected)
+	}
+}
+
+func TestGenerateNginxCfgForVirtualServerWithMissingTLSSecret(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	vsEx := createCafeVirtualServerEx()
+
+	pemFile := cnf.updateVirtualServerTLSSecret(vsEx)
+	if pemFile != pemFileNameForMissingTLSSecret {
+		t.Errorf("updateVirtualServerTLSSecret returned %q, but expected %q", pemFile, pemFileNameForMissingTLSSecret)
+	}
+
+	result := cnf.generateNginxCfgForVirtualServer(vsEx, pemFile)
+	if result.Servers[0].SSLCiphers != "NULL" {
+		t.Errorf("generateNginxCfgForVirtualServer returned SSLCiphers %q for a missing TLS Secret, but expected %q", result.Servers[0].SSLCiphers, "NULL")
+	}
+}
+
+func TestGenerateNginxCfgForVirtualServerWithoutEndpoints(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	// NGINX OSS
+	cnf.nginxAPI = nil
+
+	vsEx := createCafeVirtualServerEx()
+	vsEx.VirtualServer.Spec.TLS = nil
+	vsEx.Endpoints = map[string][]string{}
+
+	result := cnf.generateNginxCfgForVirtualServer(vsEx, cnf.updateVirtualServerTLSSecret(vsEx))
+	for _, ups := range result.Upstreams {
+		expected := NewUpstreamWithDefaultServer(ups.Name).UpstreamServers
+		if !reflect.DeepEqual(ups.UpstreamServers, expected) {
+			t.Errorf("generateNginxCfgForVirtualServer returned upstream servers %+v for upstream %v, but expected %+v", ups.UpstreamServers, ups.Name, expected)
+		}
+	}
+	if result.Servers[0].SSL {
+		t.Errorf("generateNginxCfgForVirtualServer enabled SSL for a VirtualServer without TLS")
+	}
+}
+
+func TestAddOrUpdateAndDeleteVirtualServer(t *testing.T) {
+	cnf, err := createTestConfigurator()
+	if err != nil {
+		t.Fatalf("Failed to create a test configurator: %v", err)
+	}
+	vsEx := createCafeVirtualServerEx()
+
+	if cnf.HasVirtualServer(vsEx.VirtualServer) {
+		t.Errorf("HasVirtualServer returned true before the VirtualServer was added")
+	}
+
+	err = cnf.AddOrUpdateVirtualServer(vsEx)
+	if err != nil {
+		t.Errorf("AddOrUpdateVirtualServer returned an error: %v", err)
+	}
+	if !cnf.HasVirtualServer(vsEx.VirtualServer) {
+		t.Errorf("HasVirtualServer returned false after the VirtualServer was added")
+	}
+
+	err = cnf.DeleteVirtualServer("default/cafe")
+	if err != nil {
+		t.Errorf("DeleteVirtualServer returned an error: %v", err)
+	}
+	if cnf.HasVirtualServer(vsEx.VirtualServer) {
+		t.Errorf("HasVirtualServer returned true after the VirtualServer was deleted")
+	}
+}
+
+func TestGetFileNameForVirtualServer(t *testing.T) {
+	vs := &conf_v1alpha1.VirtualServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe",
+			Namespace: "default",
+		},
+	}
+	expected := "vs_default_cafe"
+
+	result := getFileNameForVirtualServer(vs)
+	if result != expected {
+		t.Errorf("getFileNameForVirtualServer returned %q, but expected %q", result, expected)
+	}
+
+	result = getFileNameForVirtualServerFromKey("default/cafe")
+	if result != expected {
+		t.Errorf("getFileNameForVirtualServerFromKey returned %q, but expected %q", result, expected)
+	}
+}
@@ -19,11 +19,15 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
 
 	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/validation"
+	conf_client "github.com/nginxinc/kubernetes-ingress/pkg/client/configuration/v1alpha1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -49,42 +53,59 @@ const (
 // LoadBalancerController watches Kubernetes API and
 // reconfigures NGINX via NginxController when needed
 type LoadBalancerController struct {
-	client                  kubernetes.Interface
-	ingressController       cache.Controller
-	svcController           cache.Controller
-	endpointController      cache.Controller
-	configMapController     cache.Controller
-	secretController        cache.Controller
-	ingressLister           storeToIngressLister
-	svcLister               cache.Store
-	endpointLister          storeToEndpointLister
-	configMapLister         storeToConfigMapLister
-	secretLister            storeToSecretLister
-	syncQueue               *taskQueue
-	ctx                     context.Context
-	cancel                  context.CancelFunc
-	configurator            *configs.Configurator
-	watchNginxConfigMaps    bool
-	isNginxPlus             bool
-	recorder                record.EventRecorder
-	defaultServerSecret     string
-	ingressClass            string
-	useIngressClassOnly     bool
-	statusUpdater           *statusUpdater
-	leaderElector           *leaderelection.LeaderElector
-	reportIngressStatus     bool
-	isLeaderElectionEnabled bool
-	resync                  time.Duration
-	namespace               string
-	controllerNamespace     string
-	wildcardTLSSecret       string
+	client                    kubernetes.Interface
+	confClient                *conf_client.ConfigurationV1alpha1Client
+	ingressController         cache.Controller
+	svcController             cache.Controller
+	endpointController        cache.Controller
+	configMapController       cache.Controller
+	secretController          cache.Controller
+	virtualServerController   cache.Controller
+	transportServerController cache.Controller
+	ingressLister             storeToIngressLister
+	svcLister                 cache.Store
+	endpointLister            storeToEndpointLister
+	configMapLister           storeToConfigMapLister
+	secretLister              storeToSecretLister
+	virtualServerLister       cache.Store
+	transportServerLister     cache.Store
+	syncQueue                 *taskQueue
+	ctx                       context.Context
+	cancel                    context.CancelFunc
+	configurator              *configs.Configurator
+	watchNginxConfigMaps      bool
+	isNginxPlus               bool
+	recorder                  record.EventRecorder
+	defaultServerSecret       string
+	ingressClass              string
+	useIngressClassOnly       bool
+	statusUpdater             *statusUpdater
+	leaderElector             *leaderelection.LeaderElector
+	reportIngressStatus       bool
+	isLeaderElectionEnabled   bool
+	resync                    time.Duration
+	namespace                 string
+	controllerNamespace       string
+	wildcardTLSSecret         string
+	customResourcesEnabled    bool
+	defaultBackendService     string
+	// certificateExpiryWarningDays is the number of days before the expiration of a certificate when the Ingress
+	// resources that reference its Secret get Warning events. Zero disables the events
+	certificateExpiryWarningDays int
+	transportServerReservedPorts []int
+	// reloadBatch holds the resources added or updated during the current batch of changes. It is nil outside of a batch
+	reloadBatch *reloadBatchResources
 }
 
 var keyFunc = cache.DeletionHandlingMetaNamespaceKeyFunc
 
+// certificateExpiryCheckPeriod is the period of the checks of the expiration of the certificates of the Ingress resources
+const certificateExpiryCheckPeriod = 24 * time.Hour
+
 // NewLoadBalancerControllerInput holds the input needed to call NewLoadBalancerController.
 type NewLoadBalancerControllerInput struct {
 	KubeClient              kubernetes.Interface
+	ConfClient              *conf_client.ConfigurationV1alpha1Client
 	ResyncPeriod            time.Duration
 	Namespace               string
 	NginxConfigurator       *configs.Configurator
@@ -98,12 +119,25 @@ type NewLoadBalancerControllerInput struct {
 	IsLeaderElectionEnabled bool
 	WildcardTLSSecret       string
 	ConfigMaps              string
+	CustomResourcesEnabled  bool
+	DefaultBackendService   string
+
+	CertificateExpiryWarningDays int
+	// TransportServerReservedPorts are the TCP ports that the listeners of the TransportServers must not use, because
+	// the Ingress Controller already uses them. The ports of the http context of NGINX are reserved too.
+	TransportServerReservedPorts []int
+	// ReloadBatchInterval enables the batches of changes if greater than zero: the changes that arrive within
+	// the interval after the previous change are applied with a single reload of NGINX, which happens no later than
+	// ReloadBatchMaxLatency after the first change of the batch
+	ReloadBatchInterval   time.Duration
+	ReloadBatchMaxLatency time.Duration
 }
 
 // NewLoadBalancerController creates a controller
 func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalancerController {
 	lbc := &LoadBalancerController{
 		client:                  input.KubeClient,
+		confClient:              input.ConfClient,
 		configurator:            input.NginxConfigurator,
 		defaultServerSecret:     input.DefaultServerSecret,
 		isNginxPlus:             input.IsNginxPlus,
@@ -115,6 +149,18 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 		namespace:               input.Namespace,
 		controllerNamespace:     input.ControllerNamespace,
 		wildcardTLSSecret:       input.WildcardTLSSecret,
+		customResourcesEnabled:  input.CustomResourcesEnabled,
+		defaultBackendService:   input.DefaultBackendService,
+
+		certificateExpiryWarningDays: input.CertificateExpiryWarningDays,
+		transportServerReservedPorts: input.TransportServerReservedPorts,
+	}
+
+	if input.CustomResourcesEnabled {
+		// the event recorder needs to know about the custom resources to be able to emit events for them
+		if err := conf_v1alpha1.AddToScheme(scheme.Scheme); err != nil {
+			glog.Errorf("Error adding the custom resources to the scheme: %v", err)
+		}
 	}
 
 	eventBroadcaster := record.NewBroadcaster()
@@ -125,7 +171,16 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 	lbc.recorder = eventBroadcaster.NewRecorder(scheme.Scheme,
 		api_v1.EventSource{Component: "nginx-ingress-controller"})
 
-	lbc.syncQueue = newTaskQueue(lbc.sync)
+	if input.ReloadBatchInterval > 0 {
+		lbc.syncQueue = newTaskQueueWithBatches(lbc.sync, taskBatchConfig{
+			interval:   input.ReloadBatchInterval,
+			maxLatency: input.ReloadBatchMaxLatency,
+			start:      lbc.startReloadBatch,
+			end:        lbc.endReloadBatch,
+		})
+	} else {
+		lbc.syncQueue = newTaskQueue(lbc.sync)
+	}
 
 	glog.V(3).Infof("Nginx Ingress Controller has class: %v", input.IngressClass)
 
@@ -143,6 +198,11 @@ func NewLoadBalancerController(input NewLoadBalancerControllerInput) *LoadBalanc
 	lbc.addServiceHandler(createServiceHandlers(lbc))
 	lbc.addEndpointHandler(createEndpointHandlers(lbc))
 
+	if lbc.customResourcesEnabled {
+		lbc.addVirtualServerHandler(createVirtualServerHandlers(lbc))
+		lbc.addTransportServerHandler(createTransportServerHandlers(lbc))
+	}
+
 	if input.ConfigMaps != "" {
 		nginxConfigMapsNS, nginxConfigMapsName, err := ParseNamespaceName(input.ConfigMaps)
 		if err != nil {
@@ -249,6 +309,34 @@ func (lbc *LoadBalancerController) addConfigMapHandler(handlers cache.ResourceEv
 	)
 }
 
+// addVirtualServerHandler adds the handler for VirtualServers to the controller
+func (lbc *LoadBalancerController) addVirtualServerHandler(handlers cache.ResourceEventHandlerFuncs) {
+	lbc.virtualServerLister, lbc.virtualServerController = cache.NewInformer(
+		cache.NewListWatchFromClient(
+			lbc.confClient.RESTClient(),
+			"virtualservers",
+			lbc.namespace,
+			fields.Everything()),
+		&conf_v1alpha1.VirtualServer{},
+		lbc.resync,
+		handlers,
+	)
+}
+
+// addTransportServerHandler adds the handler for TransportServers to the controller
+func (lbc *LoadBalancerController) addTransportServerHandler(handlers cache.ResourceEventHandlerFuncs) {
+	lbc.transportServerLister, lbc.transportServerController = cache.NewInformer(
+		cache.NewListWatchFromClient(
+			lbc.confClient.RESTClient(),
+			"transportservers",
+			lbc.namespace,
+			fields.Everything()),
+		&conf_v1alpha1.TransportServer{},
+		lbc.resync,
+		handlers,
+	)
+}
+
 // Run starts the loadbalancer controller
 func (lbc *LoadBalancerController) Run() {
 	lbc.ctx, lbc.cancel = context.WithCancel(context.Background())
@@ -263,7 +351,14 @@ func (lbc *LoadBalancerController) Run() {
 		go lbc.configMapController.Run(lbc.ctx.Done())
 	}
 	go lbc.ingressController.Run(lbc.ctx.Done())
+	if lbc.customResourcesEnabled {
+		go lbc.virtualServerController.Run(lbc.ctx.Done())
+		go lbc.transportServerController.Run(lbc.ctx.Done())
+	}
 	go lbc.syncQueue.Run(time.Second, lbc.ctx.Done())
+	if lbc.certificateExpiryWarningDays > 0 {
+		go lbc.runCertificateExpiryCheck()
+	}
 	<-lbc.ctx.Done()
 }
 
@@ -284,6 +379,20 @@ func (lbc *LoadBalancerController) syncEndpoint(task task) {
 		return
 	}
 
+	if key == lbc.defaultBackendService {
+		lbc.syncDefaultBackend()
+	}
+
+	if lbc.customResourcesEnabled {
+		namespace, name, err := ParseNamespaceName(key)
+		if err != nil {
+			glog.Warningf("Endpoints key %v is invalid: %v", key, err)
+		} else {
+			lbc.enqueueVirtualServers(lbc.getVirtualServersForService(namespace, name))
+			lbc.enqueueTransportServers(lbc.getTransportServersForService(namespace, name))
+		}
+	}
+
 	if endpExists {
 		ings := lbc.getIngressForEndpoints(obj)
 
@@ -328,6 +437,7 @@ func (lbc *LoadBalancerController) syncEndpoint(task task) {
 			err = lbc.configurator.UpdateEndpoints(ingExes)
 			if err != nil {
 				glog.Errorf("Error updating endpoints for %v: %v", ingExes, err)
+				lbc.emitEventForIngressErrors(err, "the endpoints change", ingExes)
 			}
 		}
 
@@ -336,11 +446,100 @@ func (lbc *LoadBalancerController) syncEndpoint(task task) {
 			err = lbc.configurator.UpdateEndpointsMergeableIngress(mergableIngressesSlice)
 			if err != nil {
 				glog.Errorf("Error updating endpoints for %v: %v", mergableIngressesSlice, err)
+				for _, mergeableIng := range mergableIngressesSlice {
+					lbc.emitEventForIngressErrors(err, "the endpoints change", append([]*configs.IngressEx{mergeableIng.Master}, mergeableIng.Minions...))
+				}
 			}
 		}
 	}
 }
 
+// syncDefaultBackend updates the endpoints of the default backend Service in NGINX configuration
+func (lbc *LoadBalancerController) syncDefaultBackend() {
+	endps, err := lbc.getEndpointsForDefaultBackend()
+	if err != nil {
+		glog.Warningf("Error getting the endpoints of the default backend service %v: %v", lbc.defaultBackendService, err)
+	}
+
+	ingresses, mergeableIngresses := lbc.GetManagedIngresses()
+	ingExes := lbc.ingressesToIngressExes(ingresses)
+
+	err = lbc.configurator.UpdateDefaultBackend(endps, ingExes, mergeableIngresses)
+	if err != nil {
+		glog.Errorf("Error updating the default backend service %v: %v", lbc.defaultBackendService, err)
+		lbc.emitEventForIngressErrors(err, "the default backend change", ingExes)
+		for _, mergeableIng := range mergeableIngresses {
+			lbc.emitEventForIngressErrors(err, "the default backend change", append([]*configs.IngressEx{mergeableIng.Master}, mergeableIng.Minions...))
+		}
+	}
+}
+
+// emitEventForIngressErrors emits a warning event for every Ingress resource, whose configuration wasn't updated
+// because of the error of an update of many resources
+func (lbc *LoadBalancerController) emitEventForIngressErrors(err error, reason string, ingExes []*configs.IngressEx) {
+	for _, ingEx := range ingExes {
+		if ingErr := getIngressError(err, ingEx.Ingress); ingErr != nil {
+			lbc.recorder.Eventf(ingEx.Ingress, api_v1.EventTypeWarning, "UpdatedWithError", "Configuration for %v/%v was updated due to %s, but not applied: %v",
+				ingEx.Ingress.Namespace, ingEx.Ingress.Name, reason, ingErr)
+		}
+	}
+}
+
+// getIngressError returns the error of the Ingress resource from the error of an update of many resources.
+// If only the configuration of some resources wasn't updated, the error is nil for the other resources.
+func getIngressError(err error, ing *extensions.Ingress) error {
+	if resErrs, ok := err.(*configs.ResourceErrors); ok {
+		return resErrs.IngressError(ing)
+	}
+	return err
+}
+
+// getVirtualServerError is the same as getIngressError for the VirtualServer resources
+func getVirtualServerError(err error, vs *conf_v1alpha1.VirtualServer) error {
+	if resErrs, ok := err.(*configs.ResourceErrors); ok {
+		return resErrs.VirtualServerError(vs)
+	}
+	return err
+}
+
+// getUpdateEventDetails returns the type, the title and the warning message of the event about the update of
+// the configuration of a resource
+func getUpdateEventDetails(err error) (eventType string, eventTitle string, eventWarningMessage string) {
+	if err != nil {
+		return api_v1.EventTypeWarning, "UpdatedWithError", fmt.Sprintf("but was not applied: %v", err)
+	}
+	return api_v1.EventTypeNormal, "Updated", ""
+}
+
+// getEndpointsForDefaultBackend returns the endpoints of the first port of the default backend Service
+func (lbc *LoadBalancerController) getEndpointsForDefaultBackend() ([]string, error) {
+	obj, exists, err := lbc.svcLister.GetByKey(lbc.defaultBackendService)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("service doesn't exist")
+	}
+
+	svc := obj.(*api_v1.Service)
+	if len(svc.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("service has no ports")
+	}
+
+	backend := &extensions.IngressBackend{
+		ServiceName: svc.Name,
+		ServicePort: intstr.FromInt(int(svc.Spec.Ports[0].Port)),
+	}
+	endps, isExternal, err := lbc.getEndpointsForIngressBackend(backend, svc.Namespace, svc)
+	if err != nil {
+		return nil, err
+	}
+	if isExternal {
+		return nil, fmt.Errorf("services of type ExternalName are not supported")
+	}
+	return endps, nil
+}
+
 func (lbc *LoadBalancerController) syncConfig(task task) {
 	key := task.Key
 	glog.V(3).Infof("Syncing configmap %v", key)
@@ -369,33 +568,54 @@ func (lbc *LoadBalancerController) syncConfig(task task) {
 		}
 	}
 
-	updateErr := lbc.configurator.UpdateConfig(cfg, ingExes, mergeableIngresses)
+	var virtualServerExes []*configs.VirtualServerEx
+	var transportServerExes []*configs.TransportServerEx
+	if lbc.customResourcesEnabled {
+		virtualServerExes = lbc.getManagedVirtualServerExes()
+		transportServerExes = lbc.getManagedTransportServerExes()
+	}
 
-	eventTitle := "Updated"
-	eventType := api_v1.EventTypeNormal
-	eventWarningMessage := ""
+	updateErr := lbc.configurator.UpdateConfig(cfg, ingExes, mergeableIngresses, virtualServerExes, transportServerExes)
+
+	// the errors of some resources don't prevent the configuration of the ConfigMap and of the other resources from being applied
+	resErrs, isResourceErrors := updateErr.(*configs.ResourceErrors)
 
-	if updateErr != nil {
-		eventTitle = "UpdatedWithError"
-		eventType = api_v1.EventTypeWarning
-		eventWarningMessage = fmt.Sprintf("but was not applied: %v", updateErr)
-	}
 	if configExists {
 		cfgm := obj.(*api_v1.ConfigMap)
-		lbc.recorder.Eventf(cfgm, eventType, eventTitle, "Configuration from %v was updated %s", key, eventWarningMessage)
+		if isResourceErrors {
+			lbc.recorder.Eventf(cfgm, api_v1.EventTypeWarning, "UpdatedWithError", "Configuration from %v was updated, but was not applied to some resources: %v", key, resErrs)
+		} else {
+			eventType, eventTitle, eventWarningMessage := getUpdateEventDetails(updateErr)
+			lbc.recorder.Eventf(cfgm, eventType, eventTitle, "Configuration from %v was updated %s", key, eventWarningMessage)
+		}
 	}
 	for _, ingEx := range ingExes {
+		eventType, eventTitle, eventWarningMessage := getUpdateEventDetails(getIngressError(updateErr, ingEx.Ingress))
 		lbc.recorder.Eventf(ingEx.Ingress, eventType, eventTitle, "Configuration for %v/%v was updated %s",
 			ingEx.Ingress.Namespace, ingEx.Ingress.Name, eventWarningMessage)
 	}
 	for _, mergeableIng := range mergeableIngresses {
 		master := mergeableIng.Master
+		eventType, eventTitle, eventWarningMessage := getUpdateEventDetails(getIngressError(updateErr, master.Ingress))
 		lbc.recorder.Eventf(master.Ingress, eventType, eventTitle, "Configuration for %v/%v(Master) was updated %s", master.Ingress.Namespace, master.Ingress.Name, eventWarningMessage)
 		for _, minion := range mergeableIng.Minions {
 			lbc.recorder.Eventf(minion.Ingress, eventType, eventTitle, "Configuration for %v/%v(Minion) was updated %s",
 				minion.Ingress.Namespace, minion.Ingress.Name, eventWarningMessage)
 		}
 	}
+	for _, vsEx := range virtualServerExes {
+		eventType, eventTitle, eventWarningMessage := getUpdateEventDetails(getVirtualServerError(updateErr, vsEx.VirtualServer))
+		lbc.recorder.Eventf(vsEx.VirtualServer, eventType, eventTitle, "Configuration for %v was updated %s", vsEx, eventWarningMessage)
+	}
+	// the configuration of the TransportServers is in the main config file, which is updated even if some resources fail
+	tsUpdateErr := updateErr
+	if isResourceErrors {
+		tsUpdateErr = nil
+	}
+	for _, tsEx := range transportServerExes {
+		eventType, eventTitle, eventWarningMessage := getUpdateEventDetails(tsUpdateErr)
+		lbc.recorder.Eventf(tsEx.TransportServer, eventType, eventTitle, "Configuration for %v was updated %s", tsEx, eventWarningMessage)
+	}
 }
 
 // GetManagedIngresses gets Ingress resources that the IC is currently responsible for
@@ -450,13 +670,27 @@ func (lbc *LoadBalancerController) ingressesToIngressExes(ings []extensions.Ingr
 func (lbc *LoadBalancerController) sync(task task) {
 	glog.V(3).Infof("Syncing %v", task.Key)
 
+	// the configuration restored by a rollback is kept until a resource changes. The changes of the Endpoints are
+	// postponed until the pin expires, so that the upstreams don't keep the removed endpoints for long.
+	if lbc.configurator.IsConfigPinned() {
+		if timeLeft := lbc.configurator.GetConfigPinTimeLeft(); task.Kind == endpoints && timeLeft > 0 {
+			lbc.syncQueue.RequeueAfter(task, fmt.Errorf("The configuration restored by a rollback is pinned"), timeLeft)
+			return
+		}
+		lbc.unpinConfig()
+	}
+
 	switch task.Kind {
 	case ingress:
+		httpPorts := lbc.configurator.GetHTTPListenPorts()
 		lbc.syncIng(task)
+		lbc.enqueueTransportServersForChangedPorts(httpPorts)
 	case ingressMinion:
 		lbc.syncIngMinion(task)
 	case configMap:
+		httpPorts := lbc.configurator.GetHTTPListenPorts()
 		lbc.syncConfig(task)
+		lbc.enqueueTransportServersForChangedPorts(httpPorts)
 		return
 	case endpoints:
 		lbc.syncEndpoint(task)
@@ -466,6 +700,120 @@ func (lbc *LoadBalancerController) sync(task task) {
 		return
 	case service:
 		lbc.syncExternalService(task)
+	case virtualserver:
+		lbc.syncVirtualServer(task)
+	case transportserver:
+		lbc.syncTransportServer(task)
+	}
+}
+
+// unpinConfig ends the pinning of the configuration restored by a rollback and generates the configuration
+// of all the resources from their current state
+func (lbc *LoadBalancerController) unpinConfig() {
+	glog.Infof("Unpinning the configuration restored by a rollback")
+
+	ingresses, mergeableIngresses := lbc.GetManagedIngresses()
+	ingExes := lbc.ingressesToIngressExes(ingresses)
+
+	var virtualServerExes []*configs.VirtualServerEx
+	var transportServerExes []*configs.TransportServerEx
+	if lbc.customResourcesEnabled {
+		virtualServerExes = lbc.getManagedVirtualServerExes()
+		transportServerExes = lbc.getManagedTransportServerExes()
+	}
+
+	if err := lbc.configurator.UnpinConfig(ingExes, mergeableIngresses, virtualServerExes, transportServerExes); err != nil {
+		glog.Errorf("Error generating the configuration after a rollback: %v", err)
+	}
+
+	if lbc.defaultBackendService != "" {
+		lbc.syncDefaultBackend()
+	}
+}
+
+// reloadBatchResources holds the keys of the resources whose configuration was added or updated during a batch of changes.
+// The start and the end of a batch and the sync function run in the same goroutine of the task queue.
+type reloadBatchResources struct {
+	ingresses        map[string]bool
+	virtualServers   map[string]bool
+	transportServers map[string]bool
+}
+
+func (b *reloadBatchResources) addIngress(ing *extensions.Ingress) {
+	if b != nil {
+		b.ingresses[ing.Namespace+"/"+ing.Name] = true
+	}
+}
+
+func (b *reloadBatchResources) addVirtualServer(vs *conf_v1alpha1.VirtualServer) {
+	if b != nil {
+		b.virtualServers[vs.Namespace+"/"+vs.Name] = true
+	}
+}
+
+func (b *reloadBatchResources) addTransportServer(ts *conf_v1alpha1.TransportServer) {
+	if b != nil {
+		b.transportServers[ts.Namespace+"/"+ts.Name] = true
+	}
+}
+
+// startReloadBatch starts a batch of changes and the recording of its resources
+func (lbc *LoadBalancerController) startReloadBatch() {
+	lbc.reloadBatch = &reloadBatchResources{
+		ingresses:        make(map[string]bool),
+		virtualServers:   make(map[string]bool),
+		transportServers: make(map[string]bool),
+	}
+	lbc.configurator.StartReloadBatch()
+}
+
+// endReloadBatch applies the changes of a batch. The resources of the batch have already got the events of the successful
+// updates, so if the reload fails, they get Warning events and the VirtualServers and TransportServers become invalid.
+func (lbc *LoadBalancerController) endReloadBatch() {
+	batch := lbc.reloadBatch
+	lbc.reloadBatch = nil
+
+	if err := lbc.configurator.EndReloadBatch(); err != nil {
+		glog.Errorf("Error reloading NGINX at the end of a batch of changes: %v", err)
+		lbc.emitEventsForReloadBatchError(batch, err)
+	}
+}
+
+func (lbc *LoadBalancerController) emitEventsForReloadBatchError(batch *reloadBatchResources, err error) {
+	if batch == nil {
+		return
+	}
+
+	reason := "AddedOrUpdatedWithError"
+
+	for key := range batch.ingresses {
+		obj, exists, _ := lbc.ingressLister.Store.GetByKey(key)
+		if !exists {
+			continue
+		}
+		lbc.recorder.Eventf(obj.(*extensions.Ingress), api_v1.EventTypeWarning, reason, "Configuration for %v was added or updated, but not applied: %v", key, err)
+	}
+
+	for key := range batch.virtualServers {
+		obj, exists, _ := lbc.virtualServerLister.GetByKey(key)
+		if !exists {
+			continue
+		}
+		vs := obj.(*conf_v1alpha1.VirtualServer)
+		msg := fmt.Sprintf("Configuration for %v was added or updated, but not applied: %v", key, err)
+		lbc.recorder.Event(vs, api_v1.EventTypeWarning, reason, msg)
+		lbc.updateVirtualServerStatus(vs, conf_v1alpha1.StateInvalid, reason, msg)
+	}
+
+	for key := range batch.transportServers {
+		obj, exists, _ := lbc.transportServerLister.GetByKey(key)
+		if !exists {
+			continue
+		}
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		msg := fmt.Sprintf("Configuration for %v was added or updated, but not applied: %v", key, err)
+		lbc.recorder.Event(ts, api_v1.EventTypeWarning, reason, msg)
+		lbc.updateTransportServerStatus(ts, conf_v1alpha1.StateInvalid, reason, msg)
 	}
 }
 
@@ -491,6 +839,16 @@ func (lbc *LoadBalancerController) syncIngMinion(task task) {
 		return
 	}
 
+	// invalid annotations can only be fixed by updating the minion, so there is no need to requeue it
+	if err := validateIngressAnnotations(minion); err != nil {
+		lbc.recorder.Eventf(minion, api_v1.EventTypeWarning, "Rejected", "%v was rejected: %v", key, err)
+		if lbc.configurator.HasMinion(master, minion) {
+			// the master will be regenerated without the minion
+			lbc.syncQueue.Enqueue(master)
+		}
+		return
+	}
+
 	_, err = lbc.createIngress(minion)
 	if err != nil {
 		lbc.syncQueue.RequeueAfter(task, err, 5*time.Second)
@@ -517,6 +875,9 @@ func (lbc *LoadBalancerController) syncIng(task task) {
 		if err != nil {
 			glog.Errorf("Error when deleting configuration for %v: %v", key, err)
 		}
+
+		// the hosts of the deleted Ingress might have been requested by a rejected Ingress with TLS passthrough
+		lbc.enqueueTLSPassthroughIngresses(nil)
 	} else {
 		glog.V(2).Infof("Adding or Updating Ingress: %v\n", key)
 
@@ -547,11 +908,18 @@ func (lbc *LoadBalancerController) syncIng(task task) {
 				eventTitle = "AddedOrUpdatedWithError"
 				eventType = api_v1.EventTypeWarning
 				eventWarningMessage = fmt.Sprintf("but was not applied: %v", addErr)
+			} else {
+				lbc.reloadBatch.addIngress(ing)
+				for _, minion := range mergeableIngExs.Minions {
+					lbc.reloadBatch.addIngress(minion.Ingress)
+				}
 			}
 			lbc.recorder.Eventf(ing, eventType, eventTitle, "Configuration for %v(Master) was added or updated %s", key, eventWarningMessage)
 			for _, minion := range mergeableIngExs.Minions {
 				lbc.recorder.Eventf(ing, eventType, eventTitle, "Configuration for %v/%v(Minion) was added or updated %s", minion.Ingress.Namespace, minion.Ingress.Name, eventWarningMessage)
 			}
+			lbc.checkTLSSecrets(ing)
+			lbc.checkCertificateExpiry(ing)
 
 			if lbc.reportStatusEnabled() {
 				err = lbc.statusUpdater.UpdateMergableIngresses(mergeableIngExs)
@@ -562,6 +930,16 @@ func (lbc *LoadBalancerController) syncIng(task task) {
 			return
 		}
 		ingEx, err := lbc.createIngress(ing)
+		if err == nil {
+			if conflictingIng, host := lbc.findIngressWithSameTLSPassthroughHost(ing); conflictingIng != nil {
+				err = fmt.Errorf("host %v is already used by Ingress %v/%v with TLS passthrough", host, conflictingIng.Namespace, conflictingIng.Name)
+				if lbc.configurator.HasIngress(ing) {
+					if deleteErr := lbc.configurator.DeleteIngress(key); deleteErr != nil {
+						glog.Errorf("Error when deleting configuration for %v: %v", key, deleteErr)
+					}
+				}
+			}
+		}
 		if err != nil {
 			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "Rejected", "%v was rejected: %v", key, err)
 			if lbc.reportStatusEnabled() {
@@ -573,19 +951,94 @@ func (lbc *LoadBalancerController) syncIng(task task) {
 			return
 		}
 
-		err = lbc.configurator.AddOrUpdateIngress(ingEx)
-		if err != nil {
-			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "AddedOrUpdatedWithError", "Configuration for %v was added or updated, but not applied: %v", key, err)
+		addErr := lbc.configurator.AddOrUpdateIngress(ingEx)
+		if addErr != nil {
+			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "AddedOrUpdatedWithError", "Configuration for %v was added or updated, but not applied: %v", key, addErr)
 		} else {
 			lbc.recorder.Eventf(ing, api_v1.EventTypeNormal, "AddedOrUpdated", "Configuration for %v was added or updated", key)
+			lbc.reloadBatch.addIngress(ing)
 		}
+		lbc.checkTLSSecrets(ing)
+		lbc.checkCertificateExpiry(ing)
 		if lbc.reportStatusEnabled() {
 			err = lbc.statusUpdater.UpdateIngressStatus(*ing)
 			if err != nil {
 				glog.V(3).Infof("error updating ing status: %v", err)
 			}
 		}
+
+		// Ingresses with TLS passthrough that use the same hosts but have lower precedence must be rejected
+		if passthrough, _ := configs.ParseTLSPassthrough(ing); passthrough && addErr == nil {
+			lbc.enqueueTLSPassthroughIngresses(ing)
+		}
+	}
+}
+
+// getTLSPassthroughIngresses returns the valid Ingresses with TLS passthrough that the Ingress Controller handles
+func (lbc *LoadBalancerController) getTLSPassthroughIngresses() []*extensions.Ingress {
+	var result []*extensions.Ingress
+
+	ings, _ := lbc.ingressLister.List()
+	for i := range ings.Items {
+		ing := &ings.Items[i]
+		if !lbc.IsNginxIngress(ing) {
+			continue
+		}
+		if passthrough, err := configs.ParseTLSPassthrough(ing); err != nil || !passthrough {
+			continue
+		}
+		result = append(result, ing)
+	}
+
+	return result
+}
+
+// findIngressWithSameTLSPassthroughHost returns a valid Ingress with TLS passthrough that uses a host of the given
+// Ingress with TLS passthrough and takes precedence over it, along with the host
+func (lbc *LoadBalancerController) findIngressWithSameTLSPassthroughHost(ing *extensions.Ingress) (*extensions.Ingress, string) {
+	if passthrough, err := configs.ParseTLSPassthrough(ing); err != nil || !passthrough {
+		return nil, ""
+	}
+
+	for _, other := range lbc.getTLSPassthroughIngresses() {
+		if other.Namespace == ing.Namespace && other.Name == ing.Name {
+			continue
+		}
+		if !configs.HasTLSPassthroughPrecedence(other, ing) {
+			continue
+		}
+		if host := findSameIngressHost(ing, other); host != "" {
+			return other, host
+		}
+	}
+
+	return nil, ""
+}
+
+// enqueueTLSPassthroughIngresses enqueues the other Ingresses with TLS passthrough that share a host with the given
+// Ingress, as well as the Ingresses with TLS passthrough that are not present in NGINX configuration, because they
+// might have been rejected for a host that is free now. The given Ingress can be nil.
+func (lbc *LoadBalancerController) enqueueTLSPassthroughIngresses(ing *extensions.Ingress) {
+	for _, other := range lbc.getTLSPassthroughIngresses() {
+		if ing != nil && other.Namespace == ing.Namespace && other.Name == ing.Name {
+			continue
+		}
+		if !lbc.configurator.HasIngress(other) || (ing != nil && findSameIngressHost(ing, other) != "") {
+			lbc.syncQueue.Enqueue(other)
+		}
+	}
+}
+
+// findSameIngressHost returns a host that is used in the rules of both Ingresses or an empty string
+func findSameIngressHost(ing *extensions.Ingress, other *extensions.Ingress) string {
+	for _, rule := range ing.Spec.Rules {
+		for _, otherRule := range other.Spec.Rules {
+			if rule.Host == otherRule.Host {
+				return rule.Host
+			}
+		}
 	}
+	return ""
 }
 
 // syncExternalService does not sync all services.
@@ -629,118 +1082,335 @@ func (lbc *LoadBalancerController) reportStatusEnabled() bool {
 	return false
 }
 
-func (lbc *LoadBalancerController) syncSecret(task task) {
+func (lbc *LoadBalancerController) syncVirtualServer(task task) {
 	key := task.Key
-	obj, secrExists, err := lbc.secretLister.Store.GetByKey(key)
+	obj, vsExists, err := lbc.virtualServerLister.GetByKey(key)
 	if err != nil {
 		lbc.syncQueue.Requeue(task, err)
 		return
 	}
 
-	namespace, name, err := ParseNamespaceName(key)
-	if err != nil {
-		glog.Warningf("Secret key %v is invalid: %v", key, err)
-		return
-	}
+	if !vsExists {
+		glog.V(2).Infof("Deleting VirtualServer: %v\n", key)
 
-	ings, err := lbc.findIngressesForSecret(namespace, name)
-	if err != nil {
-		glog.Warningf("Failed to find Ingress resources for Secret %v: %v", key, err)
-		lbc.syncQueue.RequeueAfter(task, err, 5*time.Second)
+		err := lbc.configurator.DeleteVirtualServer(key)
+		if err != nil {
+			glog.Errorf("Error when deleting configuration for %v: %v", key, err)
+		}
+		return
 	}
 
-	glog.V(2).Infof("Found %v Ingresses with Secret %v", len(ings), key)
+	glog.V(2).Infof("Adding or Updating VirtualServer: %v\n", key)
 
-	if !secrExists {
-		glog.V(2).Infof("Deleting Secret: %v\n", key)
+	vs := obj.(*conf_v1alpha1.VirtualServer)
 
-		lbc.handleRegularSecretDeletion(key, ings)
-		if lbc.isSpecialSecret(key) {
-			glog.Warningf("A special TLS Secret %v was removed. Retaining the Secret.", key)
+	validationErr := validation.ValidateVirtualServer(vs, lbc.isNginxPlus)
+	if validationErr != nil {
+		if lbc.configurator.HasVirtualServer(vs) {
+			err := lbc.configurator.DeleteVirtualServer(key)
+			if err != nil {
+				glog.Errorf("Error when deleting configuration for %v: %v", key, err)
+			}
 		}
+
+		reason := "Rejected"
+		msg := fmt.Sprintf("VirtualServer %v is invalid and was rejected: %v", key, validationErr)
+
+		lbc.recorder.Event(vs, api_v1.EventTypeWarning, reason, msg)
+		lbc.updateVirtualServerStatus(vs, conf_v1alpha1.StateInvalid, reason, msg)
 		return
 	}
 
-	glog.V(2).Infof("Adding / Updating Secret: %v\n", key)
+	vsEx := lbc.createVirtualServer(vs)
 
-	secret := obj.(*api_v1.Secret)
+	addErr := lbc.configurator.AddOrUpdateVirtualServer(vsEx)
 
-	if lbc.isSpecialSecret(key) {
-		lbc.handleSpecialSecretUpdate(secret)
-		// we don't return here in case the special secret is also used in Ingress resources
-	}
+	// record correct eventType, message and status depending on the error
+	eventTitle := "AddedOrUpdated"
+	eventType := api_v1.EventTypeNormal
+	eventWarningMessage := ""
+	state := conf_v1alpha1.StateValid
 
-	if len(ings) > 0 {
-		lbc.handleSecretUpdate(secret, ings)
+	if addErr != nil {
+		eventTitle = "AddedOrUpdatedWithError"
+		eventType = api_v1.EventTypeWarning
+		eventWarningMessage = fmt.Sprintf("but was not applied: %v", addErr)
+		state = conf_v1alpha1.StateInvalid
+	} else {
+		lbc.reloadBatch.addVirtualServer(vs)
 	}
-}
 
-func (lbc *LoadBalancerController) isSpecialSecret(secretName string) bool {
-	return secretName == lbc.defaultServerSecret || secretName == lbc.wildcardTLSSecret
+	msg := fmt.Sprintf("Configuration for %v was added or updated %s", key, eventWarningMessage)
+	lbc.recorder.Event(vs, eventType, eventTitle, msg)
+	lbc.updateVirtualServerStatus(vs, state, eventTitle, msg)
 }
 
-func (lbc *LoadBalancerController) handleRegularSecretDeletion(key string, ings []extensions.Ingress) {
-	eventType := api_v1.EventTypeWarning
-	title := "Missing Secret"
-	message := fmt.Sprintf("Secret %v was removed", key)
-
-	lbc.emitEventForIngresses(eventType, title, message, ings)
-
-	regular, mergeable := lbc.createIngresses(ings)
-
-	eventType = api_v1.EventTypeNormal
-	title = "Updated"
-	message = fmt.Sprintf("Configuration was updated due to removed secret %v", key)
+// updateVirtualServerStatus updates the status subresource of the VirtualServer if the status has changed.
+func (lbc *LoadBalancerController) updateVirtualServerStatus(vs *conf_v1alpha1.VirtualServer, state string, reason string, message string) {
+	if !lbc.reportCustomResourceStatusEnabled() {
+		return
+	}
 
-	if err := lbc.configurator.DeleteSecret(key, regular, mergeable); err != nil {
-		glog.Errorf("Error when deleting Secret: %v: %v", key, err)
+	if vs.Status.State == state && vs.Status.Reason == reason && vs.Status.Message == message {
+		return
+	}
 
-		eventType = api_v1.EventTypeWarning
-		title = "UpdatedWithError"
-		message = fmt.Sprintf("Configuration was updated due to removed secret %v, but not applied: %v", key, err)
+	vsCopy := vs.DeepCopy()
+	vsCopy.Status = conf_v1alpha1.VirtualServerStatus{
+		State:   state,
+		Reason:  reason,
+		Message: message,
 	}
 
-	lbc.emitEventForIngresses(eventType, title, message, ings)
+	_, err := lbc.confClient.VirtualServers(vsCopy.Namespace).UpdateStatus(vsCopy)
+	if err != nil {
+		glog.V(3).Infof("error updating VirtualServer %v/%v status: %v", vs.Namespace, vs.Name, err)
+	}
 }
 
-func (lbc *LoadBalancerController) handleSecretUpdate(secret *api_v1.Secret, ings []extensions.Ingress) {
-	secretNsName := secret.Namespace + "/" + secret.Name
-
-	err := lbc.ValidateSecret(secret)
+func (lbc *LoadBalancerController) syncTransportServer(task task) {
+	key := task.Key
+	obj, tsExists, err := lbc.transportServerLister.GetByKey(key)
 	if err != nil {
-		// Secret becomes Invalid
-		glog.Errorf("Couldn't validate secret %v: %v", secretNsName, err)
-		glog.Errorf("Removing invalid secret %v", secretNsName)
-
-		lbc.handleRegularSecretDeletion(secretNsName, ings)
-
-		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "Rejected", "%v was rejected: %v", secretNsName, err)
+		lbc.syncQueue.Requeue(task, err)
 		return
 	}
 
+	if !tsExists {
+		glog.V(2).Infof("Deleting TransportServer: %v\n", key)
+
+		err := lbc.configurator.DeleteTransportServer(key)
+		if err != nil {
+			glog.Errorf("Error when deleting configuration for %v: %v", key, err)
+		}
+
+		// the listener of the deleted TransportServer might have been requested by a rejected TransportServer
+		lbc.enqueueTransportServers(lbc.getUnmanagedTransportServers())
+		return
+	}
+
+	glog.V(2).Infof("Adding or Updating TransportServer: %v\n", key)
+
+	ts := obj.(*conf_v1alpha1.TransportServer)
+
+	validationErr := lbc.validateTransportServer(ts)
+	if validationErr == nil {
+		if conflictingTs := lbc.findTransportServerWithSameListener(ts); conflictingTs != nil {
+			validationErr = fmt.Errorf("listener %v/%v is already used by TransportServer %v/%v",
+				ts.Spec.Listener.Port, ts.Spec.Listener.Protocol, conflictingTs.Namespace, conflictingTs.Name)
+		}
+	}
+	if validationErr != nil {
+		if lbc.configurator.HasTransportServer(ts) {
+			err := lbc.configurator.DeleteTransportServer(key)
+			if err != nil {
+				glog.Errorf("Error when deleting configuration for %v: %v", key, err)
+			}
+		}
+
+		reason := "Rejected"
+		msg := fmt.Sprintf("TransportServer %v is invalid and was rejected: %v", key, validationErr)
+
+		lbc.recorder.Event(ts, api_v1.EventTypeWarning, reason, msg)
+		lbc.updateTransportServerStatus(ts, conf_v1alpha1.StateInvalid, reason, msg)
+		return
+	}
+
+	tsEx := lbc.createTransportServer(ts)
+
+	addErr := lbc.configurator.AddOrUpdateTransportServer(tsEx)
+
+	// record correct eventType, message and status depending on the error
+	eventTitle := "AddedOrUpdated"
 	eventType := api_v1.EventTypeNormal
-	title := "Updated"
-	message := fmt.Sprintf("Configuration was updated due to updated secret %v", secretNsName)
+	eventWarningMessage := ""
+	state := conf_v1alpha1.StateValid
+
+	if addErr != nil {
+		eventTitle = "AddedOrUpdatedWithError"
+		eventType = api_v1.EventTypeWarning
+		eventWarningMessage = fmt.Sprintf("but was not applied: %v", addErr)
+		state = conf_v1alpha1.StateInvalid
+	} else {
+		lbc.reloadBatch.addTransportServer(ts)
+	}
+
+	msg := fmt.Sprintf("Configuration for %v was added or updated %s", key, eventWarningMessage)
+	lbc.recorder.Event(ts, eventType, eventTitle, msg)
+	lbc.updateTransportServerStatus(ts, state, eventTitle, msg)
+
+	// TransportServers that use the same listener but have lower precedence must be rejected
+	for _, other := range lbc.getTransportServersWithListener(ts.Spec.Listener) {
+		if other.Namespace != ts.Namespace || other.Name != ts.Name {
+			lbc.syncQueue.Enqueue(other)
+		}
+	}
+}
+
+// updateTransportServerStatus updates the status subresource of the TransportServer if the status has changed.
+func (lbc *LoadBalancerController) updateTransportServerStatus(ts *conf_v1alpha1.TransportServer, state string, reason string, message string) {
+	if !lbc.reportCustomResourceStatusEnabled() {
+		return
+	}
+
+	if ts.Status.State == state && ts.Status.Reason == reason && ts.Status.Message == message {
+		return
+	}
+
+	tsCopy := ts.DeepCopy()
+	tsCopy.Status = conf_v1alpha1.TransportServerStatus{
+		State:   state,
+		Reason:  reason,
+		Message: message,
+	}
+
+	_, err := lbc.confClient.TransportServers(tsCopy.Namespace).UpdateStatus(tsCopy)
+	if err != nil {
+		glog.V(3).Infof("error updating TransportServer %v/%v status: %v", ts.Namespace, ts.Name, err)
+	}
+}
+
+// reportCustomResourceStatusEnabled determines if we should attempt to report status for the custom resources.
+// If leader election is enabled, only the leader reports status.
+func (lbc *LoadBalancerController) reportCustomResourceStatusEnabled() bool {
+	if lbc.isLeaderElectionEnabled && lbc.leaderElector != nil {
+		return lbc.leaderElector.IsLeader()
+	}
+	return true
+}
+
+func (lbc *LoadBalancerController) syncSecret(task task) {
+	key := task.Key
+	obj, secrExists, err := lbc.secretLister.Store.GetByKey(key)
+	if err != nil {
+		lbc.syncQueue.Requeue(task, err)
+		return
+	}
+
+	namespace, name, err := ParseNamespaceName(key)
+	if err != nil {
+		glog.Warningf("Secret key %v is invalid: %v", key, err)
+		return
+	}
+
+	ings, err := lbc.findIngressesForSecret(namespace, name)
+	if err != nil {
+		glog.Warningf("Failed to find Ingress resources for Secret %v: %v", key, err)
+		lbc.syncQueue.RequeueAfter(task, err, 5*time.Second)
+	}
+
+	glog.V(2).Infof("Found %v Ingresses with Secret %v", len(ings), key)
+
+	if lbc.customResourcesEnabled {
+		virtualServers := lbc.getVirtualServersForSecret(namespace, name)
+		glog.V(2).Infof("Found %v VirtualServers with Secret %v", len(virtualServers), key)
+		lbc.enqueueVirtualServers(virtualServers)
+	}
+
+	if !secrExists {
+		glog.V(2).Infof("Deleting Secret: %v\n", key)
+
+		lbc.handleRegularSecretDeletion(key, ings)
+		if lbc.isSpecialSecret(key) {
+			glog.Warningf("A special TLS Secret %v was removed. Retaining the Secret.", key)
+		}
+		return
+	}
+
+	glog.V(2).Infof("Adding / Updating Secret: %v\n", key)
+
+	secret := obj.(*api_v1.Secret)
+
+	if lbc.isSpecialSecret(key) {
+		lbc.handleSpecialSecretUpdate(secret)
+		// we don't return here in case the special secret is also used in Ingress resources
+	}
+
+	if len(ings) > 0 {
+		lbc.handleSecretUpdate(secret, ings)
+	}
+}
+
+func (lbc *LoadBalancerController) isSpecialSecret(secretName string) bool {
+	return secretName == lbc.defaultServerSecret || secretName == lbc.wildcardTLSSecret
+}
+
+func (lbc *LoadBalancerController) handleRegularSecretDeletion(key string, ings []extensions.Ingress) {
+	eventType := api_v1.EventTypeWarning
+	title := "Missing Secret"
+	message := fmt.Sprintf("Secret %v was removed", key)
+
+	lbc.emitEventForIngresses(eventType, title, message, ings)
 
 	regular, mergeable := lbc.createIngresses(ings)
 
-	if err := lbc.configurator.AddOrUpdateSecret(secret, regular, mergeable); err != nil {
+	err := lbc.configurator.DeleteSecret(key, regular, mergeable)
+	if err != nil {
+		glog.Errorf("Error when deleting Secret: %v: %v", key, err)
+	}
+
+	for i := range ings {
+		eventType = api_v1.EventTypeNormal
+		title = "Updated"
+		message = fmt.Sprintf("Configuration was updated due to removed secret %v", key)
+
+		if ingErr := getIngressError(err, &ings[i]); ingErr != nil {
+			eventType = api_v1.EventTypeWarning
+			title = "UpdatedWithError"
+			message = fmt.Sprintf("Configuration was updated due to removed secret %v, but not applied: %v", key, ingErr)
+		}
+
+		lbc.emitEventForIngresses(eventType, title, message, ings[i:i+1])
+	}
+}
+
+func (lbc *LoadBalancerController) handleSecretUpdate(secret *api_v1.Secret, ings []extensions.Ingress) {
+	secretNsName := secret.Namespace + "/" + secret.Name
+
+	err := lbc.ValidateSecret(secret)
+	if err != nil {
+		// Secret becomes Invalid
+		glog.Errorf("Couldn't validate secret %v: %v", secretNsName, err)
+		glog.Errorf("Removing invalid secret %v", secretNsName)
+
+		lbc.handleRegularSecretDeletion(secretNsName, ings)
+
+		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "Rejected", "%v was rejected: %v", secretNsName, err)
+		return
+	}
+
+	regular, mergeable := lbc.createIngresses(ings)
+
+	err = lbc.configurator.AddOrUpdateSecret(secret, regular, mergeable)
+	if err != nil {
 		glog.Errorf("Error when updating Secret %v: %v", secretNsName, err)
 		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "UpdatedWithError", "%v was updated, but not applied: %v", secretNsName, err)
-
-		eventType = api_v1.EventTypeWarning
-		title = "UpdatedWithError"
-		message = fmt.Sprintf("Configuration was updated due to updated secret %v, but not applied: %v", secretNsName, err)
 	}
 
-	lbc.emitEventForIngresses(eventType, title, message, ings)
+	for i := range ings {
+		eventType := api_v1.EventTypeNormal
+		title := "Updated"
+		message := fmt.Sprintf("Configuration was updated due to updated secret %v", secretNsName)
+
+		if ingErr := getIngressError(err, &ings[i]); ingErr != nil {
+			eventType = api_v1.EventTypeWarning
+			title = "UpdatedWithError"
+			message = fmt.Sprintf("Configuration was updated due to updated secret %v, but not applied: %v", secretNsName, ingErr)
+		}
+
+		lbc.emitEventForIngresses(eventType, title, message, ings[i:i+1])
+	}
 }
 
 func (lbc *LoadBalancerController) handleSpecialSecretUpdate(secret *api_v1.Secret) {
 	var specialSecretsToUpdate []string
 	secretNsName := secret.Namespace + "/" + secret.Name
 	err := configs.ValidateTLSSecret(secret)
-	if err != nil {
+	if configs.IsCertificateValidityError(err) {
+		// an expired certificate of the default server must not stop the updates of the special Secret
+		glog.Warningf("The special Secret %v has a certificate that is not valid now: %v", secretNsName, err)
+		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "CertificateNotValid", "the special Secret %v has a certificate that is not valid now: %v", secretNsName, err)
+	} else if err != nil {
 		glog.Errorf("Couldn't validate the special Secret %v: %v", secretNsName, err)
 		lbc.recorder.Eventf(secret, api_v1.EventTypeWarning, "Rejected", "the special Secret %v was rejected, using the previous version: %v", secretNsName, err)
 		return
@@ -765,7 +1435,7 @@ func (lbc *LoadBalancerController) handleSpecialSecretUpdate(secret *api_v1.Secr
 
 func (lbc *LoadBalancerController) emitEventForIngresses(eventType string, title string, message string, ings []extensions.Ingress) {
 	for _, ing := range ings {
-		lbc.recorder.Eventf(&ing, eventType, title, message)
+		lbc.recorder.Event(&ing, eventType, title, message)
 		if isMinion(&ing) {
 			master, err := lbc.FindMasterForMinion(&ing)
 			if err != nil {
@@ -773,7 +1443,7 @@ func (lbc *LoadBalancerController) emitEventForIngresses(eventType string, title
 				continue
 			}
 			masterMsg := fmt.Sprintf("%v for Minion %v/%v", message, ing.Namespace, ing.Name)
-			lbc.recorder.Eventf(master, eventType, title, masterMsg)
+			lbc.recorder.Event(master, eventType, title, masterMsg)
 		}
 	}
 }
@@ -809,6 +1479,7 @@ func (lbc *LoadBalancerController) createIngresses(ings []extensions.Ingress) (r
 		ingEx, err := lbc.createIngress(&ings[i])
 		if err != nil {
 			glog.Errorf("Ignoring Ingress %v/%v: $%v", ings[i].Namespace, ings[i].Name, err)
+			continue
 		}
 		regular = append(regular, *ingEx)
 	}
@@ -816,6 +1487,95 @@ func (lbc *LoadBalancerController) createIngresses(ings []extensions.Ingress) (r
 	return regular, mergeable
 }
 
+// runCertificateExpiryCheck periodically checks the certificates of the Ingress resources, so that the Warning events
+// are emitted even if the Ingress resources and their Secrets don't change.
+func (lbc *LoadBalancerController) runCertificateExpiryCheck() {
+	ticker := time.NewTicker(certificateExpiryCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ings, err := lbc.ingressLister.List()
+			if err != nil {
+				glog.Errorf("Error checking the expiration of the certificates: couldn't get the list of Ingress resources: %v", err)
+				continue
+			}
+			for i := range ings.Items {
+				if lbc.IsNginxIngress(&ings.Items[i]) && !isMinion(&ings.Items[i]) {
+					lbc.checkCertificateExpiry(&ings.Items[i])
+				}
+			}
+		case <-lbc.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkTLSSecrets emits a Warning event for the Ingress for every TLS Secret that is not used because it is invalid
+// and for every host that is not covered by the certificate of its TLS Secret. The events are only emitted when the Ingress
+// is synced, because the configuration of the Ingress is also generated for the changes of the other resources.
+func (lbc *LoadBalancerController) checkTLSSecrets(ing *extensions.Ingress) {
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		secret, err := lbc.getAndValidateSecret(ing.Namespace + "/" + tls.SecretName)
+		if err != nil {
+			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "InvalidTLSSecret", "The TLS Secret %v of hosts %v is not used: %v",
+				tls.SecretName, strings.Join(tls.Hosts, ","), err)
+			continue
+		}
+
+		for _, host := range tls.Hosts {
+			if err := configs.ValidateTLSSecretHost(secret, host); err != nil {
+				lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "TLSHostMismatch", "The certificate of the TLS Secret %v is not used for host %v: %v",
+					tls.SecretName, host, err)
+			}
+		}
+	}
+}
+
+// checkCertificateExpiry emits a Warning event for the Ingress for every TLS Secret with a certificate that expires
+// in less than certificateExpiryWarningDays days or has expired.
+func (lbc *LoadBalancerController) checkCertificateExpiry(ing *extensions.Ingress) {
+	if lbc.certificateExpiryWarningDays <= 0 {
+		return
+	}
+
+	now := time.Now()
+	checked := make(map[string]bool)
+
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" || checked[tls.SecretName] {
+			continue
+		}
+		checked[tls.SecretName] = true
+
+		// the Secret is not validated, because a Secret with an expired certificate is invalid.
+		// Missing Secrets are reported when the configuration of the Ingress is generated
+		obj, exists, err := lbc.secretLister.GetByKey(ing.Namespace + "/" + tls.SecretName)
+		if err != nil || !exists {
+			continue
+		}
+		cert, err := configs.ParseTLSSecretCertificate(obj.(*api_v1.Secret))
+		if err != nil {
+			glog.Warningf("Error parsing the certificate of the Secret %v for Ingress %v: %v", tls.SecretName, ing.Name, err)
+			continue
+		}
+
+		daysLeft := int(cert.NotAfter.Sub(now).Hours() / 24)
+		if !now.Before(cert.NotAfter) {
+			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "CertificateExpired", "The certificate of the Secret %v expired on %v",
+				tls.SecretName, cert.NotAfter.UTC().Format(time.RFC3339))
+		} else if daysLeft < lbc.certificateExpiryWarningDays {
+			lbc.recorder.Eventf(ing, api_v1.EventTypeWarning, "CertificateExpiring", "The certificate of the Secret %v expires on %v, in %v days",
+				tls.SecretName, cert.NotAfter.UTC().Format(time.RFC3339), daysLeft)
+		}
+	}
+}
+
 func (lbc *LoadBalancerController) findIngressesForSecret(secretNamespace string, secretName string) (ings []extensions.Ingress, error error) {
 	allIngs, err := lbc.ingressLister.List()
 	if err != nil {
@@ -846,31 +1606,63 @@ items:
 				if jwtKey, exists := ing.Annotations[configs.JWTKeyAnnotation]; exists {
 					if jwtKey == secretName {
 						ings = append(ings, ing)
+						continue
 					}
 				}
 			}
+			if basicAuthSecret, exists := ing.Annotations[configs.BasicAuthSecretAnnotation]; exists {
+				if basicAuthSecret == secretName {
+					ings = append(ings, ing)
+					continue
+				}
+			}
+			if clientCASecret, exists := ing.Annotations[configs.ClientCASecretAnnotation]; exists {
+				if clientCASecret == secretName {
+					ings = append(ings, ing)
+					continue
+				}
+			}
+			if trustedCertSecret, exists := ing.Annotations[configs.TrustedCertSecretAnnotation]; exists {
+				if trustedCertSecret == secretName {
+					ings = append(ings, ing)
+					continue
+				}
+			}
+			if isUpstreamTLSSecret(&ing, secretName) {
+				ings = append(ings, ing)
+			}
 			continue
 		}
 
 		// we're dealing with a minion
-		// minions can only have JWT secrets
-		if lbc.isNginxPlus {
-			master, err := lbc.FindMasterForMinion(&ing)
-			if err != nil {
-				glog.Infof("Ignoring Ingress %v(Minion): %v", ing.Name, err)
-				continue
-			}
+		// minions can only have JWT, htpasswd and upstream TLS secrets
+		master, err := lbc.FindMasterForMinion(&ing)
+		if err != nil {
+			glog.Infof("Ignoring Ingress %v(Minion): %v", ing.Name, err)
+			continue
+		}
 
-			if !lbc.configurator.HasMinion(master, &ing) {
-				continue
-			}
+		if !lbc.configurator.HasMinion(master, &ing) {
+			continue
+		}
 
+		if lbc.isNginxPlus {
 			if jwtKey, exists := ing.Annotations[configs.JWTKeyAnnotation]; exists {
 				if jwtKey == secretName {
 					ings = append(ings, ing)
+					continue
 				}
 			}
 		}
+		if basicAuthSecret, exists := ing.Annotations[configs.BasicAuthSecretAnnotation]; exists {
+			if basicAuthSecret == secretName {
+				ings = append(ings, ing)
+				continue
+			}
+		}
+		if isUpstreamTLSSecret(&ing, secretName) {
+			ings = append(ings, ing)
+		}
 	}
 
 	return ings, nil
@@ -899,6 +1691,281 @@ func (lbc *LoadBalancerController) EnqueueIngressForService(svc *api_v1.Service)
 	}
 }
 
+// EnqueueVirtualServersForService enqueues the VirtualServers that reference the given service
+func (lbc *LoadBalancerController) EnqueueVirtualServersForService(svc *api_v1.Service) {
+	if !lbc.customResourcesEnabled {
+		return
+	}
+	lbc.enqueueVirtualServers(lbc.getVirtualServersForService(svc.Namespace, svc.Name))
+}
+
+func (lbc *LoadBalancerController) enqueueVirtualServers(virtualServers []*conf_v1alpha1.VirtualServer) {
+	for _, vs := range virtualServers {
+		lbc.syncQueue.Enqueue(vs)
+	}
+}
+
+func (lbc *LoadBalancerController) getVirtualServersForService(namespace string, name string) []*conf_v1alpha1.VirtualServer {
+	var result []*conf_v1alpha1.VirtualServer
+
+	for _, obj := range lbc.virtualServerLister.List() {
+		vs := obj.(*conf_v1alpha1.VirtualServer)
+		if vs.Namespace != namespace {
+			continue
+		}
+		for _, u := range vs.Spec.Upstreams {
+			if u.Service == name {
+				result = append(result, vs)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+func (lbc *LoadBalancerController) getVirtualServersForSecret(namespace string, name string) []*conf_v1alpha1.VirtualServer {
+	var result []*conf_v1alpha1.VirtualServer
+
+	for _, obj := range lbc.virtualServerLister.List() {
+		vs := obj.(*conf_v1alpha1.VirtualServer)
+		if vs.Namespace != namespace || vs.Spec.TLS == nil {
+			continue
+		}
+		if vs.Spec.TLS.Secret == name {
+			result = append(result, vs)
+		}
+	}
+
+	return result
+}
+
+// getManagedVirtualServerExes returns the VirtualServers that are currently present in NGINX configuration
+func (lbc *LoadBalancerController) getManagedVirtualServerExes() []*configs.VirtualServerEx {
+	var result []*configs.VirtualServerEx
+
+	for _, obj := range lbc.virtualServerLister.List() {
+		vs := obj.(*conf_v1alpha1.VirtualServer)
+		if !lbc.configurator.HasVirtualServer(vs) {
+			continue
+		}
+		result = append(result, lbc.createVirtualServer(vs))
+	}
+
+	return result
+}
+
+func (lbc *LoadBalancerController) createVirtualServer(virtualServer *conf_v1alpha1.VirtualServer) *configs.VirtualServerEx {
+	virtualServerEx := configs.VirtualServerEx{
+		VirtualServer: virtualServer,
+	}
+
+	if virtualServer.Spec.TLS != nil {
+		secretKey := virtualServer.Namespace + "/" + virtualServer.Spec.TLS.Secret
+		secret, err := lbc.getAndValidateSecret(secretKey)
+		if err != nil {
+			glog.Warningf("Error trying to get the secret %v for VirtualServer %v: %v", secretKey, virtualServer.Name, err)
+		} else {
+			virtualServerEx.TLSSecret = secret
+		}
+	}
+
+	endpoints := make(map[string][]string)
+	for _, u := range virtualServer.Spec.Upstreams {
+		endpointsKey := configs.GenerateEndpointsKey(virtualServer.Namespace, u.Service, u.Port)
+
+		endps, err := lbc.getEndpointsForUpstream(virtualServer.Namespace, u.Service, u.Port)
+		if err != nil {
+			glog.Warningf("Error getting Endpoints for Upstream %v of VirtualServer %v/%v: %v", u.Name, virtualServer.Namespace, virtualServer.Name, err)
+		}
+		// endps is empty if there was any error
+		endpoints[endpointsKey] = endps
+	}
+	virtualServerEx.Endpoints = endpoints
+
+	return &virtualServerEx
+}
+
+// EnqueueTransportServersForService enqueues the TransportServers that reference the given service
+func (lbc *LoadBalancerController) EnqueueTransportServersForService(svc *api_v1.Service) {
+	if !lbc.customResourcesEnabled {
+		return
+	}
+	lbc.enqueueTransportServers(lbc.getTransportServersForService(svc.Namespace, svc.Name))
+}
+
+func (lbc *LoadBalancerController) enqueueTransportServers(transportServers []*conf_v1alpha1.TransportServer) {
+	for _, ts := range transportServers {
+		lbc.syncQueue.Enqueue(ts)
+	}
+}
+
+func (lbc *LoadBalancerController) getTransportServersForService(namespace string, name string) []*conf_v1alpha1.TransportServer {
+	var result []*conf_v1alpha1.TransportServer
+
+	for _, obj := range lbc.transportServerLister.List() {
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		if ts.Namespace == namespace && ts.Spec.Upstream.Service == name {
+			result = append(result, ts)
+		}
+	}
+
+	return result
+}
+
+func (lbc *LoadBalancerController) getTransportServersWithListener(listener conf_v1alpha1.TransportServerListener) []*conf_v1alpha1.TransportServer {
+	var result []*conf_v1alpha1.TransportServer
+
+	for _, obj := range lbc.transportServerLister.List() {
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		if ts.Spec.Listener == listener {
+			result = append(result, ts)
+		}
+	}
+
+	return result
+}
+
+// findTransportServerWithSameListener returns a valid TransportServer that uses the same listener as the given TransportServer
+// and takes precedence over it. The oldest TransportServer takes precedence; if the creation timestamps are equal,
+// the TransportServer with the lexicographically smaller namespace/name wins.
+func (lbc *LoadBalancerController) findTransportServerWithSameListener(ts *conf_v1alpha1.TransportServer) *conf_v1alpha1.TransportServer {
+	for _, other := range lbc.getTransportServersWithListener(ts.Spec.Listener) {
+		if other.Namespace == ts.Namespace && other.Name == ts.Name {
+			continue
+		}
+		if lbc.validateTransportServer(other) != nil {
+			continue
+		}
+		if hasTransportServerPrecedence(other, ts) {
+			return other
+		}
+	}
+
+	return nil
+}
+
+// validateTransportServer validates the TransportServer and checks that its TCP listener doesn't use the ports of
+// the http context of NGINX or the reserved ports of the Ingress Controller. A UDP listener can use any port.
+func (lbc *LoadBalancerController) validateTransportServer(ts *conf_v1alpha1.TransportServer) error {
+	if err := validation.ValidateTransportServer(ts); err != nil {
+		return err
+	}
+
+	if ts.Spec.Listener.Protocol != conf_v1alpha1.ProtocolTCP {
+		return nil
+	}
+	for _, port := range lbc.transportServerReservedPorts {
+		if ts.Spec.Listener.Port == port {
+			return fmt.Errorf("port %v is used by the Ingress Controller", port)
+		}
+	}
+	for _, port := range lbc.configurator.GetHTTPListenPorts() {
+		if ts.Spec.Listener.Port == port {
+			return fmt.Errorf("port %v is used by the Ingress resources or the default server", port)
+		}
+	}
+
+	return nil
+}
+
+// enqueueTransportServersForChangedPorts enqueues the TCP TransportServers that use a port of the http context of
+// NGINX that was added or removed since httpPorts, so that they are rejected or configured again
+func (lbc *LoadBalancerController) enqueueTransportServersForChangedPorts(httpPorts []int) {
+	if !lbc.customResourcesEnabled {
+		return
+	}
+
+	changedPorts := make(map[int]bool)
+	for _, port := range httpPorts {
+		changedPorts[port] = true
+	}
+	for _, port := range lbc.configurator.GetHTTPListenPorts() {
+		if changedPorts[port] {
+			delete(changedPorts, port)
+		} else {
+			changedPorts[port] = true
+		}
+	}
+
+	for _, obj := range lbc.transportServerLister.List() {
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		if ts.Spec.Listener.Protocol == conf_v1alpha1.ProtocolTCP && changedPorts[ts.Spec.Listener.Port] {
+			lbc.syncQueue.Enqueue(ts)
+		}
+	}
+}
+
+func hasTransportServerPrecedence(ts *conf_v1alpha1.TransportServer, other *conf_v1alpha1.TransportServer) bool {
+	if !ts.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return ts.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return ts.Namespace+"/"+ts.Name < other.Namespace+"/"+other.Name
+}
+
+// getUnmanagedTransportServers returns the TransportServers that are not present in NGINX configuration
+func (lbc *LoadBalancerController) getUnmanagedTransportServers() []*conf_v1alpha1.TransportServer {
+	var result []*conf_v1alpha1.TransportServer
+
+	for _, obj := range lbc.transportServerLister.List() {
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		if !lbc.configurator.HasTransportServer(ts) {
+			result = append(result, ts)
+		}
+	}
+
+	return result
+}
+
+// getManagedTransportServerExes returns the TransportServers that are currently present in NGINX configuration
+func (lbc *LoadBalancerController) getManagedTransportServerExes() []*configs.TransportServerEx {
+	var result []*configs.TransportServerEx
+
+	for _, obj := range lbc.transportServerLister.List() {
+		ts := obj.(*conf_v1alpha1.TransportServer)
+		if !lbc.configurator.HasTransportServer(ts) {
+			continue
+		}
+		result = append(result, lbc.createTransportServer(ts))
+	}
+
+	return result
+}
+
+func (lbc *LoadBalancerController) createTransportServer(transportServer *conf_v1alpha1.TransportServer) *configs.TransportServerEx {
+	upstream := transportServer.Spec.Upstream
+
+	endps, err := lbc.getEndpointsForUpstream(transportServer.Namespace, upstream.Service, upstream.Port)
+	if err != nil {
+		glog.Warningf("Error getting Endpoints for the upstream of TransportServer %v/%v: %v", transportServer.Namespace, transportServer.Name, err)
+	}
+
+	// endps is empty if there was any error
+	return &configs.TransportServerEx{
+		TransportServer: transportServer,
+		Endpoints:       endps,
+	}
+}
+
+func (lbc *LoadBalancerController) getEndpointsForUpstream(namespace string, upstreamService string, upstreamPort uint16) ([]string, error) {
+	svcKey := namespace + "/" + upstreamService
+	svcObj, svcExists, err := lbc.svcLister.GetByKey(svcKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting service %v from the cache: %v", svcKey, err)
+	}
+	if !svcExists {
+		return nil, fmt.Errorf("service %s doesn't exist", svcKey)
+	}
+	svc := svcObj.(*api_v1.Service)
+
+	endps, err := lbc.endpointLister.GetServiceEndpoints(svc)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting endpoints for service %s from the cache: %v", svc.Name, err)
+	}
+
+	return lbc.getEndpointsForPort(endps, intstr.FromInt(int(upstreamPort)), svc)
+}
+
 func (lbc *LoadBalancerController) getIngressesForService(svc *api_v1.Service) []extensions.Ingress {
 	ings, err := lbc.ingressLister.GetServiceIngress(svc)
 	if err != nil {
@@ -934,6 +2001,40 @@ func (lbc *LoadBalancerController) getAndValidateSecret(secretKey string) (*api_
 	secret := secretObject.(*api_v1.Secret)
 
 	err = configs.ValidateTLSSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error validating secret %v: %v", secretKey, err)
+	}
+	return secret, nil
+}
+
+func (lbc *LoadBalancerController) getAndValidateHtpasswdSecret(secretKey string) (*api_v1.Secret, error) {
+	secretObject, secretExists, err := lbc.secretLister.GetByKey(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving secret %v", secretKey)
+	}
+	if !secretExists {
+		return nil, fmt.Errorf("secret %v not found", secretKey)
+	}
+	secret := secretObject.(*api_v1.Secret)
+
+	err = configs.ValidateHtpasswdSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error validating secret %v", secretKey)
+	}
+	return secret, nil
+}
+
+func (lbc *LoadBalancerController) getAndValidateCASecret(secretKey string) (*api_v1.Secret, error) {
+	secretObject, secretExists, err := lbc.secretLister.GetByKey(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving secret %v", secretKey)
+	}
+	if !secretExists {
+		return nil, fmt.Errorf("secret %v not found", secretKey)
+	}
+	secret := secretObject.(*api_v1.Secret)
+
+	err = configs.ValidateCASecret(secret)
 	if err != nil {
 		return nil, fmt.Errorf("error validating secret %v", secretKey)
 	}
@@ -980,34 +2081,121 @@ func (lbc *LoadBalancerController) createIngress(ing *extensions.Ingress) (*conf
 		}
 	}
 
-	ingEx.Endpoints = make(map[string][]string)
-	ingEx.HealthChecks = make(map[string]*api_v1.Probe)
-	ingEx.ExternalNameSvcs = make(map[string]bool)
+	if basicAuthSecret, exists := ingEx.Ingress.Annotations[configs.BasicAuthSecretAnnotation]; exists {
+		secretName := basicAuthSecret
 
-	if ing.Spec.Backend != nil {
-		endps := []string{}
-		var external bool
-		svc, err := lbc.getServiceForIngressBackend(ing.Spec.Backend, ing.Namespace)
+		secret, err := lbc.getAndValidateHtpasswdSecret(ing.Namespace + "/" + secretName)
 		if err != nil {
-			glog.V(3).Infof("Error getting service %v: %v", ing.Spec.Backend.ServiceName, err)
-		} else {
-			endps, external, err = lbc.getEndpointsForIngressBackend(ing.Spec.Backend, ing.Namespace, svc)
-			if err == nil && external && lbc.isNginxPlus {
-				ingEx.ExternalNameSvcs[svc.Name] = true
-			}
+			glog.Warningf("Error trying to get the secret %v for Ingress %v: %v", secretName, ing.Name, err)
+		}
+
+		ingEx.BasicAuthSecret = configs.BasicAuthSecret{
+			Name:   basicAuthSecret,
+			Secret: secret,
+		}
+	}
+
+	if clientCASecret, exists := ingEx.Ingress.Annotations[configs.ClientCASecretAnnotation]; exists {
+		secretName := clientCASecret
+
+		secret, err := lbc.getAndValidateCASecret(ing.Namespace + "/" + secretName)
+		if err != nil {
+			glog.Warningf("Error trying to get the secret %v for Ingress %v: %v", secretName, ing.Name, err)
+		}
+
+		ingEx.ClientCASecret = configs.ClientCASecret{
+			Name:   clientCASecret,
+			Secret: secret,
 		}
+	}
 
+	if trustedCertSecret, exists := ingEx.Ingress.Annotations[configs.TrustedCertSecretAnnotation]; exists {
+		secretName := trustedCertSecret
+
+		secret, err := lbc.getAndValidateCASecret(ing.Namespace + "/" + secretName)
 		if err != nil {
-			glog.Warningf("Error retrieving endpoints for the service %v: %v", ing.Spec.Backend.ServiceName, err)
+			glog.Warningf("Error trying to get the secret %v for Ingress %v: %v", secretName, ing.Name, err)
+		}
+
+		ingEx.TrustedCertSecret = configs.TrustedCertSecret{
+			Name:   trustedCertSecret,
+			Secret: secret,
 		}
-		// endps is empty if there was any error before this point
-		ingEx.Endpoints[ing.Spec.Backend.ServiceName+ing.Spec.Backend.ServicePort.String()] = endps
+	}
+
+	if _, err := configs.ParseTLSPassthrough(ing); err != nil {
+		return nil, err
+	}
+
+	// the Ingress is rejected rather than connecting to the services without the verification
+	upstreamTLS, err := configs.ParseUpstreamTLS(ing)
+	if err != nil {
+		return nil, err
+	}
 
-		if lbc.isNginxPlus && lbc.isHealthCheckEnabled(ing) {
-			healthCheck := lbc.getHealthChecksForIngressBackend(ing.Spec.Backend, ing.Namespace)
-			if healthCheck != nil {
-				ingEx.HealthChecks[ing.Spec.Backend.ServiceName+ing.Spec.Backend.ServicePort.String()] = healthCheck
+	ingEx.UpstreamTLSSecrets = make(map[string]*api_v1.Secret)
+	for _, tls := range upstreamTLS {
+		if tls.TrustedCASecret != "" {
+			secret, err := lbc.getAndValidateCASecret(ing.Namespace + "/" + tls.TrustedCASecret)
+			if err != nil {
+				glog.Warningf("Error trying to get the secret %v for Ingress %v: %v", tls.TrustedCASecret, ing.Name, err)
+			}
+			ingEx.UpstreamTLSSecrets[tls.TrustedCASecret] = secret
+		}
+		if tls.ClientSecret != "" {
+			secret, err := lbc.getAndValidateSecret(ing.Namespace + "/" + tls.ClientSecret)
+			if err != nil {
+				glog.Warningf("Error trying to get the secret %v for Ingress %v: %v", tls.ClientSecret, ing.Name, err)
 			}
+			ingEx.UpstreamTLSSecrets[tls.ClientSecret] = secret
+		}
+	}
+
+	splits, err := getSplitsForIngress(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := getMatchesForIngress(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	// the Ingress is rejected rather than exposed without authentication
+	externalAuth, err := configs.ParseExternalAuth(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	// the Ingress is rejected rather than exposed to all clients
+	if _, err := configs.ParseAccessControl(ing); err != nil {
+		return nil, err
+	}
+
+	if _, err := configs.ParseCORSPolicy(ing); err != nil {
+		return nil, err
+	}
+
+	errorPages, err := getErrorPagesForIngress(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	ingEx.Endpoints = make(map[string][]string)
+	ingEx.HealthChecks = make(map[string]*api_v1.Probe)
+	ingEx.ExternalNameSvcs = make(map[string]bool)
+
+	if ing.Spec.Backend != nil {
+		lbc.addBackendToIngressEx(ingEx, ing.Spec.Backend)
+	}
+
+	if externalAuth != nil && externalAuth.Service != nil {
+		lbc.addBackendToIngressEx(ingEx, externalAuth.Service)
+	}
+
+	for _, errorPage := range errorPages {
+		if errorPage.Service != nil {
+			lbc.addBackendToIngressEx(ingEx, errorPage.Service)
 		}
 	}
 
@@ -1022,31 +2210,20 @@ func (lbc *LoadBalancerController) createIngress(ing *extensions.Ingress) (*conf
 		}
 
 		for _, path := range rule.HTTP.Paths {
-			endps := []string{}
-			var external bool
-			svc, err := lbc.getServiceForIngressBackend(&path.Backend, ing.Namespace)
-			if err != nil {
-				glog.V(3).Infof("Error getting service %v: %v", &path.Backend.ServiceName, err)
-			} else {
-				endps, external, err = lbc.getEndpointsForIngressBackend(&path.Backend, ing.Namespace, svc)
-				if err == nil && external && lbc.isNginxPlus {
-					ingEx.ExternalNameSvcs[svc.Name] = true
+			if serviceMatches, hasMatches := matches[path.Backend.ServiceName]; hasMatches {
+				matchBackends := configs.GetMatchBackends(&path.Backend, serviceMatches)
+				for i := range matchBackends {
+					lbc.addBackendToIngressEx(ingEx, &matchBackends[i])
 				}
 			}
-
-			if err != nil {
-				glog.Warningf("Error retrieving endpoints for the service %v: %v", path.Backend.ServiceName, err)
-			}
-			// endps is empty if there was any error before this point
-			ingEx.Endpoints[path.Backend.ServiceName+path.Backend.ServicePort.String()] = endps
-
-			// Pull active health checks from k8 api
-			if lbc.isNginxPlus && lbc.isHealthCheckEnabled(ing) {
-				healthCheck := lbc.getHealthChecksForIngressBackend(&path.Backend, ing.Namespace)
-				if healthCheck != nil {
-					ingEx.HealthChecks[path.Backend.ServiceName+path.Backend.ServicePort.String()] = healthCheck
+			if serviceSplits, isSplit := splits[path.Backend.ServiceName]; isSplit {
+				splitBackends := configs.GetSplitBackends(&path.Backend, serviceSplits)
+				for i := range splitBackends {
+					lbc.addBackendToIngressEx(ingEx, &splitBackends[i])
 				}
+				continue
 			}
+			lbc.addBackendToIngressEx(ingEx, &path.Backend)
 		}
 
 		validRules++
@@ -1059,6 +2236,105 @@ func (lbc *LoadBalancerController) createIngress(ing *extensions.Ingress) (*conf
 	return ingEx, nil
 }
 
+// addBackendToIngressEx retrieves the endpoints and the health check of the backend and adds them to the IngressEx
+func (lbc *LoadBalancerController) addBackendToIngressEx(ingEx *configs.IngressEx, backend *extensions.IngressBackend) {
+	ing := ingEx.Ingress
+
+	endps := []string{}
+	var external bool
+	svc, err := lbc.getServiceForIngressBackend(backend, ing.Namespace)
+	if err != nil {
+		glog.V(3).Infof("Error getting service %v: %v", backend.ServiceName, err)
+	} else {
+		endps, external, err = lbc.getEndpointsForIngressBackend(backend, ing.Namespace, svc)
+		if err == nil && external && lbc.isNginxPlus {
+			ingEx.ExternalNameSvcs[svc.Name] = true
+		}
+	}
+
+	if err != nil {
+		glog.Warningf("Error retrieving endpoints for the service %v: %v", backend.ServiceName, err)
+	}
+	// endps is empty if there was any error before this point
+	ingEx.Endpoints[backend.ServiceName+backend.ServicePort.String()] = endps
+
+	// Pull active health checks from k8 api
+	if lbc.isNginxPlus && lbc.isHealthCheckEnabled(ing) {
+		healthCheck := lbc.getHealthChecksForIngressBackend(backend, ing.Namespace)
+		if healthCheck != nil {
+			ingEx.HealthChecks[backend.ServiceName+backend.ServicePort.String()] = healthCheck
+		}
+	}
+}
+
+// getSplitsForIngress parses the splits annotation of the Ingress
+func getSplitsForIngress(ing *extensions.Ingress) (map[string][]configs.Split, error) {
+	annotation, exists := ing.Annotations[configs.SplitsAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	splits, err := configs.ParseSplits(annotation)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %v annotation: %v", configs.SplitsAnnotation, err)
+	}
+
+	return splits, nil
+}
+
+// validateIngressAnnotations validates the annotations that are invalid if they can't be parsed as a whole
+func validateIngressAnnotations(ing *extensions.Ingress) error {
+	if _, err := getSplitsForIngress(ing); err != nil {
+		return err
+	}
+	if _, err := getMatchesForIngress(ing); err != nil {
+		return err
+	}
+	if _, err := configs.ParseExternalAuth(ing); err != nil {
+		return err
+	}
+	if _, err := configs.ParseAccessControl(ing); err != nil {
+		return err
+	}
+	if _, err := configs.ParseCORSPolicy(ing); err != nil {
+		return err
+	}
+	if _, err := configs.ParseUpstreamTLS(ing); err != nil {
+		return err
+	}
+	_, err := getErrorPagesForIngress(ing)
+	return err
+}
+
+// getErrorPagesForIngress parses the error pages annotation of the Ingress
+func getErrorPagesForIngress(ing *extensions.Ingress) ([]configs.ErrorPageRule, error) {
+	annotation, exists := ing.Annotations[configs.ErrorPagesAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	errorPages, err := configs.ParseErrorPages(annotation)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %v: %v", configs.ErrorPagesAnnotation, err)
+	}
+	return errorPages, nil
+}
+
+// getMatchesForIngress parses the matches annotation of the Ingress
+func getMatchesForIngress(ing *extensions.Ingress) (map[string][]configs.Match, error) {
+	annotation, exists := ing.Annotations[configs.MatchesAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	matches, err := configs.ParseMatches(annotation)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %v annotation: %v", configs.MatchesAnnotation, err)
+	}
+
+	return matches, nil
+}
+
 func (lbc *LoadBalancerController) getPodsForIngressBackend(svc *api_v1.Service, namespace string) *api_v1.PodList {
 	pods, err := lbc.client.CoreV1().Pods(svc.Namespace).List(meta_v1.ListOptions{LabelSelector: labels.Set(svc.Spec.Selector).String()})
 	if err != nil {
@@ -1254,21 +2530,36 @@ func (lbc *LoadBalancerController) isHealthCheckEnabled(ing *extensions.Ingress)
 	return false
 }
 
-// ValidateSecret validates that the secret follows the TLS Secret format.
+// ValidateSecret validates that the secret follows the TLS, the htpasswd or the CA Secret format.
 // For NGINX Plus, it also checks if the secret follows the JWK Secret format.
 func (lbc *LoadBalancerController) ValidateSecret(secret *api_v1.Secret) error {
+	// a Secret with a certificate is a TLS Secret, so the errors of its validation are more relevant than the mismatch of the other formats
+	if _, exists := secret.Data[api_v1.TLSCertKey]; exists {
+		err := configs.ValidateTLSSecret(secret)
+		if configs.IsCertificateValidityError(err) {
+			// the Secret is well-formed, its users decide whether to use the certificate
+			return nil
+		}
+		return err
+	}
+
 	err1 := configs.ValidateTLSSecret(secret)
+	err2 := configs.ValidateHtpasswdSecret(secret)
+	err3 := configs.ValidateCASecret(secret)
 	if !lbc.isNginxPlus {
-		return err1
+		if err1 == nil || err2 == nil || err3 == nil {
+			return nil
+		}
+		return fmt.Errorf("Secret is not a TLS, htpasswd or CA secret")
 	}
 
-	err2 := configs.ValidateJWKSecret(secret)
+	err4 := configs.ValidateJWKSecret(secret)
 
-	if err1 == nil || err2 == nil {
+	if err1 == nil || err2 == nil || err3 == nil || err4 == nil {
 		return nil
 	}
 
-	return fmt.Errorf("Secret is not a TLS or JWK secret")
+	return fmt.Errorf("Secret is not a TLS, htpasswd, CA or JWK secret")
 }
 
 // getMinionsForHost returns a list of all minion ingress resources for a given master
@@ -1,15 +1,26 @@
 package k8s
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
 	"net/http"
+	"os"
+	"path"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
 
 	"github.com/nginxinc/kubernetes-ingress/internal/configs"
 	"github.com/nginxinc/kubernetes-ingress/internal/nginx"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
 	"k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestIsNginxIngress(t *testing.T) {
@@ -549,7 +561,7 @@ func getMergableDefaults() (cafeMaster, coffeeMinion, teaMinion extensions.Ingre
 	cafeMasterIngEx, _ := lbc.createIngress(&cafeMaster)
 	ingExMap["default-cafe-master"] = cafeMasterIngEx
 
-	cnf := configs.NewConfigurator(&nginx.Controller{}, &configs.Config{}, &nginx.NginxAPIController{}, &configs.TemplateExecutor{}, false)
+	cnf := configs.NewConfigurator(&nginx.Controller{}, &configs.Config{}, &nginx.NginxAPIController{}, &configs.TemplateExecutor{}, false, false, nil)
 
 	// edit private field ingresses to use in testing
 	pointerVal := reflect.ValueOf(cnf)
@@ -782,7 +794,7 @@ func TestFindProbeForPods(t *testing.T) {
 
 func TestGetServicePortForIngressPort(t *testing.T) {
 	fakeClient := fake.NewSimpleClientset()
-	cnf := configs.NewConfigurator(&nginx.Controller{}, &configs.Config{}, &nginx.NginxAPIController{}, &configs.TemplateExecutor{}, false)
+	cnf := configs.NewConfigurator(&nginx.Controller{}, &configs.Config{}, &nginx.NginxAPIController{}, &configs.TemplateExecutor{}, false, false, nil)
 	lbc := LoadBalancerController{
 		client:       fakeClient,
 		ingressClass: "nginx",
@@ -920,6 +932,121 @@ func TestFindIngressesForSecret(t *testing.T) {
 			expectedToFind: false,
 			desc:           "an Ingress references a JWK secret that exists in a different namespace",
 		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-htpasswd-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-1",
+					Annotations: map[string]string{
+						configs.BasicAuthSecretAnnotation: "my-htpasswd-secret",
+					},
+				},
+			},
+			expectedToFind: true,
+			desc:           "an Ingress references an htpasswd Secret that exists in the Ingress namespace",
+		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-htpasswd-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-2",
+					Annotations: map[string]string{
+						configs.BasicAuthSecretAnnotation: "my-htpasswd-secret",
+					},
+				},
+			},
+			expectedToFind: false,
+			desc:           "an Ingress references an htpasswd secret that exists in a different namespace",
+		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ca-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-1",
+					Annotations: map[string]string{
+						configs.ClientCASecretAnnotation: "my-ca-secret",
+					},
+				},
+			},
+			expectedToFind: true,
+			desc:           "an Ingress references a CA Secret that exists in the Ingress namespace",
+		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-chain-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-1",
+					Annotations: map[string]string{
+						configs.TrustedCertSecretAnnotation: "my-chain-secret",
+					},
+				},
+			},
+			expectedToFind: true,
+			desc:           "an Ingress references a trusted certificate Secret that exists in the Ingress namespace",
+		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ca-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-2",
+					Annotations: map[string]string{
+						configs.ClientCASecretAnnotation: "my-ca-secret",
+					},
+				},
+			},
+			expectedToFind: false,
+			desc:           "an Ingress references a CA secret that exists in a different namespace",
+		},
+		{
+			secret: v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-client-secret",
+					Namespace: "namespace-1",
+				},
+			},
+			ingress: extensions.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "namespace-1",
+					Annotations: map[string]string{
+						"nginx.org/ssl-services":      "my-svc",
+						configs.UpstreamTLSAnnotation: "serviceName=my-svc trusted-ca-secret=my-ca-secret client-secret=my-client-secret",
+					},
+				},
+			},
+			expectedToFind: true,
+			desc:           "an Ingress references a client Secret for the TLS connections to a service",
+		},
 	}
 
 	for _, test := range testCases {
@@ -930,13 +1057,13 @@ func TestFindIngressesForSecret(t *testing.T) {
 			if err != nil {
 				t.Fatalf("templateExecuter could not start: %v", err)
 			}
-			ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true)
+			ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true, nil)
 			apiCtrl, err := nginx.NewNginxAPIController(&http.Client{}, "", true)
 			if err != nil {
 				t.Fatalf("NGINX API Controller could not start: %v", err)
 			}
 
-			cnf := configs.NewConfigurator(ngxc, &configs.Config{}, apiCtrl, templateExecutor, false)
+			cnf := configs.NewConfigurator(ngxc, &configs.Config{}, apiCtrl, templateExecutor, false, false, nil)
 			lbc := LoadBalancerController{
 				client:       fakeClient,
 				ingressClass: "nginx",
@@ -1111,13 +1238,13 @@ func TestFindIngressesForSecretWithMinions(t *testing.T) {
 			if err != nil {
 				t.Fatalf("templateExecuter could not start: %v", err)
 			}
-			ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true)
+			ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true, nil)
 			apiCtrl, err := nginx.NewNginxAPIController(&http.Client{}, "", true)
 			if err != nil {
 				t.Fatalf("NGINX API Controller could not start: %v", err)
 			}
 
-			cnf := configs.NewConfigurator(ngxc, &configs.Config{}, apiCtrl, templateExecutor, false)
+			cnf := configs.NewConfigurator(ngxc, &configs.Config{}, apiCtrl, templateExecutor, false, false, nil)
 			lbc := LoadBalancerController{
 				client:       fakeClient,
 				ingressClass: "nginx",
@@ -1178,3 +1305,542 @@ func TestFindIngressesForSecretWithMinions(t *testing.T) {
 	}
 
 }
+
+func createTestTransportServer(name string, created time.Time, port int, protocol string) *conf_v1alpha1.TransportServer {
+	return &conf_v1alpha1.TransportServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: meta_v1.NewTime(created),
+		},
+		Spec: conf_v1alpha1.TransportServerSpec{
+			Listener: conf_v1alpha1.TransportServerListener{
+				Port:     port,
+				Protocol: protocol,
+			},
+			Upstream: conf_v1alpha1.TransportServerUpstream{
+				Service: "test-svc",
+				Port:    80,
+			},
+		},
+	}
+}
+
+func TestFindTransportServerWithSameListener(t *testing.T) {
+	now := time.Now()
+	older := createTestTransportServer("older", now.Add(-time.Minute), 5353, "UDP")
+	newer := createTestTransportServer("newer", now, 5353, "UDP")
+	sameAge := createTestTransportServer("same-age", now, 5353, "UDP")
+	tcp := createTestTransportServer("tcp", now.Add(-time.Hour), 5353, "TCP")
+	invalid := createTestTransportServer("invalid", now.Add(-time.Hour), 5353, "UDP")
+	invalid.Spec.Upstream.Service = ""
+
+	lbc := LoadBalancerController{
+		transportServerLister: cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+	for _, ts := range []*conf_v1alpha1.TransportServer{older, newer, sameAge, tcp, invalid} {
+		lbc.transportServerLister.Add(ts)
+	}
+
+	tests := []struct {
+		ts       *conf_v1alpha1.TransportServer
+		expected *conf_v1alpha1.TransportServer
+		msg      string
+	}{
+		{
+			ts:       older,
+			expected: nil,
+			msg:      "the oldest TransportServer takes precedence",
+		},
+		{
+			ts:       newer,
+			expected: older,
+			msg:      "a newer TransportServer conflicts with an older one",
+		},
+		{
+			ts:       tcp,
+			expected: nil,
+			msg:      "listeners with different protocols do not conflict",
+		},
+	}
+
+	for _, test := range tests {
+		result := lbc.findTransportServerWithSameListener(test.ts)
+		if result != test.expected {
+			t.Errorf("findTransportServerWithSameListener() returned %v but expected %v for the case of %s", result, test.expected, test.msg)
+		}
+	}
+
+	if !hasTransportServerPrecedence(newer, sameAge) {
+		t.Errorf("hasTransportServerPrecedence() returned false for TransportServers with the same age, expected the smaller name to win")
+	}
+}
+
+func TestValidateTransportServerPorts(t *testing.T) {
+	cnf := configs.NewConfigurator(&nginx.Controller{}, configs.NewDefaultConfig(), &nginx.NginxAPIController{}, &configs.TemplateExecutor{}, false, false, nil)
+	lbc := LoadBalancerController{
+		configurator:                 cnf,
+		transportServerReservedPorts: []int{8181, 9113},
+	}
+
+	tests := []struct {
+		port     int
+		protocol string
+		valid    bool
+	}{
+		{
+			port:     5353,
+			protocol: "TCP",
+			valid:    true,
+		},
+		{
+			port:     443,
+			protocol: "TCP",
+			valid:    false,
+		},
+		{
+			port:     9113,
+			protocol: "TCP",
+			valid:    false,
+		},
+		{
+			port:     443,
+			protocol: "UDP",
+			valid:    true,
+		},
+		{
+			port:     8181,
+			protocol: "UDP",
+			valid:    true,
+		},
+	}
+
+	for _, test := range tests {
+		ts := createTestTransportServer("ts", time.Now(), test.port, test.protocol)
+		err := lbc.validateTransportServer(ts)
+		if test.valid && err != nil {
+			t.Errorf("validateTransportServer() returned %v for the listener %v/%v", err, test.port, test.protocol)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("validateTransportServer() returned no error for the listener %v/%v", test.port, test.protocol)
+		}
+	}
+}
+
+func createTestTLSPassthroughIngress(name string, created time.Time, hosts ...string) *extensions.Ingress {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: meta_v1.NewTime(created),
+			Annotations: map[string]string{
+				configs.TLSPassthroughAnnotation: "true",
+			},
+		},
+	}
+	for _, host := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{
+			Host: host,
+			IngressRuleValue: extensions.IngressRuleValue{
+				HTTP: &extensions.HTTPIngressRuleValue{
+					Paths: []extensions.HTTPIngressPath{
+						{
+							Path: "/",
+							Backend: extensions.IngressBackend{
+								ServiceName: "app-svc",
+								ServicePort: intstr.FromInt(443),
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	return ing
+}
+
+func TestFindIngressWithSameTLSPassthroughHost(t *testing.T) {
+	now := time.Now()
+	older := createTestTLSPassthroughIngress("older", now.Add(-time.Minute), "app.example.com")
+	newer := createTestTLSPassthroughIngress("newer", now, "other.example.com", "app.example.com")
+	sameAge := createTestTLSPassthroughIngress("same-age", now, "other.example.com")
+	otherHost := createTestTLSPassthroughIngress("other-host", now.Add(-time.Hour), "tea.example.com")
+	withoutPassthrough := createTestTLSPassthroughIngress("without-passthrough", now.Add(-time.Hour), "app.example.com")
+	delete(withoutPassthrough.Annotations, configs.TLSPassthroughAnnotation)
+
+	lbc := LoadBalancerController{
+		ingressClass: "nginx",
+	}
+	lbc.ingressLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, ing := range []*extensions.Ingress{older, newer, sameAge, otherHost, withoutPassthrough} {
+		lbc.ingressLister.Add(ing)
+	}
+
+	tests := []struct {
+		ing          *extensions.Ingress
+		expected     *extensions.Ingress
+		expectedHost string
+		msg          string
+	}{
+		{
+			ing:          older,
+			expected:     nil,
+			expectedHost: "",
+			msg:          "the oldest Ingress takes precedence",
+		},
+		{
+			ing:          newer,
+			expected:     older,
+			expectedHost: "app.example.com",
+			msg:          "a newer Ingress conflicts with an older one",
+		},
+		{
+			ing:          sameAge,
+			expected:     newer,
+			expectedHost: "other.example.com",
+			msg:          "the smaller name wins for Ingresses with the same age",
+		},
+		{
+			ing:          otherHost,
+			expected:     nil,
+			expectedHost: "",
+			msg:          "different hosts do not conflict",
+		},
+	}
+
+	for _, test := range tests {
+		result, host := lbc.findIngressWithSameTLSPassthroughHost(test.ing)
+		resultName, expectedName := "", ""
+		if result != nil {
+			resultName = result.Name
+		}
+		if test.expected != nil {
+			expectedName = test.expected.Name
+		}
+		if resultName != expectedName || host != test.expectedHost {
+			t.Errorf("findIngressWithSameTLSPassthroughHost() returned %q, %q but expected %q, %q for the case of %s", resultName, host, expectedName, test.expectedHost, test.msg)
+		}
+	}
+}
+
+func createTestTLSSecret(t *testing.T, name string, notAfter time.Time) *v1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate: %v", err)
+	}
+
+	return &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			v1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+}
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		notAfter      time.Time
+		warningDays   int
+		expectedEvent string
+		msg           string
+	}{
+		{
+			notAfter:      now.Add(90 * 24 * time.Hour),
+			warningDays:   30,
+			expectedEvent: "",
+			msg:           "valid certificate",
+		},
+		{
+			notAfter:      now.Add(10*24*time.Hour + time.Hour),
+			warningDays:   30,
+			expectedEvent: "Warning CertificateExpiring The certificate of the Secret cafe-secret expires on " + now.Add(10*24*time.Hour+time.Hour).UTC().Format(time.RFC3339) + ", in 10 days",
+			msg:           "certificate expiring",
+		},
+		{
+			notAfter:      now.Add(-time.Hour),
+			warningDays:   30,
+			expectedEvent: "Warning CertificateExpired The certificate of the Secret cafe-secret expired on " + now.Add(-time.Hour).UTC().Format(time.RFC3339),
+			msg:           "certificate expired",
+		},
+		{
+			notAfter:      now.Add(-time.Hour),
+			warningDays:   0,
+			expectedEvent: "",
+			msg:           "events disabled",
+		},
+	}
+
+	for _, test := range tests {
+		recorder := record.NewFakeRecorder(10)
+		lbc := LoadBalancerController{
+			recorder:                     recorder,
+			certificateExpiryWarningDays: test.warningDays,
+		}
+		lbc.secretLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		lbc.secretLister.Add(createTestTLSSecret(t, "cafe-secret", test.notAfter.Truncate(time.Second)))
+
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "cafe-ingress",
+				Namespace: "default",
+			},
+			Spec: extensions.IngressSpec{
+				TLS: []extensions.IngressTLS{
+					{
+						Hosts:      []string{"cafe.example.com"},
+						SecretName: "cafe-secret",
+					},
+					{
+						Hosts:      []string{"tea.example.com"},
+						SecretName: "cafe-secret",
+					},
+					{
+						Hosts:      []string{"coffee.example.com"},
+						SecretName: "missing-secret",
+					},
+				},
+			},
+		}
+
+		lbc.checkCertificateExpiry(ing)
+		close(recorder.Events)
+
+		var events []string
+		for event := range recorder.Events {
+			events = append(events, event)
+		}
+
+		var expected []string
+		if test.expectedEvent != "" {
+			expected = []string{test.expectedEvent}
+		}
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("checkCertificateExpiry() emitted %q, but expected %q for the case of %s", events, expected, test.msg)
+		}
+	}
+}
+
+func TestCheckTLSSecrets(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	lbc := LoadBalancerController{
+		recorder: recorder,
+	}
+	lbc.secretLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	// the key of the Secret is invalid
+	lbc.secretLister.Add(createTestTLSSecret(t, "cafe-secret", time.Now().Add(24*time.Hour).Truncate(time.Second)))
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe-ingress",
+			Namespace: "default",
+		},
+		Spec: extensions.IngressSpec{
+			TLS: []extensions.IngressTLS{
+				{
+					Hosts:      []string{"cafe.example.com"},
+					SecretName: "cafe-secret",
+				},
+			},
+		},
+	}
+
+	// the configuration of the Ingress is also generated for the changes of the other resources, so no events are emitted
+	lbc.createIngress(ing)
+	if len(recorder.Events) != 0 {
+		t.Errorf("createIngress() emitted %v events, but expected none", len(recorder.Events))
+	}
+
+	lbc.checkTLSSecrets(ing)
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+
+	expectedPrefix := "Warning InvalidTLSSecret The TLS Secret cafe-secret of hosts cafe.example.com is not used: error validating secret default/cafe-secret: Invalid tls.key"
+	if len(events) != 1 || !strings.HasPrefix(events[0], expectedPrefix) {
+		t.Errorf("checkTLSSecrets() emitted %q, but expected one event starting with %q", events, expectedPrefix)
+	}
+}
+
+func TestGetIngressError(t *testing.T) {
+	cafeIngress := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "default",
+			Name:      "cafe",
+		},
+	}
+
+	if err := getIngressError(nil, cafeIngress); err != nil {
+		t.Errorf("getIngressError() returned %v for no error", err)
+	}
+
+	reloadErr := fmt.Errorf("reload error")
+	if err := getIngressError(reloadErr, cafeIngress); err != reloadErr {
+		t.Errorf("getIngressError() returned %v but expected %v", err, reloadErr)
+	}
+
+	templateExecutor, err := configs.NewTemplateExecutor("../configs/templates/nginx-plus.tmpl", "../configs/templates/nginx-plus.ingress.tmpl", true, true, []string{"127.0.0.1"}, 8080, false)
+	if err != nil {
+		t.Fatalf("templateExecuter could not start: %v", err)
+	}
+	invalidIngressTemplate := "{{.Upstreams.This.Field.Does.Not.Exist}}"
+	if err := templateExecutor.UpdateIngressTemplate(&invalidIngressTemplate); err != nil {
+		t.Fatalf("Failed to update the Ingress template: %v", err)
+	}
+	ngxc := nginx.NewNginxController("/etc/nginx", "nginx", true, nil)
+	cnf := configs.NewConfigurator(ngxc, configs.NewDefaultConfig(), &nginx.NginxAPIController{}, templateExecutor, false, false, nil)
+
+	updateErr := cnf.UpdateEndpoints([]*configs.IngressEx{{Ingress: cafeIngress}})
+	if err := getIngressError(updateErr, cafeIngress); err == nil {
+		t.Errorf("getIngressError() returned no error for the Ingress that failed to be updated")
+	}
+
+	teaIngress := cafeIngress.DeepCopy()
+	teaIngress.Name = "tea"
+	if err := getIngressError(updateErr, teaIngress); err != nil {
+		t.Errorf("getIngressError() returned %v for the Ingress that wasn't updated", err)
+	}
+}
+
+func TestSyncEndpointsWhileConfigPinned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the version 1 of the configuration history has the config file of the deleted Ingress resource
+	files := map[string]string{
+		"nginx.conf":                         "# main",
+		"conf.d/.keep":                       "",
+		"history/1/nginx.conf":               "# main",
+		"history/1/conf.d/default-cafe.conf": "# cafe",
+		"history/1/secrets.sha256":           "",
+	}
+	for name, content := range files {
+		filename := path.Join(dir, name)
+		if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+			t.Fatalf("Failed to create the directory of %v: %v", name, err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %v: %v", name, err)
+		}
+	}
+
+	templateExecutor, err := configs.NewTemplateExecutor("../configs/templates/nginx.tmpl", "../configs/templates/nginx.ingress.tmpl", true, true, []string{"127.0.0.1"}, 8080, false)
+	if err != nil {
+		t.Fatalf("templateExecuter could not start: %v", err)
+	}
+	ngxc := nginx.NewNginxController(dir, "nginx", true, nil)
+	if err := ngxc.EnableConfigHistory(1); err != nil {
+		t.Fatalf("EnableConfigHistory() returned an error: %v", err)
+	}
+	pinTimeout := 100 * time.Millisecond
+	ngxc.SetConfigPinTimeout(pinTimeout)
+	cnf := configs.NewConfigurator(ngxc, configs.NewDefaultConfig(), &nginx.NginxAPIController{}, templateExecutor, false, false, nil)
+
+	lbc := LoadBalancerController{
+		client:       fake.NewSimpleClientset(),
+		ingressClass: "nginx",
+		configurator: cnf,
+	}
+	lbc.syncQueue = newTaskQueue(lbc.sync)
+	lbc.ingressLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	lbc.endpointLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+
+	if err := ngxc.RollbackConfig(1); err != nil {
+		t.Fatalf("RollbackConfig() returned an error: %v", err)
+	}
+	if _, err := os.Stat(path.Join(dir, "conf.d/default-cafe.conf")); err != nil {
+		t.Fatalf("RollbackConfig() didn't restore the config file: %v", err)
+	}
+
+	// the change of the endpoints during the pin is postponed until the pin expires
+	endpointsTask := task{Kind: endpoints, Key: "default/coffee-svc"}
+	lbc.sync(endpointsTask)
+	if !cnf.IsConfigPinned() {
+		t.Fatalf("The sync of the endpoints unpinned the configuration before the pin expired")
+	}
+	if length := lbc.syncQueue.queue.Len(); length != 0 {
+		t.Errorf("The sync of the endpoints requeued the task before the pin expired")
+	}
+
+	time.Sleep(3 * pinTimeout)
+	if length := lbc.syncQueue.queue.Len(); length != 1 {
+		t.Fatalf("The sync of the endpoints didn't requeue the task after the pin expired: the queue has %v tasks", length)
+	}
+
+	// the requeued change of the endpoints after the pin expired unpins the configuration
+	lbc.sync(endpointsTask)
+	if cnf.IsConfigPinned() {
+		t.Errorf("The sync of the endpoints didn't unpin the configuration after the pin expired")
+	}
+	if _, err := os.Stat(path.Join(dir, "conf.d/default-cafe.conf")); !os.IsNotExist(err) {
+		t.Errorf("The unpinning kept the config file of the deleted Ingress resource")
+	}
+}
+
+func TestEmitEventsForReloadBatchError(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	lbc := LoadBalancerController{
+		recorder: recorder,
+	}
+	lbc.ingressLister.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe-ingress",
+			Namespace: "default",
+		},
+	}
+	lbc.ingressLister.Add(ing)
+	deletedIng := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "deleted-ingress",
+			Namespace: "default",
+		},
+	}
+
+	// the resources are not recorded outside of a batch
+	lbc.reloadBatch.addIngress(ing)
+
+	batch := &reloadBatchResources{
+		ingresses:        make(map[string]bool),
+		virtualServers:   make(map[string]bool),
+		transportServers: make(map[string]bool),
+	}
+	batch.addIngress(ing)
+	batch.addIngress(deletedIng)
+
+	lbc.emitEventsForReloadBatchError(batch, fmt.Errorf("reload failed"))
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+
+	expected := []string{"Warning AddedOrUpdatedWithError Configuration for default/cafe-ingress was added or updated, but not applied: reload failed"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("emitEventsForReloadBatchError() emitted %q, but expected %q", events, expected)
+	}
+}
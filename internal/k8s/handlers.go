@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/golang/glog"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/client-go/tools/cache"
@@ -199,6 +200,8 @@ func createServiceHandlers(lbc *LoadBalancerController) cache.ResourceEventHandl
 			}
 			glog.V(3).Infof("Adding service: %v", svc.Name)
 			lbc.EnqueueIngressForService(svc)
+			lbc.EnqueueVirtualServersForService(svc)
+			lbc.EnqueueTransportServersForService(svc)
 		},
 		DeleteFunc: func(obj interface{}) {
 			svc, isSvc := obj.(*v1.Service)
@@ -221,6 +224,8 @@ func createServiceHandlers(lbc *LoadBalancerController) cache.ResourceEventHandl
 
 			glog.V(3).Infof("Removing service: %v", svc.Name)
 			lbc.EnqueueIngressForService(svc)
+			lbc.EnqueueVirtualServersForService(svc)
+			lbc.EnqueueTransportServersForService(svc)
 
 		},
 		UpdateFunc: func(old, cur interface{}) {
@@ -234,12 +239,88 @@ func createServiceHandlers(lbc *LoadBalancerController) cache.ResourceEventHandl
 				if hasServiceChanges(oldSvc, curSvc) {
 					glog.V(3).Infof("Service %v changed, syncing", curSvc.Name)
 					lbc.EnqueueIngressForService(curSvc)
+					lbc.EnqueueVirtualServersForService(curSvc)
+					lbc.EnqueueTransportServersForService(curSvc)
 				}
 			}
 		},
 	}
 }
 
+// createVirtualServerHandlers builds the handler funcs for VirtualServers
+func createVirtualServerHandlers(lbc *LoadBalancerController) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			vs := obj.(*conf_v1alpha1.VirtualServer)
+			glog.V(3).Infof("Adding VirtualServer: %v", vs.Name)
+			lbc.AddSyncQueue(vs)
+		},
+		DeleteFunc: func(obj interface{}) {
+			vs, isVs := obj.(*conf_v1alpha1.VirtualServer)
+			if !isVs {
+				deletedState, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					glog.V(3).Infof("Error received unexpected object: %v", obj)
+					return
+				}
+				vs, ok = deletedState.Obj.(*conf_v1alpha1.VirtualServer)
+				if !ok {
+					glog.V(3).Infof("Error DeletedFinalStateUnknown contained non-VirtualServer object: %v", deletedState.Obj)
+					return
+				}
+			}
+			glog.V(3).Infof("Removing VirtualServer: %v", vs.Name)
+			lbc.AddSyncQueue(vs)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			curVs := cur.(*conf_v1alpha1.VirtualServer)
+			oldVs := old.(*conf_v1alpha1.VirtualServer)
+			// changes of the status are made by the Ingress controller itself, so we only react to changes of the spec
+			if !reflect.DeepEqual(oldVs.Spec, curVs.Spec) {
+				glog.V(3).Infof("VirtualServer %v changed, syncing", curVs.Name)
+				lbc.AddSyncQueue(curVs)
+			}
+		},
+	}
+}
+
+// createTransportServerHandlers builds the handler funcs for TransportServers
+func createTransportServerHandlers(lbc *LoadBalancerController) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ts := obj.(*conf_v1alpha1.TransportServer)
+			glog.V(3).Infof("Adding TransportServer: %v", ts.Name)
+			lbc.AddSyncQueue(ts)
+		},
+		DeleteFunc: func(obj interface{}) {
+			ts, isTs := obj.(*conf_v1alpha1.TransportServer)
+			if !isTs {
+				deletedState, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					glog.V(3).Infof("Error received unexpected object: %v", obj)
+					return
+				}
+				ts, ok = deletedState.Obj.(*conf_v1alpha1.TransportServer)
+				if !ok {
+					glog.V(3).Infof("Error DeletedFinalStateUnknown contained non-TransportServer object: %v", deletedState.Obj)
+					return
+				}
+			}
+			glog.V(3).Infof("Removing TransportServer: %v", ts.Name)
+			lbc.AddSyncQueue(ts)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			curTs := cur.(*conf_v1alpha1.TransportServer)
+			oldTs := old.(*conf_v1alpha1.TransportServer)
+			// changes of the status are made by the Ingress controller itself, so we only react to changes of the spec
+			if !reflect.DeepEqual(oldTs.Spec, curTs.Spec) {
+				glog.V(3).Infof("TransportServer %v changed, syncing", curTs.Name)
+				lbc.AddSyncQueue(curTs)
+			}
+		},
+	}
+}
+
 type portSort []v1.ServicePort
 
 func (a portSort) Len() int {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	conf_v1alpha1 "github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -20,6 +21,18 @@ type taskQueue struct {
 	sync func(task)
 	// workerDone is closed when the worker exits
 	workerDone chan struct{}
+	// batch configures the batches of tasks. If nil, the tasks are processed without batches
+	batch *taskBatchConfig
+}
+
+// taskBatchConfig configures the batches of tasks. A batch starts with a task and includes the tasks that arrive
+// within interval after the previous task, but no later than maxLatency after the start of the batch.
+// start is called before the first task of a batch and end is called after the last one.
+type taskBatchConfig struct {
+	interval   time.Duration
+	maxLatency time.Duration
+	start      func()
+	end        func()
 }
 
 // newTaskQueue creates a new task queue with the given sync function.
@@ -32,9 +45,20 @@ func newTaskQueue(syncFn func(task)) *taskQueue {
 	}
 }
 
+// newTaskQueueWithBatches creates a new task queue with the given sync function that processes the tasks in batches.
+func newTaskQueueWithBatches(syncFn func(task), batch taskBatchConfig) *taskQueue {
+	tq := newTaskQueue(syncFn)
+	tq.batch = &batch
+	return tq
+}
+
 // Run begins running the worker for the given duration
 func (tq *taskQueue) Run(period time.Duration, stopCh <-chan struct{}) {
-	wait.Until(tq.worker, period, stopCh)
+	if tq.batch != nil {
+		wait.Until(tq.batchWorker, period, stopCh)
+	} else {
+		wait.Until(tq.worker, period, stopCh)
+	}
 }
 
 // Enqueue enqueues ns/name of the given api object in the task queue.
@@ -79,12 +103,80 @@ func (tq *taskQueue) worker() {
 			close(tq.workerDone)
 			return
 		}
-		glog.V(3).Infof("Syncing %v", t.(task).Key)
-		tq.sync(t.(task))
-		tq.queue.Done(t)
+		tq.syncTask(t)
 	}
 }
 
+// batchWorker processes work in the queue through sync in batches.
+func (tq *taskQueue) batchWorker() {
+	tasks := make(chan interface{})
+	go func() {
+		for {
+			t, quit := tq.queue.Get()
+			if quit {
+				close(tasks)
+				return
+			}
+			tasks <- t
+		}
+	}()
+
+	for {
+		t, ok := <-tasks
+		if !ok {
+			close(tq.workerDone)
+			return
+		}
+
+		glog.V(3).Info("Starting a batch of tasks")
+		tq.batch.start()
+		deadline := time.Now().Add(tq.batch.maxLatency)
+
+		tq.syncTask(t)
+		ok = tq.syncBatch(tasks, deadline)
+
+		glog.V(3).Info("Ending the batch of tasks")
+		tq.batch.end()
+
+		if !ok {
+			close(tq.workerDone)
+			return
+		}
+	}
+}
+
+// syncBatch processes the tasks that arrive within the interval after the previous task until the deadline.
+// It returns false if the queue was shut down.
+func (tq *taskQueue) syncBatch(tasks <-chan interface{}, deadline time.Time) bool {
+	for {
+		wait := tq.batch.interval
+		if untilDeadline := time.Until(deadline); untilDeadline < wait {
+			wait = untilDeadline
+		}
+		if wait <= 0 {
+			return true
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case t, ok := <-tasks:
+			timer.Stop()
+			if !ok {
+				return false
+			}
+			tq.syncTask(t)
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+func (tq *taskQueue) syncTask(t interface{}) {
+	glog.V(3).Infof("Syncing %v", t.(task).Key)
+	tq.sync(t.(task))
+	tq.queue.Done(t)
+}
+
 // Shutdown shuts down the work queue and waits for the worker to ACK
 func (tq *taskQueue) Shutdown() {
 	tq.queue.ShutDown()
@@ -107,6 +199,10 @@ const (
 	secret
 	// service resource
 	service
+	// virtualserver resource
+	virtualserver
+	// transportserver resource
+	transportserver
 )
 
 // task is an element of a taskQueue
@@ -134,6 +230,10 @@ func newTask(key string, obj interface{}) (task, error) {
 		k = secret
 	case *v1.Service:
 		k = service
+	case *conf_v1alpha1.VirtualServer:
+		k = virtualserver
+	case *conf_v1alpha1.TransportServer:
+		k = transportserver
 	default:
 		return task{}, fmt.Errorf("Unknow type: %v", t)
 	}
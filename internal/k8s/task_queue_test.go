@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder records the keys of the synced tasks grouped by the batches
+type batchRecorder struct {
+	batches [][]string
+	mutex   sync.Mutex
+}
+
+func (r *batchRecorder) start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.batches = append(r.batches, nil)
+}
+
+func (r *batchRecorder) sync(t task) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.batches[len(r.batches)-1] = append(r.batches[len(r.batches)-1], t.Key)
+}
+
+func (r *batchRecorder) end() {}
+
+func (r *batchRecorder) getBatches() [][]string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.batches
+}
+
+func TestTaskQueueWithBatches(t *testing.T) {
+	recorder := &batchRecorder{}
+	tq := newTaskQueueWithBatches(recorder.sync, taskBatchConfig{
+		interval:   100 * time.Millisecond,
+		maxLatency: time.Minute,
+		start:      recorder.start,
+		end:        recorder.end,
+	})
+
+	stopCh := make(chan struct{})
+	go tq.Run(time.Second, stopCh)
+
+	tq.queue.Add(task{Kind: ingress, Key: "default/cafe"})
+	tq.queue.Add(task{Kind: endpoints, Key: "default/tea-svc"})
+	time.Sleep(10 * time.Millisecond)
+	tq.queue.Add(task{Kind: secret, Key: "default/cafe-secret"})
+
+	// the next task arrives after the interval, so it starts a new batch
+	time.Sleep(500 * time.Millisecond)
+	tq.queue.Add(task{Kind: ingress, Key: "default/coffee"})
+	time.Sleep(500 * time.Millisecond)
+
+	close(stopCh)
+	tq.Shutdown()
+
+	expected := [][]string{
+		{"default/cafe", "default/tea-svc", "default/cafe-secret"},
+		{"default/coffee"},
+	}
+	if batches := recorder.getBatches(); !reflect.DeepEqual(batches, expected) {
+		t.Errorf("The task queue processed the batches %v, but expected %v", batches, expected)
+	}
+}
+
+func TestTaskQueueWithBatchesMaxLatency(t *testing.T) {
+	recorder := &batchRecorder{}
+	tq := newTaskQueueWithBatches(recorder.sync, taskBatchConfig{
+		interval:   time.Minute,
+		maxLatency: 200 * time.Millisecond,
+		start:      recorder.start,
+		end:        recorder.end,
+	})
+
+	stopCh := make(chan struct{})
+	go tq.Run(time.Second, stopCh)
+
+	tq.queue.Add(task{Kind: ingress, Key: "default/cafe"})
+	// the batch ends after the max latency even though the next task arrives within the interval
+	time.Sleep(500 * time.Millisecond)
+	tq.queue.Add(task{Kind: ingress, Key: "default/coffee"})
+	time.Sleep(500 * time.Millisecond)
+
+	close(stopCh)
+	tq.Shutdown()
+
+	expected := [][]string{
+		{"default/cafe"},
+		{"default/coffee"},
+	}
+	if batches := recorder.getBatches(); !reflect.DeepEqual(batches, expected) {
+		t.Errorf("The task queue processed the batches %v, but expected %v", batches, expected)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -81,6 +82,9 @@ func (s *storeToIngressLister) GetServiceIngress(svc *v1.Service) (ings []v1beta
 				}
 			}
 		}
+		if isSplitTarget(&ing, svc.Name) || isMatchTarget(&ing, svc.Name) || isAuthService(&ing, svc.Name) || isErrorPageService(&ing, svc.Name) {
+			ings = append(ings, ing)
+		}
 	}
 	if len(ings) == 0 {
 		err = fmt.Errorf("No ingress for service %v", svc.Name)
@@ -88,6 +92,75 @@ func (s *storeToIngressLister) GetServiceIngress(svc *v1.Service) (ings []v1beta
 	return
 }
 
+// isSplitTarget checks if the service receives a part of the traffic of a split of the Ingress.
+func isSplitTarget(ing *v1beta1.Ingress, serviceName string) bool {
+	splits, err := getSplitsForIngress(ing)
+	if err != nil {
+		return false
+	}
+	for _, serviceSplits := range splits {
+		for _, split := range serviceSplits {
+			if split.ServiceName == serviceName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMatchTarget checks if the service receives the requests that satisfy a match of the Ingress.
+func isMatchTarget(ing *v1beta1.Ingress, serviceName string) bool {
+	matches, err := getMatchesForIngress(ing)
+	if err != nil {
+		return false
+	}
+	for _, serviceMatches := range matches {
+		for _, match := range serviceMatches {
+			if match.ServiceName == serviceName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAuthService checks if the service is the external authentication service of the Ingress.
+func isAuthService(ing *v1beta1.Ingress, serviceName string) bool {
+	externalAuth, err := configs.ParseExternalAuth(ing)
+	if err != nil || externalAuth == nil || externalAuth.Service == nil {
+		return false
+	}
+	return externalAuth.Service.ServiceName == serviceName
+}
+
+// isErrorPageService checks if the service returns an error page of the Ingress.
+func isErrorPageService(ing *v1beta1.Ingress, serviceName string) bool {
+	errorPages, err := getErrorPagesForIngress(ing)
+	if err != nil {
+		return false
+	}
+	for _, errorPage := range errorPages {
+		if errorPage.Service != nil && errorPage.Service.ServiceName == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpstreamTLSSecret checks if the secret is a CA or a client Secret of the TLS connections to the services of the Ingress.
+func isUpstreamTLSSecret(ing *v1beta1.Ingress, secretName string) bool {
+	upstreamTLS, err := configs.ParseUpstreamTLS(ing)
+	if err != nil {
+		return false
+	}
+	for _, tls := range upstreamTLS {
+		if tls.TrustedCASecret == secretName || tls.ClientSecret == secretName {
+			return true
+		}
+	}
+	return false
+}
+
 // storeToConfigMapLister makes a Store that lists ConfigMaps
 type storeToConfigMapLister struct {
 	cache.Store
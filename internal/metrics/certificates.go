@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// controllerMetricsNamespace is the namespace of the metrics of the Ingress controller
+const controllerMetricsNamespace = "nginx_ingress_controller"
+
+type certificate struct {
+	namespace string
+	name      string
+	notAfter  time.Time
+}
+
+// CertificateCollector collects the expiration dates of the certificates of the TLS Secrets.
+// It implements prometheus.Collector interface.
+type CertificateCollector struct {
+	certificates   map[string]certificate
+	notAfterMetric *prometheus.Desc
+	expiryMetric   *prometheus.Desc
+	now            func() time.Time
+	mutex          sync.Mutex
+}
+
+// NewCertificateCollector creates a CertificateCollector.
+func NewCertificateCollector() *CertificateCollector {
+	labels := []string{"namespace", "secret"}
+
+	return &CertificateCollector{
+		certificates: make(map[string]certificate),
+		notAfterMetric: prometheus.NewDesc(prometheus.BuildFQName(controllerMetricsNamespace, "certificate", "not_after_seconds"),
+			"The expiration date of the certificate of the TLS Secret as a Unix timestamp", labels, nil),
+		expiryMetric: prometheus.NewDesc(prometheus.BuildFQName(controllerMetricsNamespace, "certificate", "expiry_days"),
+			"The number of days until the certificate of the TLS Secret expires. Negative if the certificate has expired", labels, nil),
+		now: time.Now,
+	}
+}
+
+// UpdateCertificate adds or updates the expiration date of the certificate of the Secret.
+func (c *CertificateCollector) UpdateCertificate(namespace string, name string, notAfter time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.certificates[namespace+"/"+name] = certificate{
+		namespace: namespace,
+		name:      name,
+		notAfter:  notAfter,
+	}
+}
+
+// DeleteCertificate deletes the certificate of the Secret.
+func (c *CertificateCollector) DeleteCertificate(namespace string, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.certificates, namespace+"/"+name)
+}
+
+// Describe sends the descriptors of the certificate metrics to the provided channel.
+func (c *CertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.notAfterMetric
+	ch <- c.expiryMetric
+}
+
+// Collect sends the metrics of the certificates to the provided channel. The number of days
+// until the expiration is calculated at the time of the collection.
+func (c *CertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+
+	for _, cert := range c.certificates {
+		ch <- prometheus.MustNewConstMetric(c.notAfterMetric, prometheus.GaugeValue,
+			float64(cert.notAfter.Unix()), cert.namespace, cert.name)
+		ch <- prometheus.MustNewConstMetric(c.expiryMetric, prometheus.GaugeValue,
+			cert.notAfter.Sub(now).Hours()/24, cert.namespace, cert.name)
+	}
+}
@@ -21,17 +21,21 @@ func NewNginxMetricsClient(httpClient *http.Client) (*prometheusClient.NginxClie
 	return prometheusClient.NewNginxClient(httpClient, "http://config-status/stub_status")
 }
 
-// RunPrometheusListenerForNginx runs an http server to expose Prometheus metrics for NGINX
-func RunPrometheusListenerForNginx(port int, client *prometheusClient.NginxClient) {
+// RunPrometheusListenerForNginx runs an http server to expose Prometheus metrics for NGINX, the certificates and the reloads
+func RunPrometheusListenerForNginx(port int, client *prometheusClient.NginxClient, certificateCollector *CertificateCollector, reloadCollector *ReloadCollector) {
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(collector.NewNginxCollector(client, "nginx"))
+	registry.MustRegister(certificateCollector)
+	registry.MustRegister(reloadCollector)
 	runServer(strconv.Itoa(port), registry)
 }
 
-// RunPrometheusListenerForNginxPlus runs an http server to expose Prometheus metrics for NGINX Plus
-func RunPrometheusListenerForNginxPlus(port int, plusClient *sdkClient.NginxClient) {
+// RunPrometheusListenerForNginxPlus runs an http server to expose Prometheus metrics for NGINX Plus, the certificates and the reloads
+func RunPrometheusListenerForNginxPlus(port int, plusClient *sdkClient.NginxClient, certificateCollector *CertificateCollector, reloadCollector *ReloadCollector) {
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(collector.NewNginxPlusCollector(plusClient, "nginxplus"))
+	registry.MustRegister(certificateCollector)
+	registry.MustRegister(reloadCollector)
 	runServer(strconv.Itoa(port), registry)
 }
 
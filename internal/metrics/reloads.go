@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReloadCollector collects the number of the reloads of NGINX, the number of the reloads that were saved
+// by batching the changes of the resources and the number of the reloads that were skipped because the configuration
+// didn't change. It implements prometheus.Collector interface.
+type ReloadCollector struct {
+	reloadsTotal        prometheus.Counter
+	savedReloadsTotal   prometheus.Counter
+	skippedReloadsTotal prometheus.Counter
+}
+
+// NewReloadCollector creates a ReloadCollector.
+func NewReloadCollector() *ReloadCollector {
+	return &ReloadCollector{
+		reloadsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: controllerMetricsNamespace,
+			Subsystem: "nginx",
+			Name:      "reloads_total",
+			Help:      "The number of the reloads of NGINX",
+		}),
+		savedReloadsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: controllerMetricsNamespace,
+			Subsystem: "nginx",
+			Name:      "reloads_saved_total",
+			Help:      "The number of the reloads of NGINX that were saved by batching the changes",
+		}),
+		skippedReloadsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: controllerMetricsNamespace,
+			Subsystem: "nginx",
+			Name:      "reloads_skipped_total",
+			Help:      "The number of the reloads of NGINX that were skipped because the configuration didn't change",
+		}),
+	}
+}
+
+// IncReloadCount increments the number of the reloads.
+func (c *ReloadCollector) IncReloadCount() {
+	c.reloadsTotal.Inc()
+}
+
+// IncSavedReloadCount increments the number of the saved reloads.
+func (c *ReloadCollector) IncSavedReloadCount() {
+	c.savedReloadsTotal.Inc()
+}
+
+// IncSkippedReloadCount increments the number of the skipped reloads.
+func (c *ReloadCollector) IncSkippedReloadCount() {
+	c.skippedReloadsTotal.Inc()
+}
+
+// Describe sends the descriptors of the reload metrics to the provided channel.
+func (c *ReloadCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.reloadsTotal.Describe(ch)
+	c.savedReloadsTotal.Describe(ch)
+	c.skippedReloadsTotal.Describe(ch)
+}
+
+// Collect sends the reload metrics to the provided channel.
+func (c *ReloadCollector) Collect(ch chan<- prometheus.Metric) {
+	c.reloadsTotal.Collect(ch)
+	c.savedReloadsTotal.Collect(ch)
+	c.skippedReloadsTotal.Collect(ch)
+}
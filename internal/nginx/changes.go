@@ -0,0 +1,59 @@
+package nginx
+
+import (
+	"crypto/sha256"
+
+	"github.com/golang/glog"
+)
+
+// The Controller keeps the hashes of the files it writes. Writing a file with the same content is skipped, and
+// a reload is skipped if no file changed since the last reload. For example, the periodic resync of the Endpoints
+// generates the same configuration files again.
+
+// isFileUnchanged checks if the file was written with the same content. The caller must hold the mutex.
+func (nginx *Controller) isFileUnchanged(filename string, content []byte) bool {
+	hash, exists := nginx.fileHashes[filename]
+	return exists && hash == sha256.Sum256(content)
+}
+
+// recordFileWrite records the content of a written file. The caller must hold the mutex.
+func (nginx *Controller) recordFileWrite(filename string, content []byte) {
+	if nginx.fileHashes == nil {
+		nginx.fileHashes = make(map[string][sha256.Size]byte)
+	}
+	nginx.fileHashes[filename] = sha256.Sum256(content)
+	nginx.isConfigChanged = true
+}
+
+// recordFileRemoval records the removal of a file. The caller must hold the mutex.
+func (nginx *Controller) recordFileRemoval(filename string) {
+	delete(nginx.fileHashes, filename)
+	nginx.isConfigChanged = true
+}
+
+// resetFileHashes forgets the hashes of the files, so that the next write of every file happens. It is used when
+// the files are changed not through the Controller. The caller must hold the mutex.
+func (nginx *Controller) resetFileHashes() {
+	nginx.fileHashes = nil
+	nginx.isConfigChanged = true
+}
+
+func (nginx *Controller) hasConfigChanges() bool {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	return nginx.isConfigChanged
+}
+
+// reloadIfChanged reloads NGINX if any file changed since the last reload
+func (nginx *Controller) reloadIfChanged() error {
+	if !nginx.hasConfigChanges() {
+		glog.V(3).Info("Skipping the reload of nginx: the configuration didn't change")
+		if nginx.reloadCollector != nil {
+			nginx.reloadCollector.IncSkippedReloadCount()
+		}
+		return nil
+	}
+
+	return nginx.reload()
+}
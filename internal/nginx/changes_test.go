@@ -0,0 +1,63 @@
+package nginx
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUpdateIngressConfigFileUnchanged(t *testing.T) {
+	ngxc, dir := createTestController(t, "#!/bin/sh\nexit 0\n")
+	defer os.RemoveAll(dir)
+
+	if err := ngxc.UpdateIngressConfigFile("default-cafe", []byte("# updated")); err != nil {
+		t.Fatalf("UpdateIngressConfigFile() returned an error: %v", err)
+	}
+	if !ngxc.isConfigChanged {
+		t.Errorf("UpdateIngressConfigFile() didn't record the change of the config file")
+	}
+
+	// the file with the same content is not written again
+	filename := ngxc.getIngressNginxConfigFileName("default-cafe")
+	if err := ioutil.WriteFile(filename, []byte("# modified"), 0644); err != nil {
+		t.Fatalf("Failed to write %v: %v", filename, err)
+	}
+	ngxc.isConfigChanged = false
+
+	if err := ngxc.UpdateIngressConfigFile("default-cafe", []byte("# updated")); err != nil {
+		t.Fatalf("UpdateIngressConfigFile() returned an error: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read %v: %v", filename, err)
+	}
+	if string(content) != "# modified" {
+		t.Errorf("UpdateIngressConfigFile() wrote the unchanged config file")
+	}
+	if ngxc.isConfigChanged {
+		t.Errorf("UpdateIngressConfigFile() recorded a change for the unchanged config file")
+	}
+
+	ngxc.DeleteIngress("default-cafe")
+	if !ngxc.isConfigChanged {
+		t.Errorf("DeleteIngress() didn't record the change of the config")
+	}
+}
+
+func TestReloadSkippedWithoutChanges(t *testing.T) {
+	// the Controller is not set up to reload NGINX, so the test fails if a reload happens
+	nginx := &Controller{}
+
+	if err := nginx.Reload(); err != nil {
+		t.Errorf("Reload() returned an error for an unchanged configuration: %v", err)
+	}
+
+	nginx.StartReloadBatch()
+	if err := nginx.Reload(); err != nil {
+		t.Errorf("Reload() returned an error during a batch: %v", err)
+	}
+	if err := nginx.EndReloadBatch(); err != nil {
+		t.Errorf("EndReloadBatch() returned an error for an unchanged configuration: %v", err)
+	}
+}
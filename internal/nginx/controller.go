@@ -2,13 +2,16 @@ package nginx
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/nginxinc/kubernetes-ingress/internal/metrics"
 )
 
 const dhparamFilename = "dhparam.pem"
@@ -19,32 +22,72 @@ const TLSSecretFileMode = 0600
 // JWKSecretFileMode defines the default filemode for files with JWK Secrets
 const JWKSecretFileMode = 0644
 
+// HtpasswdSecretFileMode defines the default filemode for files with htpasswd Secrets
+const HtpasswdSecretFileMode = 0644
+
+// CASecretFileMode defines the default filemode for files with CA Secrets
+const CASecretFileMode = 0644
+
+// ConfigFileMode defines the default filemode for the generated NGINX configuration files
+const ConfigFileMode = 0644
+
 // Controller updates NGINX configuration, starts and reloads NGINX
 type Controller struct {
-	nginxConfdPath        string
-	nginxSecretsPath      string
-	local                 bool
-	nginxBinaryPath       string
-	verifyConfigGenerator *verifyConfigGenerator
-	verifyClient          *verifyClient
-	ConfigVersion         int
+	nginxConfdPath          string
+	nginxSecretsPath        string
+	nginxStagingPath        string
+	nginxHistoryPath        string
+	nginxMainConfigFileName string
+	local                   bool
+	nginxBinaryPath         string
+	verifyConfigGenerator   *verifyConfigGenerator
+	verifyClient            *verifyClient
+	configVersion           int
+	reloadCollector         *metrics.ReloadCollector
+	// isBatchingReloads is true during a batch of changes, when the reloads are postponed until the end of the batch
+	isBatchingReloads bool
+	isReloadPending   bool
+	historySize       int
+	// fileHashes holds the hashes of the written files, isConfigChanged is true if any file changed since the last reload
+	fileHashes      map[string][sha256.Size]byte
+	isConfigChanged bool
+	// isConfigPinned is true after a rollback until the next change of the resources, the changes of the endpoints
+	// end the pinning only after configPinExpiry
+	isConfigPinned   bool
+	configPinTimeout time.Duration
+	configPinExpiry  time.Time
+
+	// mutex serializes the changes of the configuration files and the reloads, because a rollback of the configuration
+	// can happen concurrently with the sync of the resources
+	mutex sync.Mutex
 }
 
-// NewNginxController creates a NGINX controller
-func NewNginxController(nginxConfPath string, nginxBinaryPath string, local bool) *Controller {
+// NewNginxController creates a NGINX controller. reloadCollector can be nil if the metrics are disabled.
+func NewNginxController(nginxConfPath string, nginxBinaryPath string, local bool, reloadCollector *metrics.ReloadCollector) *Controller {
 	verifyConfigGenerator, err := newVerifyConfigGenerator()
 	if err != nil {
 		glog.Fatalf("error instantiating a verifyConfigGenerator: %v", err)
 	}
 
 	ngxc := Controller{
-		nginxConfdPath:        path.Join(nginxConfPath, "conf.d"),
-		nginxSecretsPath:      path.Join(nginxConfPath, "secrets"),
-		local:                 local,
-		nginxBinaryPath:       nginxBinaryPath,
-		verifyConfigGenerator: verifyConfigGenerator,
-		ConfigVersion:         0,
-		verifyClient:          newVerifyClient(),
+		nginxConfdPath:          path.Join(nginxConfPath, "conf.d"),
+		nginxSecretsPath:        path.Join(nginxConfPath, "secrets"),
+		nginxStagingPath:        path.Join(nginxConfPath, stagingDirName),
+		nginxHistoryPath:        path.Join(nginxConfPath, historyDirName),
+		nginxMainConfigFileName: path.Join(nginxConfPath, "nginx.conf"),
+		local:                   local,
+		nginxBinaryPath:         nginxBinaryPath,
+		verifyConfigGenerator:   verifyConfigGenerator,
+		configVersion:           0,
+		verifyClient:            newVerifyClient(),
+		reloadCollector:         reloadCollector,
+		configPinTimeout:        DefaultConfigPinTimeout,
+	}
+
+	if !local {
+		if err := ngxc.createStagingDirs(); err != nil {
+			glog.Fatalf("Couldn't create the staging directory: %v", err)
+		}
 	}
 
 	return &ngxc
@@ -53,57 +96,49 @@ func NewNginxController(nginxConfPath string, nginxBinaryPath string, local bool
 // DeleteIngress deletes the configuration file, which corresponds for the
 // specified ingress from NGINX conf directory
 func (nginx *Controller) DeleteIngress(name string) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
 	filename := nginx.getIngressNginxConfigFileName(name)
 	glog.V(3).Infof("deleting %v", filename)
 
-	if !nginx.local {
+	if !nginx.local && !nginx.isFileChangeSkipped(filename) {
 		if err := os.Remove(filename); err != nil {
 			glog.Warningf("Failed to delete %v: %v", filename, err)
 		}
+		nginx.recordFileRemoval(filename)
 	}
 }
 
 // AddOrUpdateDHParam creates the servers dhparam.pem file
 func (nginx *Controller) AddOrUpdateDHParam(dhparam string) (string, error) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
 	fileName := nginx.nginxSecretsPath + "/" + dhparamFilename
-	if !nginx.local {
-		err := createFileAndWrite(fileName, []byte(dhparam))
+	if !nginx.local && !nginx.isFileUnchanged(fileName, []byte(dhparam)) && !nginx.isFileChangeSkipped(fileName) {
+		err := writeFileAtomically(fileName, []byte(dhparam), ConfigFileMode)
 		if err != nil {
 			return fileName, fmt.Errorf("Failed to write pem file: %v", err)
 		}
+		nginx.recordFileWrite(fileName, []byte(dhparam))
 	}
 	return fileName, nil
 }
 
 // AddOrUpdateSecretFile creates a file with the specified name, content and mode.
 func (nginx *Controller) AddOrUpdateSecretFile(name string, content []byte, mode os.FileMode) string {
-	filename := nginx.GetSecretFileName(name)
-
-	if !nginx.local {
-		file, err := ioutil.TempFile(nginx.nginxSecretsPath, name)
-		if err != nil {
-			glog.Fatalf("Couldn't create a temp file for the secret file %v: %v", name, err)
-		}
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
 
-		err = file.Chmod(mode)
-		if err != nil {
-			glog.Fatalf("Couldn't change the mode of the temp secret file %v: %v", file.Name(), err)
-		}
-
-		_, err = file.Write(content)
-		if err != nil {
-			glog.Fatalf("Couldn't write to the temp secret file %v: %v", file.Name(), err)
-		}
-
-		err = file.Close()
-		if err != nil {
-			glog.Fatalf("Couldn't close the temp secret file %v: %v", file.Name(), err)
-		}
+	filename := nginx.GetSecretFileName(name)
 
-		err = os.Rename(file.Name(), filename)
+	if !nginx.local && !nginx.isFileUnchanged(filename, content) && !nginx.isFileChangeSkipped(filename) {
+		err := writeFileAtomically(filename, content, mode)
 		if err != nil {
-			glog.Fatalf("Fail to rename the temp secret file %v to %v: %v", file.Name(), filename, err)
+			glog.Fatalf("Couldn't write the secret file %v: %v", filename, err)
 		}
+		nginx.recordFileWrite(filename, content)
 	}
 
 	return filename
@@ -111,13 +146,17 @@ func (nginx *Controller) AddOrUpdateSecretFile(name string, content []byte, mode
 
 // DeleteSecretFile the file with a Secret
 func (nginx *Controller) DeleteSecretFile(name string) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
 	filename := nginx.GetSecretFileName(name)
 	glog.V(3).Infof("deleting %v", filename)
 
-	if !nginx.local {
+	if !nginx.local && !nginx.isFileChangeSkipped(filename) {
 		if err := os.Remove(filename); err != nil {
 			glog.Warningf("Failed to delete %v: %v", filename, err)
 		}
+		nginx.recordFileRemoval(filename)
 	}
 }
 
@@ -134,27 +173,74 @@ func (nginx *Controller) getNginxCommand(cmd string) string {
 	return fmt.Sprint(nginx.nginxBinaryPath, " -s ", cmd)
 }
 
-// Reload reloads NGINX
+// Reload reloads NGINX. During a batch of changes, the reload is postponed until the end of the batch.
 func (nginx *Controller) Reload() error {
 	if nginx.local {
 		glog.V(3).Info("local - skipping nginx reload")
 		return nil
 	}
+
+	if nginx.isBatchingReloads {
+		if nginx.isReloadPending && nginx.reloadCollector != nil {
+			nginx.reloadCollector.IncSavedReloadCount()
+		}
+		nginx.isReloadPending = true
+		glog.V(3).Info("Postponing the reload of nginx until the end of the batch")
+		return nil
+	}
+
+	return nginx.reloadIfChanged()
+}
+
+// StartReloadBatch starts a batch of changes. The reloads are postponed until EndReloadBatch is called.
+func (nginx *Controller) StartReloadBatch() {
+	nginx.isBatchingReloads = true
+}
+
+// EndReloadBatch ends a batch of changes and reloads NGINX once if any of the changes required a reload.
+func (nginx *Controller) EndReloadBatch() error {
+	nginx.isBatchingReloads = false
+
+	if !nginx.isReloadPending {
+		return nil
+	}
+	nginx.isReloadPending = false
+
+	return nginx.reloadIfChanged()
+}
+
+func (nginx *Controller) reload() error {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if nginx.reloadCollector != nil {
+		nginx.reloadCollector.IncReloadCount()
+	}
+
 	// write a new config version
-	nginx.ConfigVersion++
+	nginx.configVersion++
 	nginx.UpdateConfigVersionFile()
 
-	glog.V(3).Infof("Reloading nginx. configVersion: %v", nginx.ConfigVersion)
+	glog.V(3).Infof("Reloading nginx. configVersion: %v", nginx.configVersion)
 
 	reloadCmd := nginx.getNginxCommand("reload")
 	if err := shellOut(reloadCmd); err != nil {
 		return fmt.Errorf("nginx reload failed: %v", err)
 	}
-	err := nginx.verifyClient.WaitForCorrectVersion(nginx.ConfigVersion)
+	err := nginx.verifyClient.WaitForCorrectVersion(nginx.configVersion)
 	if err != nil {
 		return fmt.Errorf("could not get newest config version: %v", err)
 	}
 
+	nginx.isConfigChanged = false
+
+	// only the configuration that NGINX has accepted gets to the history
+	if nginx.historySize > 0 {
+		if err := nginx.saveConfigVersion(nginx.configVersion); err != nil {
+			glog.Warningf("Failed to save the config version %v to the history: %v", nginx.configVersion, err)
+		}
+	}
+
 	return nil
 }
 
@@ -177,7 +263,7 @@ func (nginx *Controller) Start(done chan error) {
 		done <- cmd.Wait()
 	}()
 
-	err := nginx.verifyClient.WaitForCorrectVersion(nginx.ConfigVersion)
+	err := nginx.verifyClient.WaitForCorrectVersion(nginx.configVersion)
 	if err != nil {
 		glog.Fatalf("Could not get newest config version: %v", err)
 	}
@@ -224,9 +310,13 @@ func shellOut(cmd string) (err error) {
 	return nil
 }
 
-// UpdateMainConfigFile writes the main NGINX configuration file to the filesystem
-func (nginx *Controller) UpdateMainConfigFile(cfg []byte) {
-	filename := "/etc/nginx/nginx.conf"
+// UpdateMainConfigFile writes the main NGINX configuration file to the filesystem. The file is tested with nginx -t first.
+// If the test fails, the previous file is kept and the returned error includes the output of NGINX.
+func (nginx *Controller) UpdateMainConfigFile(cfg []byte) error {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	filename := nginx.nginxMainConfigFileName
 	glog.V(3).Infof("Writing NGINX conf to %v", filename)
 
 	if bool(glog.V(3)) || nginx.local {
@@ -234,16 +324,30 @@ func (nginx *Controller) UpdateMainConfigFile(cfg []byte) {
 	}
 
 	if !nginx.local {
-		err := createFileAndWrite(filename, cfg)
+		if nginx.isFileUnchanged(filename, cfg) {
+			glog.V(3).Infof("The main NGINX config file is unchanged")
+			return nil
+		}
+		if nginx.isFileChangeSkipped(filename) {
+			return nil
+		}
+
+		err := nginx.stageMainConfigFile(cfg)
 		if err != nil {
-			glog.Fatalf("Failed to write NGINX conf: %v", err)
+			return fmt.Errorf("Failed to update NGINX conf: %v", err)
 		}
+		nginx.recordFileWrite(filename, cfg)
 	}
 	glog.V(3).Infof("The main NGINX config file has been updated")
+	return nil
 }
 
-// UpdateIngressConfigFile writes the Ingress configuration file to the filesystem
-func (nginx *Controller) UpdateIngressConfigFile(name string, cfg []byte) {
+// UpdateIngressConfigFile writes the Ingress configuration file to the filesystem. The file is tested with nginx -t first.
+// If the test fails, the previous file is kept and the returned error includes the output of NGINX.
+func (nginx *Controller) UpdateIngressConfigFile(name string, cfg []byte) error {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
 	filename := nginx.getIngressNginxConfigFileName(name)
 	glog.V(3).Infof("Writing Ingress conf to %v", filename)
 
@@ -252,23 +356,40 @@ func (nginx *Controller) UpdateIngressConfigFile(name string, cfg []byte) {
 	}
 
 	if !nginx.local {
-		err := createFileAndWrite(filename, cfg)
+		if nginx.isFileUnchanged(filename, cfg) {
+			glog.V(3).Infof("The Ingress config file is unchanged")
+			return nil
+		}
+		if nginx.isFileChangeSkipped(filename) {
+			return nil
+		}
+
+		err := nginx.stageIngressConfigFile(name, cfg)
 		if err != nil {
-			glog.Fatalf("Failed to write Ingress conf: %v", err)
+			return fmt.Errorf("Failed to update Ingress conf %v: %v", filename, err)
 		}
+		nginx.recordFileWrite(filename, cfg)
 	}
 	glog.V(3).Infof("The Ingress config file has been updated")
+	return nil
+}
+
+// GetConfigVersion returns the config version of the last reload
+func (nginx *Controller) GetConfigVersion() int {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	return nginx.configVersion
 }
 
 // UpdateConfigVersionFile writes the config version file.
 func (nginx *Controller) UpdateConfigVersionFile() {
-	cfg, err := nginx.verifyConfigGenerator.GenerateVersionConfig(nginx.ConfigVersion)
+	cfg, err := nginx.verifyConfigGenerator.GenerateVersionConfig(nginx.configVersion)
 	if err != nil {
 		glog.Fatalf("Error generating config version content: %v", err)
 	}
 
 	filename := "/etc/nginx/config-version.conf"
-	tempname := "/etc/nginx/config-version.conf.tmp"
 	glog.V(3).Infof("Writing config version to %v", filename)
 
 	if bool(glog.V(3)) || nginx.local {
@@ -276,31 +397,10 @@ func (nginx *Controller) UpdateConfigVersionFile() {
 	}
 
 	if !nginx.local {
-		err := createFileAndWrite(tempname, cfg)
+		err := writeFileAtomically(filename, cfg, ConfigFileMode)
 		if err != nil {
 			glog.Fatalf("Failed to write version config file: %v", err)
 		}
-
-		err = os.Rename(tempname, filename)
-		if err != nil {
-			glog.Fatalf("failed to rename version config file: %v", err)
-		}
 	}
 	glog.V(3).Infof("The config version file has been updated.")
 }
-
-func createFileAndWrite(name string, b []byte) error {
-	w, err := os.Create(name)
-	if err != nil {
-		return fmt.Errorf("Failed to open %v: %v", name, err)
-	}
-
-	defer w.Close()
-
-	_, err = w.Write(b)
-	if err != nil {
-		return fmt.Errorf("Failed to write to %v: %v", name, err)
-	}
-
-	return nil
-}
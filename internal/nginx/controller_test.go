@@ -13,7 +13,7 @@ func TestGetNginxCommand(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.cmd, func(t *testing.T) {
-			nginx := NewNginxController("/etc/nginx", test.nginxBinaryPath, true)
+			nginx := NewNginxController("/etc/nginx", test.nginxBinaryPath, true, nil)
 
 			if got := nginx.getNginxCommand(test.cmd); got != test.expected {
 				t.Errorf("getNginxCommand returned \n%v, but expected \n%v", got, test.expected)
@@ -21,3 +21,22 @@ func TestGetNginxCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadBatch(t *testing.T) {
+	nginx := &Controller{}
+
+	nginx.StartReloadBatch()
+	if err := nginx.EndReloadBatch(); err != nil {
+		t.Errorf("EndReloadBatch() returned an error for a batch without reloads: %v", err)
+	}
+
+	nginx.StartReloadBatch()
+	for i := 0; i < 3; i++ {
+		if err := nginx.Reload(); err != nil {
+			t.Errorf("Reload() returned an error during a batch: %v", err)
+		}
+	}
+	if !nginx.isReloadPending {
+		t.Errorf("Reload() didn't postpone the reload until the end of the batch")
+	}
+}
@@ -0,0 +1,218 @@
+package nginx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines around the changes in a unified diff
+const diffContextLines = 3
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// unifiedDiff returns the unified diff of two files or an empty string if the files are equal
+func unifiedDiff(fromName string, toName string, from []byte, to []byte) string {
+	if bytes.Equal(from, to) {
+		return ""
+	}
+
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %v\n+++ %v\n", fromName, toName)
+
+	// the positions of the ops in the old and the new file
+	fromLines := make([]int, len(ops)+1)
+	toLines := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromLines[i+1] = fromLines[i]
+		toLines[i+1] = toLines[i]
+		if op.kind != diffInsert {
+			fromLines[i+1]++
+		}
+		if op.kind != diffDelete {
+			toLines[i+1]++
+		}
+	}
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == diffEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk while the changes are close to each other
+		end := start
+		for i := start; i < len(ops) && i-end <= 2*diffContextLines; i++ {
+			if ops[i].kind != diffEqual {
+				end = i + 1
+			}
+		}
+
+		hunkStart := start - diffContextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := end + diffContextLines
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		fmt.Fprintf(&b, "@@ -%v +%v @@\n",
+			hunkRange(fromLines[hunkStart], fromLines[hunkEnd]-fromLines[hunkStart]),
+			hunkRange(toLines[hunkStart], toLines[hunkEnd]-toLines[hunkStart]))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			switch op.kind {
+			case diffEqual:
+				b.WriteString(" ")
+			case diffDelete:
+				b.WriteString("-")
+			case diffInsert:
+				b.WriteString("+")
+			}
+			b.WriteString(op.line)
+			b.WriteString("\n")
+		}
+
+		start = hunkEnd
+	}
+
+	return b.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%v", start+1)
+	}
+	return fmt.Sprintf("%v,%v", start+1, count)
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+// diffLines returns the shortest edit script that transforms the lines a into the lines b
+func diffLines(a []string, b []string) []diffOp {
+	var ops []diffOp
+
+	// the common prefix and suffix are usually most of the lines of a configuration file
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{kind: diffEqual, line: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: diffEqual, line: line})
+	}
+
+	return ops
+}
+
+// myersDiff implements the Myers' difference algorithm. trace[d] holds the furthest x on the diagonals
+// -d-1..d+1 before the step d, which is enough to backtrack the edit script.
+func myersDiff(a []string, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+func backtrackDiff(a []string, b []string, trace [][]int, d int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: diffEqual, line: a[x]})
+		}
+
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{kind: diffInsert, line: b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{kind: diffDelete, line: a[x]})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{kind: diffEqual, line: a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
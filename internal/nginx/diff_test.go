@@ -0,0 +1,110 @@
+package nginx
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\no\n"
+	expected := `--- 1/nginx.conf
++++ 2/nginx.conf
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -12,3 +12,4 @@
+ l
+ m
+ n
++o
+`
+
+	result := unifiedDiff("1/nginx.conf", "2/nginx.conf", []byte(from), []byte(to))
+	if result != expected {
+		t.Errorf("unifiedDiff() returned\n%v\nbut expected\n%v", result, expected)
+	}
+}
+
+func TestUnifiedDiffMergesCloseChanges(t *testing.T) {
+	from := "a\nb\nc\nd\ne\n"
+	to := "b\nc\nd\nE\n"
+	expected := `--- 1/nginx.conf
++++ 2/nginx.conf
+@@ -1,5 +1,4 @@
+-a
+ b
+ c
+ d
+-e
++E
+`
+
+	result := unifiedDiff("1/nginx.conf", "2/nginx.conf", []byte(from), []byte(to))
+	if result != expected {
+		t.Errorf("unifiedDiff() returned\n%v\nbut expected\n%v", result, expected)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	expected := `--- /dev/null
++++ 2/conf.d/default-cafe.conf
+@@ -0,0 +1,2 @@
++server {
++}
+`
+
+	result := unifiedDiff("/dev/null", "2/conf.d/default-cafe.conf", nil, []byte("server {\n}\n"))
+	if result != expected {
+		t.Errorf("unifiedDiff() returned\n%v\nbut expected\n%v", result, expected)
+	}
+
+	result = unifiedDiff("1/nginx.conf", "2/nginx.conf", []byte("a\n"), []byte("a\n"))
+	if result != "" {
+		t.Errorf("unifiedDiff() returned %q for equal files, but expected an empty string", result)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+
+	ops := diffLines(a, b)
+
+	var from, to []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != diffInsert {
+			from = append(from, op.line)
+		}
+		if op.kind != diffDelete {
+			to = append(to, op.line)
+		}
+		if op.kind != diffEqual {
+			edits++
+		}
+	}
+
+	if !equalLines(from, a) || !equalLines(to, b) {
+		t.Errorf("diffLines() returned the edit script %v that doesn't transform %v into %v", ops, a, b)
+	}
+	// the shortest edit script for the example from the Myers' paper has 5 edits
+	if edits != 5 {
+		t.Errorf("diffLines() returned %v edits, but expected 5", edits)
+	}
+}
+
+func equalLines(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
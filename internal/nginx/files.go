@@ -0,0 +1,142 @@
+package nginx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// tempFileSuffix is the suffix of the name of a temporary file. The names of the temporary files start with '.',
+// so they don't match the names of the files of the resources or the *.conf pattern of the includes.
+const tempFileSuffix = ".tmp"
+
+// writeFileAtomically writes the file through a temporary file in the same directory, which is synced to the disk
+// and renamed to the file. As a result, NGINX never reads a partially written file, and the file survives a crash.
+func writeFileAtomically(name string, b []byte, mode os.FileMode) error {
+	dir, base := path.Split(name)
+
+	file, err := ioutil.TempFile(dir, "."+base+tempFileSuffix)
+	if err != nil {
+		return fmt.Errorf("Failed to create a temp file for %v: %v", name, err)
+	}
+
+	err = writeAndSync(file, b, mode)
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	err = renameAndSync(file.Name(), name)
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+// writeFileAndSync creates or truncates the file, writes to it and syncs it to the disk. It is used for the files
+// NGINX doesn't read yet.
+func writeFileAndSync(name string, b []byte, mode os.FileMode) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("Failed to open %v: %v", name, err)
+	}
+
+	return writeAndSync(file, b, mode)
+}
+
+// writeAndSync writes to the file, syncs it to the disk and closes it
+func writeAndSync(file *os.File, b []byte, mode os.FileMode) error {
+	defer file.Close()
+
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("Failed to change the mode of %v: %v", file.Name(), err)
+	}
+	if _, err := file.Write(b); err != nil {
+		return fmt.Errorf("Failed to write to %v: %v", file.Name(), err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync %v: %v", file.Name(), err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Failed to close %v: %v", file.Name(), err)
+	}
+
+	return nil
+}
+
+// renameAndSync renames the file and syncs the directory of the new name, so that the rename survives a crash
+func renameAndSync(oldName string, newName string) error {
+	if err := os.Rename(oldName, newName); err != nil {
+		return fmt.Errorf("Failed to rename %v to %v: %v", oldName, newName, err)
+	}
+
+	dir, err := os.Open(path.Dir(newName))
+	if err != nil {
+		glog.Warningf("Failed to open the directory of %v: %v", newName, err)
+		return nil
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		glog.Warningf("Failed to sync the directory of %v: %v", newName, err)
+	}
+
+	return nil
+}
+
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempFileSuffix)
+}
+
+// RemoveStaleFiles removes the files that a previous run of the Ingress controller could have left: the temporary files,
+// the configuration files in the conf.d directory and the files in the secrets directory, except the files in secretsToKeep.
+// The files of the resources are created again when the resources are synced after the start.
+func (nginx *Controller) RemoveStaleFiles(secretsToKeep ...string) error {
+	if nginx.local {
+		return nil
+	}
+
+	keep := make(map[string]bool)
+	for _, name := range secretsToKeep {
+		keep[name] = true
+	}
+
+	dirs := []struct {
+		path      string
+		removeAll bool
+	}{
+		{path: path.Dir(nginx.nginxMainConfigFileName)},
+		{path: nginx.nginxConfdPath, removeAll: true},
+		{path: nginx.nginxSecretsPath, removeAll: true},
+	}
+
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir.path)
+		if err != nil {
+			return fmt.Errorf("Failed to read %v: %v", dir.path, err)
+		}
+
+		for _, file := range files {
+			if !file.Mode().IsRegular() {
+				continue
+			}
+			if !isTempFile(file.Name()) && (!dir.removeAll || keep[file.Name()]) {
+				continue
+			}
+
+			filename := path.Join(dir.path, file.Name())
+			glog.V(3).Infof("Removing the stale file %v", filename)
+			if err := os.Remove(filename); err != nil {
+				return fmt.Errorf("Failed to remove %v: %v", filename, err)
+			}
+		}
+	}
+
+	return nil
+}
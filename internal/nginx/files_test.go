@@ -0,0 +1,121 @@
+package nginx
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"testing"
+)
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "nginx.conf")
+	if err := ioutil.WriteFile(filename, []byte("# old"), 0600); err != nil {
+		t.Fatalf("Failed to write %v: %v", filename, err)
+	}
+
+	err = writeFileAtomically(filename, []byte("# new"), ConfigFileMode)
+	if err != nil {
+		t.Fatalf("writeFileAtomically() returned an error: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read %v: %v", filename, err)
+	}
+	if string(content) != "# new" {
+		t.Errorf("writeFileAtomically() wrote %q, but expected %q", content, "# new")
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("Failed to stat %v: %v", filename, err)
+	}
+	if info.Mode().Perm() != ConfigFileMode {
+		t.Errorf("writeFileAtomically() created the file with the mode %v, but expected %v", info.Mode().Perm(), os.FileMode(ConfigFileMode))
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %v: %v", dir, err)
+	}
+	if len(files) != 1 {
+		t.Errorf("writeFileAtomically() left %v files in the directory, but expected 1", len(files))
+	}
+}
+
+func TestRemoveStaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ngxc := &Controller{
+		nginxConfdPath:          path.Join(dir, "conf.d"),
+		nginxSecretsPath:        path.Join(dir, "secrets"),
+		nginxMainConfigFileName: path.Join(dir, "nginx.conf"),
+	}
+
+	files := []string{
+		"nginx.conf",
+		"config-version.conf",
+		".nginx.conf.tmp123",
+		"conf.d/default-cafe.conf",
+		"conf.d/.default-cafe.conf.tmp456",
+		"secrets/default",
+		"secrets/default-cafe-secret",
+		"secrets/.default-cafe-secret.tmp789",
+	}
+	expected := []string{
+		"config-version.conf",
+		"nginx.conf",
+		"secrets/default",
+	}
+
+	for _, d := range []string{ngxc.nginxConfdPath, ngxc.nginxSecretsPath} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("Failed to create %v: %v", d, err)
+		}
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(path.Join(dir, f), []byte("#"), 0644); err != nil {
+			t.Fatalf("Failed to write %v: %v", f, err)
+		}
+	}
+
+	err = ngxc.RemoveStaleFiles("default")
+	if err != nil {
+		t.Fatalf("RemoveStaleFiles() returned an error: %v", err)
+	}
+
+	var result []string
+	for _, d := range []string{"", "conf.d", "secrets"} {
+		infos, err := ioutil.ReadDir(path.Join(dir, d))
+		if err != nil {
+			t.Fatalf("Failed to read %v: %v", d, err)
+		}
+		for _, info := range infos {
+			if !info.IsDir() {
+				result = append(result, path.Join(d, info.Name()))
+			}
+		}
+	}
+	sort.Strings(result)
+
+	if len(result) != len(expected) {
+		t.Fatalf("RemoveStaleFiles() kept %v, but expected %v", result, expected)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("RemoveStaleFiles() kept %v, but expected %v", result, expected)
+			break
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package nginx
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// The configuration history keeps the main configuration file and the files of the conf.d directory for the last
+// reloads of NGINX. Every set of files is saved to a directory named after the config version of the reload.
+// The Secret files are not saved, because they hold private keys. Instead, the version has the list of the Secret files
+// with their SHA-256 hashes in the format of sha256sum, which is checked before a rollback.
+const (
+	// DefaultConfigPinTimeout is the time during which the configuration restored by a rollback is kept when only
+	// the endpoints change
+	DefaultConfigPinTimeout = 5 * time.Minute
+
+	historyDirName           = "history"
+	historySecretsHashesFile = "secrets.sha256"
+)
+
+// EnableConfigHistory enables saving the configuration files on every reload. Only the last historySize versions are kept.
+// The history of a previous run of the Ingress controller is removed, because the config versions start from 0 again.
+func (nginx *Controller) EnableConfigHistory(historySize int) error {
+	nginx.historySize = historySize
+
+	if nginx.local {
+		return nil
+	}
+
+	if err := os.RemoveAll(nginx.nginxHistoryPath); err != nil {
+		return fmt.Errorf("Failed to remove %v: %v", nginx.nginxHistoryPath, err)
+	}
+	if err := os.MkdirAll(nginx.nginxHistoryPath, 0755); err != nil {
+		return fmt.Errorf("Failed to create %v: %v", nginx.nginxHistoryPath, err)
+	}
+
+	return nil
+}
+
+func (nginx *Controller) getHistoryVersionPath(version int) string {
+	return path.Join(nginx.nginxHistoryPath, strconv.Itoa(version))
+}
+
+// saveConfigVersion saves the current configuration files as the version and removes the oldest versions
+// beyond the size of the history
+func (nginx *Controller) saveConfigVersion(version int) error {
+	files, err := nginx.readConfigFiles(path.Dir(nginx.nginxMainConfigFileName))
+	if err != nil {
+		return err
+	}
+
+	// the files are written to a temp directory first, so that the history never has an incomplete version
+	tempPath := path.Join(nginx.nginxHistoryPath, fmt.Sprintf(".%v%v", version, tempFileSuffix))
+	if err := os.RemoveAll(tempPath); err != nil {
+		return fmt.Errorf("Failed to remove %v: %v", tempPath, err)
+	}
+	if err := os.MkdirAll(path.Join(tempPath, "conf.d"), 0755); err != nil {
+		return fmt.Errorf("Failed to create %v: %v", tempPath, err)
+	}
+
+	for name, content := range files {
+		if err := writeFileAndSync(path.Join(tempPath, name), content, ConfigFileMode); err != nil {
+			os.RemoveAll(tempPath)
+			return err
+		}
+	}
+
+	// the configuration files reference the Secret files, which can be removed or changed after the version
+	secretHashes, err := readSecretHashes(nginx.nginxSecretsPath)
+	if err != nil {
+		os.RemoveAll(tempPath)
+		return err
+	}
+	if err := writeFileAndSync(path.Join(tempPath, historySecretsHashesFile), formatSecretHashes(secretHashes), ConfigFileMode); err != nil {
+		os.RemoveAll(tempPath)
+		return err
+	}
+
+	versionPath := nginx.getHistoryVersionPath(version)
+	if err := os.RemoveAll(versionPath); err != nil {
+		os.RemoveAll(tempPath)
+		return fmt.Errorf("Failed to remove %v: %v", versionPath, err)
+	}
+	if err := renameAndSync(tempPath, versionPath); err != nil {
+		os.RemoveAll(tempPath)
+		return err
+	}
+
+	versions, err := nginx.getConfigVersions()
+	if err != nil {
+		return err
+	}
+	for len(versions) > nginx.historySize {
+		if err := os.RemoveAll(nginx.getHistoryVersionPath(versions[0])); err != nil {
+			return fmt.Errorf("Failed to remove the config version %v: %v", versions[0], err)
+		}
+		versions = versions[1:]
+	}
+
+	return nil
+}
+
+// readConfigFiles reads the main configuration file and the files of the conf.d directory from the directory with
+// the layout of /etc/nginx. The files are keyed by their path relative to the directory.
+func (nginx *Controller) readConfigFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	mainFileName := path.Base(nginx.nginxMainConfigFileName)
+	content, err := ioutil.ReadFile(path.Join(dir, mainFileName))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %v: %v", mainFileName, err)
+	}
+	files[mainFileName] = content
+
+	confdPath := path.Join(dir, path.Base(nginx.nginxConfdPath))
+	infos, err := ioutil.ReadDir(confdPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %v: %v", confdPath, err)
+	}
+
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || !strings.HasSuffix(info.Name(), ".conf") {
+			continue
+		}
+
+		name := path.Join(path.Base(nginx.nginxConfdPath), info.Name())
+		content, err := ioutil.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %v: %v", name, err)
+		}
+		files[name] = content
+	}
+
+	return files, nil
+}
+
+// readSecretHashes returns the hex-encoded SHA-256 hashes of the files of the secrets directory. The directory can be missing.
+func readSecretHashes(dir string) (map[string]string, error) {
+	hashes := make(map[string]string)
+
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return hashes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %v: %v", dir, err)
+	}
+
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || isTempFile(info.Name()) {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path.Join(dir, info.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %v: %v", info.Name(), err)
+		}
+		hashes[info.Name()] = fmt.Sprintf("%x", sha256.Sum256(content))
+	}
+
+	return hashes, nil
+}
+
+func formatSecretHashes(hashes map[string]string) []byte {
+	var names []string
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&b, "%v  %v\n", hashes[name], name)
+	}
+	return b.Bytes()
+}
+
+func parseSecretHashes(content []byte) (map[string]string, error) {
+	hashes := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Invalid line in %v: %q", historySecretsHashesFile, scanner.Text())
+		}
+		hashes[fields[1]] = fields[0]
+	}
+
+	return hashes, scanner.Err()
+}
+
+// checkSecretFiles checks that the Secret files referenced by a config version still exist. A removed file fails
+// the check, because NGINX can't load the configuration without it, while a changed file, for example, a renewed
+// certificate, is only logged.
+func (nginx *Controller) checkSecretFiles(version int) error {
+	content, err := ioutil.ReadFile(path.Join(nginx.getHistoryVersionPath(version), historySecretsHashesFile))
+	if err != nil {
+		return fmt.Errorf("Failed to read the Secret hashes of the config version %v: %v", version, err)
+	}
+	versionHashes, err := parseSecretHashes(content)
+	if err != nil {
+		return err
+	}
+
+	currentHashes, err := readSecretHashes(nginx.nginxSecretsPath)
+	if err != nil {
+		return err
+	}
+
+	for name, hash := range versionHashes {
+		currentHash, exists := currentHashes[name]
+		if !exists {
+			return fmt.Errorf("The Secret file %v of the config version %v was removed", name, version)
+		}
+		if currentHash != hash {
+			glog.Warningf("The Secret file %v changed after the config version %v, the current file is used", name, version)
+		}
+	}
+
+	return nil
+}
+
+func (nginx *Controller) getConfigVersions() ([]int, error) {
+	infos, err := ioutil.ReadDir(nginx.nginxHistoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %v: %v", nginx.nginxHistoryPath, err)
+	}
+
+	var versions []int
+	for _, info := range infos {
+		version, err := strconv.Atoi(info.Name())
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	return versions, nil
+}
+
+// GetConfigVersions returns the config versions in the history, from the oldest to the newest
+func (nginx *Controller) GetConfigVersions() ([]int, error) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if nginx.historySize == 0 {
+		return nil, fmt.Errorf("The configuration history is disabled")
+	}
+
+	return nginx.getConfigVersions()
+}
+
+func (nginx *Controller) readConfigVersion(version int) (map[string][]byte, error) {
+	versionPath := nginx.getHistoryVersionPath(version)
+	if _, err := os.Stat(versionPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("The config version %v is not in the history", version)
+	}
+
+	return nginx.readConfigFiles(versionPath)
+}
+
+// DiffConfigVersions returns the unified diff of the configuration files of two config versions in the history
+func (nginx *Controller) DiffConfigVersions(from int, to int) (string, error) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if nginx.historySize == 0 {
+		return "", fmt.Errorf("The configuration history is disabled")
+	}
+
+	fromFiles, err := nginx.readConfigVersion(from)
+	if err != nil {
+		return "", err
+	}
+	toFiles, err := nginx.readConfigVersion(to)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for name := range fromFiles {
+		names = append(names, name)
+	}
+	for name := range toFiles {
+		if _, exists := fromFiles[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff strings.Builder
+	for _, name := range names {
+		fromName := path.Join(strconv.Itoa(from), name)
+		if _, exists := fromFiles[name]; !exists {
+			fromName = "/dev/null"
+		}
+		toName := path.Join(strconv.Itoa(to), name)
+		if _, exists := toFiles[name]; !exists {
+			toName = "/dev/null"
+		}
+
+		diff.WriteString(unifiedDiff(fromName, toName, fromFiles[name], toFiles[name]))
+	}
+
+	return diff.String(), nil
+}
+
+// RollbackConfig restores the configuration files of a config version in the history and reloads NGINX.
+// The reload creates a new config version. The restored configuration is pinned: the Controller doesn't change
+// the files until UnpinConfig is called, which happens on the next change of the resources or on the next change
+// of the endpoints after the pin timeout.
+func (nginx *Controller) RollbackConfig(version int) error {
+	if err := nginx.restoreConfigVersion(version); err != nil {
+		return err
+	}
+
+	glog.Infof("Restored the config version %v", version)
+
+	if nginx.local {
+		glog.V(3).Info("local - skipping nginx reload")
+		return nil
+	}
+
+	return nginx.reload()
+}
+
+func (nginx *Controller) restoreConfigVersion(version int) error {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if nginx.historySize == 0 {
+		return fmt.Errorf("The configuration history is disabled")
+	}
+
+	files, err := nginx.readConfigVersion(version)
+	if err != nil {
+		return err
+	}
+
+	currentFiles, err := nginx.readConfigFiles(path.Dir(nginx.nginxMainConfigFileName))
+	if err != nil {
+		return err
+	}
+	if err := nginx.checkSecretFiles(version); err != nil {
+		return err
+	}
+
+	// the files are changed not through the Controller, so the next update of every file must happen
+	nginx.resetFileHashes()
+	nginx.isConfigPinned = true
+	nginx.configPinExpiry = time.Now().Add(nginx.configPinTimeout)
+
+	dir := path.Dir(nginx.nginxMainConfigFileName)
+	for name, content := range files {
+		if err := writeFileAtomically(path.Join(dir, name), content, ConfigFileMode); err != nil {
+			return fmt.Errorf("Failed to restore %v: %v", name, err)
+		}
+	}
+	for name := range currentFiles {
+		if _, exists := files[name]; exists {
+			continue
+		}
+		if err := os.Remove(path.Join(dir, name)); err != nil {
+			return fmt.Errorf("Failed to remove %v: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// IsConfigPinned checks if the configuration restored by a rollback is pinned
+func (nginx *Controller) IsConfigPinned() bool {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	return nginx.isConfigPinned
+}
+
+// SetConfigPinTimeout sets the time during which the configuration restored by a rollback is kept when only
+// the endpoints change
+func (nginx *Controller) SetConfigPinTimeout(timeout time.Duration) {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	nginx.configPinTimeout = timeout
+}
+
+// GetConfigPinTimeLeft returns the time left until the pin of the configuration restored by a rollback expires.
+// It returns 0 if the configuration is not pinned or the pin has expired.
+func (nginx *Controller) GetConfigPinTimeLeft() time.Duration {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if !nginx.isConfigPinned {
+		return 0
+	}
+	if timeLeft := time.Until(nginx.configPinExpiry); timeLeft > 0 {
+		return timeLeft
+	}
+	return 0
+}
+
+// UnpinConfig ends the pinning of the configuration restored by a rollback. The files of the conf.d directory are
+// removed, so that the files of the deleted resources don't come back. The caller must generate the configuration
+// of all the resources again and reload NGINX.
+func (nginx *Controller) UnpinConfig() error {
+	nginx.mutex.Lock()
+	defer nginx.mutex.Unlock()
+
+	if !nginx.isConfigPinned {
+		return nil
+	}
+	nginx.isConfigPinned = false
+	nginx.resetFileHashes()
+
+	infos, err := ioutil.ReadDir(nginx.nginxConfdPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read %v: %v", nginx.nginxConfdPath, err)
+	}
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || !strings.HasSuffix(info.Name(), ".conf") {
+			continue
+		}
+		filename := path.Join(nginx.nginxConfdPath, info.Name())
+		if err := os.Remove(filename); err != nil {
+			return fmt.Errorf("Failed to remove %v: %v", filename, err)
+		}
+	}
+
+	glog.Infof("Unpinned the restored configuration")
+
+	return nil
+}
+
+// isFileChangeSkipped checks if the change of the file must be skipped, because the configuration restored by
+// a rollback is pinned. The caller must hold the mutex.
+func (nginx *Controller) isFileChangeSkipped(filename string) bool {
+	if nginx.isConfigPinned {
+		glog.V(3).Infof("Skipping the change of %v: the configuration restored by a rollback is pinned", filename)
+	}
+	return nginx.isConfigPinned
+}
@@ -0,0 +1,95 @@
+package nginx
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/glog"
+)
+
+// RunConfigHistoryListener runs an http server on the loopback interface to expose the configuration history:
+// GET /configs lists the config versions, GET /configs/diff?from=<version>&to=<version> returns the diff between
+// two versions (to defaults to the current version) and POST /configs/rollback?version=<version> rolls back to a version.
+// The rollback changes the configuration of NGINX without authentication, so it is only served if enableRollback is true.
+func RunConfigHistoryListener(port int, nginx *Controller, enableRollback bool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configs", nginx.handleConfigVersions)
+	mux.HandleFunc("/configs/diff", nginx.handleConfigDiff)
+	if enableRollback {
+		mux.HandleFunc("/configs/rollback", nginx.handleConfigRollback)
+	}
+
+	address := fmt.Sprintf("127.0.0.1:%v", port)
+	glog.Infof("Starting the configuration history listener on: %v", address)
+	glog.Fatal("Error in the configuration history listener server: ", http.ListenAndServe(address, mux))
+}
+
+func (nginx *Controller) handleConfigVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	versions, err := nginx.GetConfigVersions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, version := range versions {
+		fmt.Fprintln(w, version)
+	}
+}
+
+func (nginx *Controller) handleConfigDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid from version: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	to := nginx.GetConfigVersion()
+	if toParam := r.URL.Query().Get("to"); toParam != "" {
+		to, err = strconv.Atoi(toParam)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid to version: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := nginx.DiffConfigVersions(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fmt.Fprint(w, diff)
+}
+
+func (nginx *Controller) handleConfigRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	version, err := strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid version: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = nginx.RollbackConfig(version)
+	if err != nil {
+		glog.Errorf("Error rolling back to the config version %v: %v", version, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Rolled back to the config version %v, the current config version is %v\n", version, nginx.GetConfigVersion())
+}
@@ -0,0 +1,320 @@
+package nginx
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func createTestHistoryController(t *testing.T) (*Controller, string) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+
+	ngxc := &Controller{
+		nginxConfdPath:          path.Join(dir, "conf.d"),
+		nginxSecretsPath:        path.Join(dir, "secrets"),
+		nginxHistoryPath:        path.Join(dir, historyDirName),
+		nginxMainConfigFileName: path.Join(dir, "nginx.conf"),
+		configPinTimeout:        DefaultConfigPinTimeout,
+	}
+
+	for _, dirPath := range []string{ngxc.nginxConfdPath, ngxc.nginxSecretsPath} {
+		if err := os.Mkdir(dirPath, 0755); err != nil {
+			t.Fatalf("Failed to create %v: %v", dirPath, err)
+		}
+	}
+	if err := ngxc.EnableConfigHistory(2); err != nil {
+		t.Fatalf("EnableConfigHistory() returned an error: %v", err)
+	}
+
+	return ngxc, dir
+}
+
+func writeTestConfigFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %v: %v", name, err)
+		}
+	}
+}
+
+func TestSaveConfigVersion(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# main",
+		"conf.d/default-cafe.conf": "# cafe",
+		"secrets/default-cafe":     "# cafe secret",
+	})
+
+	for version := 1; version <= 3; version++ {
+		if err := ngxc.saveConfigVersion(version); err != nil {
+			t.Fatalf("saveConfigVersion(%v) returned an error: %v", version, err)
+		}
+	}
+
+	versions, err := ngxc.GetConfigVersions()
+	if err != nil {
+		t.Fatalf("GetConfigVersions() returned an error: %v", err)
+	}
+	expectedVersions := []int{2, 3}
+	if !reflect.DeepEqual(versions, expectedVersions) {
+		t.Errorf("GetConfigVersions() returned %v, but expected %v", versions, expectedVersions)
+	}
+
+	content, err := ioutil.ReadFile(path.Join(ngxc.getHistoryVersionPath(3), "conf.d/default-cafe.conf"))
+	if err != nil {
+		t.Fatalf("Failed to read the saved config file: %v", err)
+	}
+	if string(content) != "# cafe" {
+		t.Errorf("saveConfigVersion() saved %q, but expected %q", content, "# cafe")
+	}
+
+	// only the hash of the Secret file is saved
+	if _, err := os.Stat(path.Join(ngxc.getHistoryVersionPath(3), "secrets")); !os.IsNotExist(err) {
+		t.Errorf("saveConfigVersion() saved the secrets directory")
+	}
+	content, err = ioutil.ReadFile(path.Join(ngxc.getHistoryVersionPath(3), historySecretsHashesFile))
+	if err != nil {
+		t.Fatalf("Failed to read the saved secret hashes: %v", err)
+	}
+	expectedHashes := fmt.Sprintf("%x  default-cafe\n", sha256.Sum256([]byte("# cafe secret")))
+	if string(content) != expectedHashes {
+		t.Errorf("saveConfigVersion() saved %q, but expected %q", content, expectedHashes)
+	}
+}
+
+func TestDiffConfigVersions(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# main\n",
+		"conf.d/default-cafe.conf": "# cafe\n",
+	})
+	if err := ngxc.saveConfigVersion(1); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+
+	os.Remove(path.Join(dir, "conf.d/default-cafe.conf"))
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":              "# updated main\n",
+		"conf.d/default-tea.conf": "# tea\n",
+	})
+	if err := ngxc.saveConfigVersion(2); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+
+	expected := `--- 1/conf.d/default-cafe.conf
++++ /dev/null
+@@ -1 +0,0 @@
+-# cafe
+--- /dev/null
++++ 2/conf.d/default-tea.conf
+@@ -0,0 +1 @@
++# tea
+--- 1/nginx.conf
++++ 2/nginx.conf
+@@ -1 +1 @@
+-# main
++# updated main
+`
+
+	diff, err := ngxc.DiffConfigVersions(1, 2)
+	if err != nil {
+		t.Fatalf("DiffConfigVersions() returned an error: %v", err)
+	}
+	if diff != expected {
+		t.Errorf("DiffConfigVersions() returned\n%v\nbut expected\n%v", diff, expected)
+	}
+
+	_, err = ngxc.DiffConfigVersions(0, 2)
+	if err == nil || !strings.Contains(err.Error(), "not in the history") {
+		t.Errorf("DiffConfigVersions() returned %v for a version that is not in the history", err)
+	}
+}
+
+func TestRestoreConfigVersion(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# main",
+		"conf.d/default-cafe.conf": "# cafe",
+		"secrets/default-cafe":     "# cafe secret",
+	})
+	if err := ngxc.saveConfigVersion(1); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# updated main",
+		"conf.d/default-cafe.conf": "# updated cafe",
+		"conf.d/default-tea.conf":  "# tea",
+		"secrets/default-cafe":     "# updated cafe secret",
+	})
+
+	if err := ngxc.restoreConfigVersion(1); err != nil {
+		t.Fatalf("restoreConfigVersion() returned an error: %v", err)
+	}
+
+	files, err := ngxc.readConfigFiles(dir)
+	if err != nil {
+		t.Fatalf("readConfigFiles() returned an error: %v", err)
+	}
+	expected := map[string][]byte{
+		"nginx.conf":               []byte("# main"),
+		"conf.d/default-cafe.conf": []byte("# cafe"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("restoreConfigVersion() restored %q, but expected %q", files, expected)
+	}
+
+	// the changed Secret file is kept
+	content, err := ioutil.ReadFile(path.Join(dir, "secrets/default-cafe"))
+	if err != nil {
+		t.Fatalf("Failed to read the secret file: %v", err)
+	}
+	if string(content) != "# updated cafe secret" {
+		t.Errorf("restoreConfigVersion() changed the secret file to %q", content)
+	}
+
+	if !ngxc.IsConfigPinned() {
+		t.Errorf("restoreConfigVersion() didn't pin the restored configuration")
+	}
+
+	if err := ngxc.restoreConfigVersion(5); err == nil {
+		t.Errorf("restoreConfigVersion() returned no error for a version that is not in the history")
+	}
+}
+
+func TestRestoreConfigVersionFailsForRemovedSecret(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# main",
+		"conf.d/default-cafe.conf": "# cafe",
+		"secrets/default-cafe":     "# cafe secret",
+	})
+	if err := ngxc.saveConfigVersion(1); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+
+	os.Remove(path.Join(dir, "secrets/default-cafe"))
+	writeTestConfigFiles(t, dir, map[string]string{
+		"conf.d/default-cafe.conf": "# updated cafe",
+	})
+
+	err := ngxc.restoreConfigVersion(1)
+	if err == nil || !strings.Contains(err.Error(), "default-cafe") {
+		t.Errorf("restoreConfigVersion() returned %v for a version with a removed secret file", err)
+	}
+
+	// the files are not changed
+	content, err := ioutil.ReadFile(path.Join(dir, "conf.d/default-cafe.conf"))
+	if err != nil {
+		t.Fatalf("Failed to read the config file: %v", err)
+	}
+	if string(content) != "# updated cafe" {
+		t.Errorf("restoreConfigVersion() changed the config file to %q", content)
+	}
+	if ngxc.IsConfigPinned() {
+		t.Errorf("restoreConfigVersion() pinned the configuration")
+	}
+}
+
+func TestUnpinConfig(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf":               "# main",
+		"conf.d/default-cafe.conf": "# cafe",
+		"secrets/default-cafe":     "# cafe secret",
+	})
+	if err := ngxc.saveConfigVersion(1); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+
+	os.Remove(path.Join(dir, "conf.d/default-cafe.conf"))
+	writeTestConfigFiles(t, dir, map[string]string{
+		"conf.d/default-tea.conf": "# tea",
+		"secrets/default-tea":     "# tea secret",
+	})
+
+	if err := ngxc.restoreConfigVersion(1); err != nil {
+		t.Fatalf("restoreConfigVersion() returned an error: %v", err)
+	}
+
+	// the changes of the files are skipped while the configuration is pinned
+	ngxc.DeleteIngress("default-cafe")
+	ngxc.DeleteSecretFile("default-tea")
+	if _, err := os.Stat(path.Join(dir, "conf.d/default-cafe.conf")); err != nil {
+		t.Errorf("DeleteIngress() removed the file of the pinned configuration: %v", err)
+	}
+	if _, err := os.Stat(path.Join(dir, "secrets/default-tea")); err != nil {
+		t.Errorf("DeleteSecretFile() removed the secret file of the pinned configuration: %v", err)
+	}
+
+	if err := ngxc.UnpinConfig(); err != nil {
+		t.Fatalf("UnpinConfig() returned an error: %v", err)
+	}
+	if ngxc.IsConfigPinned() {
+		t.Errorf("UnpinConfig() didn't unpin the configuration")
+	}
+
+	files, err := ngxc.readConfigFiles(dir)
+	if err != nil {
+		t.Fatalf("readConfigFiles() returned an error: %v", err)
+	}
+	expected := map[string][]byte{
+		"nginx.conf": []byte("# main"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("UnpinConfig() kept %q, but expected %q", files, expected)
+	}
+}
+
+func TestGetConfigPinTimeLeft(t *testing.T) {
+	ngxc, dir := createTestHistoryController(t)
+	defer os.RemoveAll(dir)
+
+	if timeLeft := ngxc.GetConfigPinTimeLeft(); timeLeft != 0 {
+		t.Errorf("GetConfigPinTimeLeft() returned %v for the configuration that is not pinned", timeLeft)
+	}
+
+	writeTestConfigFiles(t, dir, map[string]string{
+		"nginx.conf": "# main",
+	})
+	if err := ngxc.saveConfigVersion(1); err != nil {
+		t.Fatalf("saveConfigVersion() returned an error: %v", err)
+	}
+	if err := ngxc.restoreConfigVersion(1); err != nil {
+		t.Fatalf("restoreConfigVersion() returned an error: %v", err)
+	}
+
+	if timeLeft := ngxc.GetConfigPinTimeLeft(); timeLeft <= 0 || timeLeft > DefaultConfigPinTimeout {
+		t.Errorf("GetConfigPinTimeLeft() returned %v, but expected at most %v", timeLeft, DefaultConfigPinTimeout)
+	}
+
+	ngxc.SetConfigPinTimeout(0)
+	if err := ngxc.restoreConfigVersion(1); err != nil {
+		t.Fatalf("restoreConfigVersion() returned an error: %v", err)
+	}
+
+	if timeLeft := ngxc.GetConfigPinTimeLeft(); timeLeft != 0 {
+		t.Errorf("GetConfigPinTimeLeft() returned %v for the expired pin", timeLeft)
+	}
+	if !ngxc.IsConfigPinned() {
+		t.Errorf("The configuration with the expired pin must stay pinned until UnpinConfig() is called")
+	}
+}
@@ -0,0 +1,158 @@
+package nginx
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// The main configuration file and the configuration files of the Ingress resources are written to the staging
+// directory and tested with nginx -t before they replace the files in use. A file that fails the test is not promoted,
+// so that NGINX keeps using the last valid file and the reloads for the other resources succeed.
+const (
+	stagingDirName      = "staging"
+	stagingMainFileName = "nginx.conf"
+)
+
+func (nginx *Controller) getStagingConfdPath() string {
+	return path.Join(nginx.nginxStagingPath, "conf.d")
+}
+
+func (nginx *Controller) getStagingIngressConfigFileName(name string) string {
+	return path.Join(nginx.getStagingConfdPath(), name+".conf")
+}
+
+// createStagingDirs creates the staging directory, removing the files that a previous run of the Ingress controller
+// could have left.
+func (nginx *Controller) createStagingDirs() error {
+	if err := os.RemoveAll(nginx.nginxStagingPath); err != nil {
+		return fmt.Errorf("Failed to remove %v: %v", nginx.nginxStagingPath, err)
+	}
+	if err := os.MkdirAll(nginx.getStagingConfdPath(), 0755); err != nil {
+		return fmt.Errorf("Failed to create %v: %v", nginx.getStagingConfdPath(), err)
+	}
+	return nil
+}
+
+// stageMainConfigFile writes the main configuration file to the staging directory, tests it along with the files of
+// the resources and replaces the main configuration file with it. If the test fails, the returned error includes
+// the output of NGINX.
+func (nginx *Controller) stageMainConfigFile(cfg []byte) error {
+	stagingFilename := path.Join(nginx.nginxStagingPath, stagingMainFileName)
+
+	err := writeFileAndSync(stagingFilename, cfg, ConfigFileMode)
+	if err != nil {
+		return err
+	}
+
+	err = nginx.testConfigFile(stagingFilename)
+	if err != nil {
+		if removeErr := os.Remove(stagingFilename); removeErr != nil {
+			glog.Warningf("Failed to delete %v: %v", stagingFilename, removeErr)
+		}
+		return err
+	}
+
+	return renameAndSync(stagingFilename, nginx.nginxMainConfigFileName)
+}
+
+// stageIngressConfigFile writes the configuration file of an Ingress resource to the staging directory, tests it
+// and replaces the file in the conf.d directory with it. If the test fails, the returned error includes the output of NGINX.
+func (nginx *Controller) stageIngressConfigFile(name string, cfg []byte) error {
+	stagingFilename := nginx.getStagingIngressConfigFileName(name)
+
+	err := writeFileAndSync(stagingFilename, cfg, ConfigFileMode)
+	if err != nil {
+		return err
+	}
+
+	err = nginx.testStagingIngressConfigFile(name)
+	if err != nil {
+		if removeErr := os.Remove(stagingFilename); removeErr != nil {
+			glog.Warningf("Failed to delete %v: %v", stagingFilename, removeErr)
+		}
+		return err
+	}
+
+	filename := nginx.getIngressNginxConfigFileName(name)
+	return renameAndSync(stagingFilename, filename)
+}
+
+// testStagingIngressConfigFile tests the staging configuration file of an Ingress resource along with the main
+// configuration file and the files of the other resources. The staging main configuration file is the main
+// configuration file that includes the staging file instead of the file in the conf.d directory.
+func (nginx *Controller) testStagingIngressConfigFile(name string) error {
+	mainCfg, err := ioutil.ReadFile(nginx.nginxMainConfigFileName)
+	if err != nil {
+		return fmt.Errorf("Failed to read %v: %v", nginx.nginxMainConfigFileName, err)
+	}
+
+	confdInclude := fmt.Sprintf("include %v/*.conf;", nginx.nginxConfdPath)
+	if !bytes.Contains(mainCfg, []byte(confdInclude)) {
+		// a custom main template can include the files differently
+		glog.Warningf("The main NGINX config file doesn't have %q, skipping the test of the config file of %v", confdInclude, name)
+		return nil
+	}
+
+	includes, err := nginx.getStagingIncludes(name)
+	if err != nil {
+		return err
+	}
+	stagingMainCfg := bytes.Replace(mainCfg, []byte(confdInclude), []byte(includes), 1)
+
+	stagingMainFilename := path.Join(nginx.nginxStagingPath, stagingMainFileName)
+	err = writeFileAndSync(stagingMainFilename, stagingMainCfg, ConfigFileMode)
+	if err != nil {
+		return err
+	}
+
+	return nginx.testConfigFile(stagingMainFilename)
+}
+
+// getStagingIncludes returns the include directives for the files in the conf.d directory, with the staging file of
+// the Ingress resource instead of its file in the conf.d directory
+func (nginx *Controller) getStagingIncludes(name string) (string, error) {
+	files, err := ioutil.ReadDir(nginx.nginxConfdPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %v: %v", nginx.nginxConfdPath, err)
+	}
+
+	filename := nginx.getIngressNginxConfigFileName(name)
+
+	var includes []string
+	for _, file := range files {
+		confdFilename := path.Join(nginx.nginxConfdPath, file.Name())
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".conf") || confdFilename == filename {
+			continue
+		}
+		includes = append(includes, fmt.Sprintf("include %v;", confdFilename))
+	}
+	includes = append(includes, fmt.Sprintf("include %v;", nginx.getStagingIngressConfigFileName(name)))
+
+	return strings.Join(includes, "\n    "), nil
+}
+
+// testConfigFile runs nginx -t for the main configuration file
+func (nginx *Controller) testConfigFile(filename string) error {
+	var stderr bytes.Buffer
+
+	glog.V(3).Infof("Testing %v", filename)
+
+	cmd := exec.Command(nginx.nginxBinaryPath, "-t", "-q", "-c", filename)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("Invalid NGINX configuration: %v", strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("Failed to test NGINX configuration %v: %v", filename, err)
+	}
+
+	return nil
+}
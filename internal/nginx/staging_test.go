@@ -0,0 +1,168 @@
+package nginx
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func createTestController(t *testing.T, nginxBinary string) (*Controller, string) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+
+	nginxBinaryPath := path.Join(dir, "nginx")
+	if err := ioutil.WriteFile(nginxBinaryPath, []byte(nginxBinary), 0755); err != nil {
+		t.Fatalf("Failed to write %v: %v", nginxBinaryPath, err)
+	}
+
+	ngxc := &Controller{
+		nginxConfdPath:          path.Join(dir, "conf.d"),
+		nginxStagingPath:        path.Join(dir, stagingDirName),
+		nginxMainConfigFileName: path.Join(dir, "nginx.conf"),
+		nginxBinaryPath:         nginxBinaryPath,
+	}
+
+	if err := os.Mkdir(ngxc.nginxConfdPath, 0755); err != nil {
+		t.Fatalf("Failed to create %v: %v", ngxc.nginxConfdPath, err)
+	}
+	if err := ngxc.createStagingDirs(); err != nil {
+		t.Fatalf("createStagingDirs() returned an error: %v", err)
+	}
+
+	mainCfg := "http {\n    include " + ngxc.nginxConfdPath + "/*.conf;\n}\n"
+	if err := ioutil.WriteFile(ngxc.nginxMainConfigFileName, []byte(mainCfg), 0644); err != nil {
+		t.Fatalf("Failed to write %v: %v", ngxc.nginxMainConfigFileName, err)
+	}
+
+	for _, name := range []string{"default-cafe", "default-tea"} {
+		if err := ioutil.WriteFile(ngxc.getIngressNginxConfigFileName(name), []byte("# "+name), 0644); err != nil {
+			t.Fatalf("Failed to write the config file of %v: %v", name, err)
+		}
+	}
+
+	return ngxc, dir
+}
+
+func TestUpdateIngressConfigFile(t *testing.T) {
+	ngxc, dir := createTestController(t, "#!/bin/sh\nexit 0\n")
+	defer os.RemoveAll(dir)
+
+	err := ngxc.UpdateIngressConfigFile("default-cafe", []byte("# updated"))
+	if err != nil {
+		t.Fatalf("UpdateIngressConfigFile() returned an error: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(ngxc.getIngressNginxConfigFileName("default-cafe"))
+	if err != nil {
+		t.Fatalf("Failed to read the config file: %v", err)
+	}
+	if string(content) != "# updated" {
+		t.Errorf("UpdateIngressConfigFile() wrote %q, but expected %q", content, "# updated")
+	}
+
+	// the staging main config file includes the staging file instead of the file in the conf.d directory
+	stagingMainCfg, err := ioutil.ReadFile(path.Join(ngxc.nginxStagingPath, stagingMainFileName))
+	if err != nil {
+		t.Fatalf("Failed to read the staging main config file: %v", err)
+	}
+	expectedIncludes := []string{
+		"include " + ngxc.getIngressNginxConfigFileName("default-tea") + ";",
+		"include " + ngxc.getStagingIngressConfigFileName("default-cafe") + ";",
+	}
+	for _, include := range expectedIncludes {
+		if !strings.Contains(string(stagingMainCfg), include) {
+			t.Errorf("The staging main config file %q doesn't include %q", stagingMainCfg, include)
+		}
+	}
+	if strings.Contains(string(stagingMainCfg), ngxc.getIngressNginxConfigFileName("default-cafe")) {
+		t.Errorf("The staging main config file %q includes the config file in the conf.d directory", stagingMainCfg)
+	}
+}
+
+func TestUpdateIngressConfigFileWithInvalidConfig(t *testing.T) {
+	nginxBinary := "#!/bin/sh\necho 'nginx: [emerg] unknown directive \"foo\"' >&2\nexit 1\n"
+	ngxc, dir := createTestController(t, nginxBinary)
+	defer os.RemoveAll(dir)
+
+	err := ngxc.UpdateIngressConfigFile("default-cafe", []byte("foo;"))
+	if err == nil {
+		t.Fatalf("UpdateIngressConfigFile() returned no error for an invalid config")
+	}
+	if !strings.Contains(err.Error(), `unknown directive "foo"`) {
+		t.Errorf("UpdateIngressConfigFile() returned the error %q without the output of NGINX", err)
+	}
+
+	// the last valid file is kept
+	content, err := ioutil.ReadFile(ngxc.getIngressNginxConfigFileName("default-cafe"))
+	if err != nil {
+		t.Fatalf("Failed to read the config file: %v", err)
+	}
+	if string(content) != "# default-cafe" {
+		t.Errorf("UpdateIngressConfigFile() replaced the config file with %q", content)
+	}
+
+	// a new Ingress with an invalid config file is not added
+	err = ngxc.UpdateIngressConfigFile("default-coffee", []byte("foo;"))
+	if err == nil {
+		t.Fatalf("UpdateIngressConfigFile() returned no error for an invalid config")
+	}
+	if _, err := os.Stat(ngxc.getIngressNginxConfigFileName("default-coffee")); !os.IsNotExist(err) {
+		t.Errorf("UpdateIngressConfigFile() created the config file of an invalid config")
+	}
+	if _, err := os.Stat(ngxc.getStagingIngressConfigFileName("default-coffee")); !os.IsNotExist(err) {
+		t.Errorf("UpdateIngressConfigFile() didn't remove the staging file of an invalid config")
+	}
+}
+
+func TestUpdateMainConfigFile(t *testing.T) {
+	ngxc, dir := createTestController(t, "#!/bin/sh\nexit 0\n")
+	defer os.RemoveAll(dir)
+
+	err := ngxc.UpdateMainConfigFile([]byte("# updated main"))
+	if err != nil {
+		t.Fatalf("UpdateMainConfigFile() returned an error: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(ngxc.nginxMainConfigFileName)
+	if err != nil {
+		t.Fatalf("Failed to read the main config file: %v", err)
+	}
+	if string(content) != "# updated main" {
+		t.Errorf("UpdateMainConfigFile() wrote %q, but expected %q", content, "# updated main")
+	}
+}
+
+func TestUpdateMainConfigFileWithInvalidConfig(t *testing.T) {
+	nginxBinary := "#!/bin/sh\necho 'nginx: [emerg] duplicate zone \"ing_default_cafe\"' >&2\nexit 1\n"
+	ngxc, dir := createTestController(t, nginxBinary)
+	defer os.RemoveAll(dir)
+
+	prevContent, err := ioutil.ReadFile(ngxc.nginxMainConfigFileName)
+	if err != nil {
+		t.Fatalf("Failed to read the main config file: %v", err)
+	}
+
+	err = ngxc.UpdateMainConfigFile([]byte("foo;"))
+	if err == nil {
+		t.Fatalf("UpdateMainConfigFile() returned no error for an invalid config")
+	}
+	if !strings.Contains(err.Error(), `duplicate zone "ing_default_cafe"`) {
+		t.Errorf("UpdateMainConfigFile() returned the error %q without the output of NGINX", err)
+	}
+
+	// the last valid file is kept
+	content, err := ioutil.ReadFile(ngxc.nginxMainConfigFileName)
+	if err != nil {
+		t.Fatalf("Failed to read the main config file: %v", err)
+	}
+	if string(content) != string(prevContent) {
+		t.Errorf("UpdateMainConfigFile() replaced the main config file with %q", content)
+	}
+	if _, err := os.Stat(path.Join(ngxc.nginxStagingPath, stagingMainFileName)); !os.IsNotExist(err) {
+		t.Errorf("UpdateMainConfigFile() didn't remove the staging file of an invalid config")
+	}
+}
@@ -0,0 +1,21 @@
+package validation
+
+import "testing"
+
+func TestValidateCIDRorIP(t *testing.T) {
+	badCIDRs := []string{"localhost", "thing", "~", "!!!", "", " ", "-1"}
+	for _, badCIDR := range badCIDRs {
+		err := ValidateCIDRorIP(badCIDR)
+		if err == nil {
+			t.Errorf(`Expected error for invalid CIDR "%v"\n`, badCIDR)
+		}
+	}
+
+	goodCIDRs := []string{"0.0.0.0/32", "0.0.0.0/0", "127.0.0.1/32", "127.0.0.0/24", "23.232.65.42"}
+	for _, goodCIDR := range goodCIDRs {
+		err := ValidateCIDRorIP(goodCIDR)
+		if err != nil {
+			t.Errorf("Error for valid CIDR: %v err: %v\n", goodCIDR, err)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=k8s.nginx.org
+
+// Package v1alpha1 contains the v1alpha1 version of the k8s.nginx.org API group,
+// which holds the custom resources of the Ingress controller.
+package v1alpha1
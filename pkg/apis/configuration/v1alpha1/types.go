@@ -0,0 +1,122 @@
+package v1alpha1
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StateValid is the state of a resource that was accepted by the Ingress controller.
+	StateValid = "Valid"
+	// StateInvalid is the state of a resource that was rejected by the Ingress controller.
+	StateInvalid = "Invalid"
+
+	// ProtocolTCP is the TCP protocol of a TransportServer listener.
+	ProtocolTCP = "TCP"
+	// ProtocolUDP is the UDP protocol of a TransportServer listener.
+	ProtocolUDP = "UDP"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VirtualServer defines the VirtualServer resource.
+type VirtualServer struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualServerSpec   `json:"spec"`
+	Status VirtualServerStatus `json:"status"`
+}
+
+// VirtualServerSpec is the spec of the VirtualServer resource.
+type VirtualServerSpec struct {
+	Host      string     `json:"host"`
+	TLS       *TLS       `json:"tls"`
+	Upstreams []Upstream `json:"upstreams"`
+	Routes    []Route    `json:"routes"`
+}
+
+// TLS defines TLS configuration for a VirtualServer.
+type TLS struct {
+	Secret string `json:"secret"`
+}
+
+// Upstream defines an upstream.
+type Upstream struct {
+	Name        string `json:"name"`
+	Service     string `json:"service"`
+	Port        uint16 `json:"port"`
+	LBMethod    string `json:"lb-method"`
+	FailTimeout string `json:"fail-timeout"`
+	MaxFails    *int   `json:"max-fails"`
+}
+
+// Route defines a route.
+type Route struct {
+	Path     string `json:"path"`
+	Upstream string `json:"upstream"`
+}
+
+// VirtualServerStatus defines the status of the VirtualServer resource.
+type VirtualServerStatus struct {
+	State   string `json:"state"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VirtualServerList is a list of the VirtualServer resources.
+type VirtualServerList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []VirtualServer `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TransportServer defines the TransportServer resource.
+type TransportServer struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TransportServerSpec   `json:"spec"`
+	Status TransportServerStatus `json:"status"`
+}
+
+// TransportServerSpec is the spec of the TransportServer resource.
+type TransportServerSpec struct {
+	Listener TransportServerListener `json:"listener"`
+	Upstream TransportServerUpstream `json:"upstream"`
+}
+
+// TransportServerListener defines the port and the protocol NGINX listens on for a TransportServer.
+type TransportServerListener struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// TransportServerUpstream defines the service the traffic of a TransportServer is load balanced to.
+type TransportServerUpstream struct {
+	Service string `json:"service"`
+	Port    uint16 `json:"port"`
+}
+
+// TransportServerStatus defines the status of the TransportServer resource.
+type TransportServerStatus struct {
+	State   string `json:"state"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TransportServerList is a list of the TransportServer resources.
+type TransportServerList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []TransportServer `json:"items"`
+}
@@ -0,0 +1,305 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/nginxinc/kubernetes-ingress/internal/configs"
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateVirtualServer validates a VirtualServer.
+func ValidateVirtualServer(virtualServer *v1alpha1.VirtualServer, isPlus bool) error {
+	allErrs := validateVirtualServerSpec(&virtualServer.Spec, field.NewPath("spec"), isPlus)
+	return allErrs.ToAggregate()
+}
+
+// validateVirtualServerSpec validates a VirtualServerSpec.
+func validateVirtualServerSpec(spec *v1alpha1.VirtualServerSpec, fieldPath *field.Path, isPlus bool) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateHost(spec.Host, fieldPath.Child("host"))...)
+	allErrs = append(allErrs, validateTLS(spec.TLS, fieldPath.Child("tls"))...)
+
+	upstreamErrs, upstreamNames := validateUpstreams(spec.Upstreams, fieldPath.Child("upstreams"), isPlus)
+	allErrs = append(allErrs, upstreamErrs...)
+
+	allErrs = append(allErrs, validateRoutes(spec.Routes, fieldPath.Child("routes"), upstreamNames)...)
+
+	return allErrs
+}
+
+func validateHost(host string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if host == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(host) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, host, msg))
+	}
+
+	return allErrs
+}
+
+func validateTLS(tls *v1alpha1.TLS, fieldPath *field.Path) field.ErrorList {
+	if tls == nil {
+		// valid case - tls is not defined
+		return field.ErrorList{}
+	}
+
+	return validateSecretName(tls.Secret, fieldPath.Child("secret"))
+}
+
+func validateSecretName(name string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if name == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, name, msg))
+	}
+
+	return allErrs
+}
+
+func validateUpstreams(upstreams []v1alpha1.Upstream, fieldPath *field.Path, isPlus bool) (allErrs field.ErrorList, upstreamNames sets.String) {
+	allErrs = field.ErrorList{}
+	upstreamNames = sets.String{}
+
+	for i, u := range upstreams {
+		idxPath := fieldPath.Index(i)
+
+		upstreamErrors := validateUpstreamName(u.Name, idxPath.Child("name"))
+		if len(upstreamErrors) > 0 {
+			allErrs = append(allErrs, upstreamErrors...)
+		} else if upstreamNames.Has(u.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), u.Name))
+		} else {
+			upstreamNames.Insert(u.Name)
+		}
+
+		allErrs = append(allErrs, validateServiceName(u.Service, idxPath.Child("service"))...)
+		allErrs = append(allErrs, validateServicePort(u.Port, idxPath.Child("port"))...)
+		allErrs = append(allErrs, validateLBMethod(u.LBMethod, idxPath.Child("lb-method"), isPlus)...)
+		allErrs = append(allErrs, validateTime(u.FailTimeout, idxPath.Child("fail-timeout"))...)
+		allErrs = append(allErrs, validatePositiveIntOrZero(u.MaxFails, idxPath.Child("max-fails"))...)
+	}
+
+	return allErrs, upstreamNames
+}
+
+// validateUpstreamName checks if an upstream name is valid.
+// The upstream name is used as a part of the NGINX upstream name, so we require it to be a valid DNS label.
+func validateUpstreamName(name string, fieldPath *field.Path) field.ErrorList {
+	return validateDNS1123Label(name, fieldPath)
+}
+
+// validateServiceName checks if a service name is valid.
+// It performs the same validation as ValidateServiceName from k8s.io/kubernetes/pkg/apis/core/validation/validation.go.
+func validateServiceName(name string, fieldPath *field.Path) field.ErrorList {
+	return validateDNS1035Label(name, fieldPath)
+}
+
+func validateDNS1123Label(name string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if name == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	for _, msg := range validation.IsDNS1123Label(name) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, name, msg))
+	}
+
+	return allErrs
+}
+
+func validateDNS1035Label(name string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if name == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	for _, msg := range validation.IsDNS1035Label(name) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, name, msg))
+	}
+
+	return allErrs
+}
+
+func validateServicePort(port uint16, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for _, msg := range validation.IsValidPortNum(int(port)) {
+		allErrs = append(allErrs, field.Invalid(fieldPath, port, msg))
+	}
+
+	return allErrs
+}
+
+func validateLBMethod(lBMethod string, fieldPath *field.Path, isPlus bool) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if lBMethod == "" {
+		// valid case - the default load balancing method will be used
+		return allErrs
+	}
+
+	var err error
+	if isPlus {
+		_, err = configs.ParseLBMethodForPlus(lBMethod)
+	} else {
+		_, err = configs.ParseLBMethod(lBMethod)
+	}
+	if err != nil {
+		return append(allErrs, field.Invalid(fieldPath, lBMethod, err.Error()))
+	}
+
+	return allErrs
+}
+
+func validateTime(time string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if time == "" {
+		// valid case - the default value will be used
+		return allErrs
+	}
+
+	if _, err := configs.ParseTime(time); err != nil {
+		return append(allErrs, field.Invalid(fieldPath, time, err.Error()))
+	}
+
+	return allErrs
+}
+
+func validatePositiveIntOrZero(n *int, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if n == nil {
+		// valid case - the default value will be used
+		return allErrs
+	}
+
+	if *n < 0 {
+		return append(allErrs, field.Invalid(fieldPath, *n, "must be positive or zero"))
+	}
+
+	return allErrs
+}
+
+func validateRoutes(routes []v1alpha1.Route, fieldPath *field.Path, upstreamNames sets.String) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allPaths := sets.String{}
+
+	for i, r := range routes {
+		idxPath := fieldPath.Index(i)
+
+		pathErrs := validatePath(r.Path, idxPath.Child("path"))
+		if len(pathErrs) > 0 {
+			allErrs = append(allErrs, pathErrs...)
+		} else if allPaths.Has(r.Path) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), r.Path))
+		} else {
+			allPaths.Insert(r.Path)
+		}
+
+		allErrs = append(allErrs, validateReferencedUpstream(r.Upstream, idxPath.Child("upstream"), upstreamNames)...)
+	}
+
+	return allErrs
+}
+
+// validatePath checks that the path of a route is a valid NGINX prefix location.
+func validatePath(path string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if path == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return append(allErrs, field.Invalid(fieldPath, path, "must start with '/'"))
+	}
+
+	if strings.ContainsAny(path, " \t\n;{}") {
+		return append(allErrs, field.Invalid(fieldPath, path, "must not contain whitespace, ';', '{' or '}'"))
+	}
+
+	return allErrs
+}
+
+func validateReferencedUpstream(name string, fieldPath *field.Path, upstreamNames sets.String) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	upstreamErrs := validateUpstreamName(name, fieldPath)
+	if len(upstreamErrs) > 0 {
+		return append(allErrs, upstreamErrs...)
+	}
+
+	if !upstreamNames.Has(name) {
+		return append(allErrs, field.NotFound(fieldPath, name))
+	}
+
+	return allErrs
+}
+
+// ValidateTransportServer validates a TransportServer.
+func ValidateTransportServer(transportServer *v1alpha1.TransportServer) error {
+	allErrs := validateTransportServerSpec(&transportServer.Spec, field.NewPath("spec"))
+	return allErrs.ToAggregate()
+}
+
+func validateTransportServerSpec(spec *v1alpha1.TransportServerSpec, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateTransportServerListener(&spec.Listener, fieldPath.Child("listener"))...)
+	allErrs = append(allErrs, validateTransportServerUpstream(&spec.Upstream, fieldPath.Child("upstream"))...)
+
+	return allErrs
+}
+
+func validateTransportServerListener(listener *v1alpha1.TransportServerListener, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for _, msg := range validation.IsValidPortNum(listener.Port) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("port"), listener.Port, msg))
+	}
+
+	allErrs = append(allErrs, validateListenerProtocol(listener.Protocol, fieldPath.Child("protocol"))...)
+
+	return allErrs
+}
+
+var validListenerProtocols = []string{v1alpha1.ProtocolTCP, v1alpha1.ProtocolUDP}
+
+func validateListenerProtocol(protocol string, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if protocol == "" {
+		return append(allErrs, field.Required(fieldPath, ""))
+	}
+
+	if !sets.NewString(validListenerProtocols...).Has(protocol) {
+		return append(allErrs, field.NotSupported(fieldPath, protocol, validListenerProtocols))
+	}
+
+	return allErrs
+}
+
+func validateTransportServerUpstream(upstream *v1alpha1.TransportServerUpstream, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateServiceName(upstream.Service, fieldPath.Child("service"))...)
+	allErrs = append(allErrs, validateServicePort(upstream.Port, fieldPath.Child("port"))...)
+
+	return allErrs
+}
@@ -0,0 +1,457 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func createPointerFromInt(n int) *int {
+	return &n
+}
+
+func TestValidateVirtualServer(t *testing.T) {
+	virtualServer := v1alpha1.VirtualServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "cafe",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.VirtualServerSpec{
+			Host: "example.com",
+			TLS: &v1alpha1.TLS{
+				Secret: "abc",
+			},
+			Upstreams: []v1alpha1.Upstream{
+				{
+					Name:        "first",
+					Service:     "service-1",
+					Port:        80,
+					LBMethod:    "random",
+					MaxFails:    createPointerFromInt(3),
+					FailTimeout: "30s",
+				},
+				{
+					Name:    "second",
+					Service: "service-2",
+					Port:    80,
+				},
+			},
+			Routes: []v1alpha1.Route{
+				{
+					Path:     "/first",
+					Upstream: "first",
+				},
+				{
+					Path:     "/second",
+					Upstream: "second",
+				},
+			},
+		},
+	}
+
+	err := ValidateVirtualServer(&virtualServer, false)
+	if err != nil {
+		t.Errorf("ValidateVirtualServer() returned error %v for valid input %v", err, virtualServer)
+	}
+}
+
+func TestValidateHost(t *testing.T) {
+	validHosts := []string{
+		"hello",
+		"example.com",
+		"hello-world-1",
+	}
+
+	for _, h := range validHosts {
+		allErrs := validateHost(h, field.NewPath("host"))
+		if len(allErrs) > 0 {
+			t.Errorf("validateHost(%q) returned errors %v for valid input", h, allErrs)
+		}
+	}
+
+	invalidHosts := []string{
+		"",
+		"*",
+		"..",
+		".example.com",
+		"-hello-world-1",
+	}
+
+	for _, h := range invalidHosts {
+		allErrs := validateHost(h, field.NewPath("host"))
+		if len(allErrs) == 0 {
+			t.Errorf("validateHost(%q) returned no errors for invalid input", h)
+		}
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	validTLSes := []*v1alpha1.TLS{
+		nil,
+		{
+			Secret: "my-secret",
+		},
+	}
+
+	for _, tls := range validTLSes {
+		allErrs := validateTLS(tls, field.NewPath("tls"))
+		if len(allErrs) > 0 {
+			t.Errorf("validateTLS() returned errors %v for valid input %v", allErrs, tls)
+		}
+	}
+
+	invalidTLSes := []*v1alpha1.TLS{
+		{
+			Secret: "",
+		},
+		{
+			Secret: "-",
+		},
+		{
+			Secret: "a/b",
+		},
+	}
+
+	for _, tls := range invalidTLSes {
+		allErrs := validateTLS(tls, field.NewPath("tls"))
+		if len(allErrs) == 0 {
+			t.Errorf("validateTLS() returned no errors for invalid input %v", tls)
+		}
+	}
+}
+
+func TestValidateUpstreams(t *testing.T) {
+	tests := []struct {
+		upstreams             []v1alpha1.Upstream
+		expectedUpstreamNames sets.String
+		msg                   string
+	}{
+		{
+			upstreams:             []v1alpha1.Upstream{},
+			expectedUpstreamNames: sets.String{},
+			msg:                   "no upstreams",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:    "upstream1",
+					Service: "test-1",
+					Port:    80,
+				},
+				{
+					Name:        "upstream2",
+					Service:     "test-2",
+					Port:        80,
+					LBMethod:    "least_conn",
+					MaxFails:    createPointerFromInt(0),
+					FailTimeout: "1m",
+				},
+			},
+			expectedUpstreamNames: map[string]sets.Empty{
+				"upstream1": {},
+				"upstream2": {},
+			},
+			msg: "2 valid upstreams",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs, resultUpstreamNames := validateUpstreams(test.upstreams, field.NewPath("upstreams"), false)
+		if len(allErrs) > 0 {
+			t.Errorf("validateUpstreams() returned errors %v for valid input for the case of %s", allErrs, test.msg)
+		}
+		if !resultUpstreamNames.Equal(test.expectedUpstreamNames) {
+			t.Errorf("validateUpstreams() returned %v expected %v for the case of %s", resultUpstreamNames, test.expectedUpstreamNames, test.msg)
+		}
+	}
+}
+
+func TestValidateUpstreamsFails(t *testing.T) {
+	tests := []struct {
+		upstreams []v1alpha1.Upstream
+		isPlus    bool
+		msg       string
+	}{
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:    "@upstream1",
+					Service: "test-1",
+					Port:    80,
+				},
+			},
+			msg: "invalid upstream name",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:    "upstream1",
+					Service: "test-1",
+					Port:    80,
+				},
+				{
+					Name:    "upstream1",
+					Service: "test-2",
+					Port:    80,
+				},
+			},
+			msg: "duplicated upstreams",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:    "upstream1",
+					Service: "$test",
+					Port:    80,
+				},
+			},
+			msg: "invalid service",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:    "upstream1",
+					Service: "test-1",
+					Port:    0,
+				},
+			},
+			msg: "invalid port",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:     "upstream1",
+					Service:  "test-1",
+					Port:     80,
+					LBMethod: "least_time header",
+				},
+			},
+			isPlus: false,
+			msg:    "NGINX Plus load balancing method for NGINX",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:     "upstream1",
+					Service:  "test-1",
+					Port:     80,
+					MaxFails: createPointerFromInt(-1),
+				},
+			},
+			msg: "negative max-fails",
+		},
+		{
+			upstreams: []v1alpha1.Upstream{
+				{
+					Name:        "upstream1",
+					Service:     "test-1",
+					Port:        80,
+					FailTimeout: "10 seconds",
+				},
+			},
+			msg: "invalid fail-timeout",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs, _ := validateUpstreams(test.upstreams, field.NewPath("upstreams"), test.isPlus)
+		if len(allErrs) == 0 {
+			t.Errorf("validateUpstreams() returned no errors for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	upstreamNames := sets.NewString("test")
+
+	routes := []v1alpha1.Route{
+		{
+			Path:     "/",
+			Upstream: "test",
+		},
+		{
+			Path:     "/tea",
+			Upstream: "test",
+		},
+	}
+
+	allErrs := validateRoutes(routes, field.NewPath("routes"), upstreamNames)
+	if len(allErrs) > 0 {
+		t.Errorf("validateRoutes() returned errors %v for valid input", allErrs)
+	}
+}
+
+func TestValidateRoutesFails(t *testing.T) {
+	upstreamNames := sets.NewString("test")
+
+	tests := []struct {
+		routes []v1alpha1.Route
+		msg    string
+	}{
+		{
+			routes: []v1alpha1.Route{
+				{
+					Path:     "",
+					Upstream: "test",
+				},
+			},
+			msg: "empty path",
+		},
+		{
+			routes: []v1alpha1.Route{
+				{
+					Path:     "tea",
+					Upstream: "test",
+				},
+			},
+			msg: "path without leading slash",
+		},
+		{
+			routes: []v1alpha1.Route{
+				{
+					Path:     "/tea;}",
+					Upstream: "test",
+				},
+			},
+			msg: "path with special characters",
+		},
+		{
+			routes: []v1alpha1.Route{
+				{
+					Path:     "/",
+					Upstream: "test",
+				},
+				{
+					Path:     "/",
+					Upstream: "test",
+				},
+			},
+			msg: "duplicated paths",
+		},
+		{
+			routes: []v1alpha1.Route{
+				{
+					Path:     "/",
+					Upstream: "coffee",
+				},
+			},
+			msg: "non-existing upstream",
+		},
+	}
+
+	for _, test := range tests {
+		allErrs := validateRoutes(test.routes, field.NewPath("routes"), upstreamNames)
+		if len(allErrs) == 0 {
+			t.Errorf("validateRoutes() returned no errors for the case of %s", test.msg)
+		}
+	}
+}
+
+func TestValidateTransportServer(t *testing.T) {
+	transportServer := v1alpha1.TransportServer{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.TransportServerSpec{
+			Listener: v1alpha1.TransportServerListener{
+				Port:     5353,
+				Protocol: "UDP",
+			},
+			Upstream: v1alpha1.TransportServerUpstream{
+				Service: "coredns",
+				Port:    53,
+			},
+		},
+	}
+
+	err := ValidateTransportServer(&transportServer)
+	if err != nil {
+		t.Errorf("ValidateTransportServer() returned error %v for valid input %v", err, transportServer)
+	}
+}
+
+func TestValidateTransportServerFails(t *testing.T) {
+	tests := []struct {
+		spec v1alpha1.TransportServerSpec
+		msg  string
+	}{
+		{
+			spec: v1alpha1.TransportServerSpec{
+				Listener: v1alpha1.TransportServerListener{
+					Port:     0,
+					Protocol: "TCP",
+				},
+				Upstream: v1alpha1.TransportServerUpstream{
+					Service: "test",
+					Port:    80,
+				},
+			},
+			msg: "invalid listener port",
+		},
+		{
+			spec: v1alpha1.TransportServerSpec{
+				Listener: v1alpha1.TransportServerListener{
+					Port: 5353,
+				},
+				Upstream: v1alpha1.TransportServerUpstream{
+					Service: "test",
+					Port:    80,
+				},
+			},
+			msg: "missing listener protocol",
+		},
+		{
+			spec: v1alpha1.TransportServerSpec{
+				Listener: v1alpha1.TransportServerListener{
+					Port:     5353,
+					Protocol: "tcp",
+				},
+				Upstream: v1alpha1.TransportServerUpstream{
+					Service: "test",
+					Port:    80,
+				},
+			},
+			msg: "unsupported listener protocol",
+		},
+		{
+			spec: v1alpha1.TransportServerSpec{
+				Listener: v1alpha1.TransportServerListener{
+					Port:     5353,
+					Protocol: "TCP",
+				},
+				Upstream: v1alpha1.TransportServerUpstream{
+					Service: "",
+					Port:    80,
+				},
+			},
+			msg: "missing upstream service",
+		},
+		{
+			spec: v1alpha1.TransportServerSpec{
+				Listener: v1alpha1.TransportServerListener{
+					Port:     5353,
+					Protocol: "TCP",
+				},
+				Upstream: v1alpha1.TransportServerUpstream{
+					Service: "test",
+					Port:    0,
+				},
+			},
+			msg: "invalid upstream port",
+		},
+	}
+
+	for _, test := range tests {
+		transportServer := v1alpha1.TransportServer{
+			Spec: test.spec,
+		}
+		err := ValidateTransportServer(&transportServer)
+		if err == nil {
+			t.Errorf("ValidateTransportServer() returned no error for the case of %s", test.msg)
+		}
+	}
+}
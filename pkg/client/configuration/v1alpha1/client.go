@@ -0,0 +1,79 @@
+// Package v1alpha1 provides a client for the k8s.nginx.org/v1alpha1 API group.
+package v1alpha1
+
+import (
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+)
+
+// Scheme contains the types of the k8s.nginx.org API group.
+var Scheme = runtime.NewScheme()
+
+// Codecs provides access to encoding and decoding for the Scheme.
+var Codecs = serializer.NewCodecFactory(Scheme)
+
+// ParameterCodec handles versioning of objects that are converted to query parameters.
+var ParameterCodec = runtime.NewParameterCodec(Scheme)
+
+func init() {
+	meta_v1.AddToGroupVersion(Scheme, schema.GroupVersion{Version: "v1"})
+	if err := v1alpha1.AddToScheme(Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// ConfigurationV1alpha1Client is a client for the k8s.nginx.org/v1alpha1 API group.
+type ConfigurationV1alpha1Client struct {
+	restClient rest.Interface
+}
+
+// NewForConfig creates a new ConfigurationV1alpha1Client for the given config.
+func NewForConfig(c *rest.Config) (*ConfigurationV1alpha1Client, error) {
+	config := *c
+	config.GroupVersion = &v1alpha1.SchemeGroupVersion
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: Codecs}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigurationV1alpha1Client{restClient: client}, nil
+}
+
+// New creates a new ConfigurationV1alpha1Client for the given RESTClient.
+func New(c rest.Interface) *ConfigurationV1alpha1Client {
+	return &ConfigurationV1alpha1Client{restClient: c}
+}
+
+// RESTClient returns a RESTClient that is used to communicate with API server by this client implementation.
+func (c *ConfigurationV1alpha1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
+
+// VirtualServers returns a VirtualServerInterface for the namespace.
+func (c *ConfigurationV1alpha1Client) VirtualServers(namespace string) VirtualServerInterface {
+	return &virtualServers{
+		client: c.restClient,
+		ns:     namespace,
+	}
+}
+
+// TransportServers returns a TransportServerInterface for the namespace.
+func (c *ConfigurationV1alpha1Client) TransportServers(namespace string) TransportServerInterface {
+	return &transportServers{
+		client: c.restClient,
+		ns:     namespace,
+	}
+}
@@ -0,0 +1,59 @@
+package v1alpha1
+
+import (
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// TransportServerInterface has methods to work with TransportServer resources.
+type TransportServerInterface interface {
+	Get(name string, options meta_v1.GetOptions) (*v1alpha1.TransportServer, error)
+	List(opts meta_v1.ListOptions) (*v1alpha1.TransportServerList, error)
+	UpdateStatus(ts *v1alpha1.TransportServer) (*v1alpha1.TransportServer, error)
+}
+
+// transportServers implements TransportServerInterface
+type transportServers struct {
+	client rest.Interface
+	ns     string
+}
+
+// Get takes name of the TransportServer, and returns the corresponding TransportServer object, and an error if there is any.
+func (c *transportServers) Get(name string, options meta_v1.GetOptions) (*v1alpha1.TransportServer, error) {
+	result := &v1alpha1.TransportServer{}
+	err := c.client.Get().
+		Namespace(c.ns).
+		Resource("transportservers").
+		Name(name).
+		VersionedParams(&options, ParameterCodec).
+		Do().
+		Into(result)
+	return result, err
+}
+
+// List takes label and field selectors, and returns the list of TransportServers that match those selectors.
+func (c *transportServers) List(opts meta_v1.ListOptions) (*v1alpha1.TransportServerList, error) {
+	result := &v1alpha1.TransportServerList{}
+	err := c.client.Get().
+		Namespace(c.ns).
+		Resource("transportservers").
+		VersionedParams(&opts, ParameterCodec).
+		Do().
+		Into(result)
+	return result, err
+}
+
+// UpdateStatus updates the status subresource of the TransportServer and returns the server's representation of it.
+func (c *transportServers) UpdateStatus(ts *v1alpha1.TransportServer) (*v1alpha1.TransportServer, error) {
+	result := &v1alpha1.TransportServer{}
+	err := c.client.Put().
+		Namespace(c.ns).
+		Resource("transportservers").
+		Name(ts.Name).
+		SubResource("status").
+		Body(ts).
+		Do().
+		Into(result)
+	return result, err
+}
@@ -0,0 +1,59 @@
+package v1alpha1
+
+import (
+	"github.com/nginxinc/kubernetes-ingress/pkg/apis/configuration/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// VirtualServerInterface has methods to work with VirtualServer resources.
+type VirtualServerInterface interface {
+	Get(name string, options meta_v1.GetOptions) (*v1alpha1.VirtualServer, error)
+	List(opts meta_v1.ListOptions) (*v1alpha1.VirtualServerList, error)
+	UpdateStatus(vs *v1alpha1.VirtualServer) (*v1alpha1.VirtualServer, error)
+}
+
+// virtualServers implements VirtualServerInterface
+type virtualServers struct {
+	client rest.Interface
+	ns     string
+}
+
+// Get takes name of the VirtualServer, and returns the corresponding VirtualServer object, and an error if there is any.
+func (c *virtualServers) Get(name string, options meta_v1.GetOptions) (*v1alpha1.VirtualServer, error) {
+	result := &v1alpha1.VirtualServer{}
+	err := c.client.Get().
+		Namespace(c.ns).
+		Resource("virtualservers").
+		Name(name).
+		VersionedParams(&options, ParameterCodec).
+		Do().
+		Into(result)
+	return result, err
+}
+
+// List takes label and field selectors, and returns the list of VirtualServers that match those selectors.
+func (c *virtualServers) List(opts meta_v1.ListOptions) (*v1alpha1.VirtualServerList, error) {
+	result := &v1alpha1.VirtualServerList{}
+	err := c.client.Get().
+		Namespace(c.ns).
+		Resource("virtualservers").
+		VersionedParams(&opts, ParameterCodec).
+		Do().
+		Into(result)
+	return result, err
+}
+
+// UpdateStatus updates the status subresource of the VirtualServer and returns the server's representation of it.
+func (c *virtualServers) UpdateStatus(vs *v1alpha1.VirtualServer) (*v1alpha1.VirtualServer, error) {
+	result := &v1alpha1.VirtualServer{}
+	err := c.client.Put().
+		Namespace(c.ns).
+		Resource("virtualservers").
+		Name(vs.Name).
+		SubResource("status").
+		Body(vs).
+		Do().
+		Into(result)
+	return result, err
+}